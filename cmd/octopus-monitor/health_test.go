@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/soothill/octopus-home-mini/pkg/config"
+	"github.com/soothill/octopus-home-mini/pkg/health"
+)
+
+func TestStartHealthServer(t *testing.T) {
+	// Reserve a free port, then release it for the health server
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	cfg := &config.Config{HealthServerEnabled: false, HealthServerAddr: addr}
+	server := health.NewServer(addr, "test")
+	if startHealthServer(cfg, server) {
+		t.Fatal("startHealthServer() = true with the health server disabled")
+	}
+	if conn, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+		conn.Close()
+		t.Fatalf("health server listening on %s while disabled", addr)
+	}
+
+	cfg.HealthServerEnabled = true
+	if !startHealthServer(cfg, server) {
+		t.Fatal("startHealthServer() = false with the health server enabled")
+	}
+	defer server.Stop(context.Background())
+
+	// The server listens from a goroutine, so allow it a moment
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("health server not listening on %s when enabled: %v", addr, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -2,7 +2,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"strings"
@@ -18,16 +22,65 @@ import (
 	"github.com/soothill/octopus-home-mini/pkg/health"
 	"github.com/soothill/octopus-home-mini/pkg/influx"
 	"github.com/soothill/octopus-home-mini/pkg/monitor"
+	"github.com/soothill/octopus-home-mini/pkg/notify"
 	"github.com/soothill/octopus-home-mini/pkg/octopus"
+	"github.com/soothill/octopus-home-mini/pkg/parquetsink"
+	"github.com/soothill/octopus-home-mini/pkg/reconcile"
 	"github.com/soothill/octopus-home-mini/pkg/slack"
+	"github.com/soothill/octopus-home-mini/pkg/tracing"
+	"github.com/soothill/octopus-home-mini/pkg/version"
+	"github.com/soothill/octopus-home-mini/pkg/weather"
+)
+
+const (
+	// recentStatsWindow is how far back /stats reads stored data from InfluxDB
+	recentStatsWindow = 24 * time.Hour
+	// recentStatsTimeout bounds the InfluxDB query behind each /stats request
+	recentStatsTimeout = 5 * time.Second
 )
 
 func main() {
+	cacheInfo := flag.Bool("cache-info", false, "Print details of cache files and exit")
+	exportLP := flag.Bool("export-lp", false, "Write cached points to stdout as InfluxDB line protocol and exit")
+	reconcileRange := flag.Bool("reconcile", false, "Compare points stored in InfluxDB between the start and end arguments with those expected, print the gaps and exit")
+	replayFile := flag.String("replay", "", "Write the readings recorded in a JSONL or CSV file through the write and cache pipeline instead of polling the Octopus API, then exit")
+	replaySpeed := flag.Float64("replay-speed", 1, "Time compression for --replay: 60 replays an hour of readings a minute, 0 as fast as possible")
+	showVersion := flag.Bool("version", false, "Print version information and exit")
+	validateConfig := flag.Bool("validate-config", false, "Load and validate configuration, print the result and exit")
+	printSchema := flag.Bool("print-schema", false, "Print a JSON Schema for config.yaml and exit")
+	flag.Parse()
+
+	if *showVersion {
+		version.Print(os.Stdout)
+		return
+	}
+
+	if *printSchema {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(config.Schema()); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to print schema: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *validateConfig {
+		if !runValidateConfig(os.Stdout) {
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Configure logger
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 
-	log.Info().Msg("Starting Octopus Home Mini Monitor...")
+	log.Info().
+		Str("version", version.Version).
+		Str("commit", version.Commit).
+		Str("build_date", version.BuildDate).
+		Msg("Starting Octopus Home Mini Monitor...")
 
 	// Load configuration
 	cfg, err := config.Load()
@@ -42,6 +95,47 @@ func main() {
 		logLevel = zerolog.InfoLevel
 	}
 	zerolog.SetGlobalLevel(logLevel)
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeLocation: cfg.DisplayLocation()})
+
+	// Inspect cache files without starting the monitor
+	if *cacheInfo {
+		if err := runCacheInfo(os.Stdout, cfg.CacheDir, cfg.DisplayLocation()); err != nil {
+			log.Fatal().Err(err).Msg("Failed to inspect cache")
+		}
+		return
+	}
+
+	// Export cached points for `influx write` or archival without starting the monitor
+	if *exportLP {
+		count, err := runExportLineProtocol(os.Stdout, cfg)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to export cache")
+		}
+		log.Info().Int("count", count).Msg("Exported cached points as line protocol")
+		return
+	}
+
+	// Report gaps in stored data without starting the monitor
+	if *reconcileRange {
+		if err := runReconcile(os.Stdout, cfg, flag.Args()); err != nil {
+			log.Fatal().Err(err).Msg("Failed to reconcile stored data")
+		}
+		return
+	}
+
+	// Replay recorded readings without calling the Octopus API
+	if *replayFile != "" {
+		count, err := runReplay(cfg, *replayFile, *replaySpeed)
+		if err != nil {
+			log.Fatal().Err(err).Int("count", count).Msg("Failed to replay recorded telemetry")
+		}
+		log.Info().Int("count", count).Msg("Replayed recorded telemetry")
+		return
+	}
+
+	for _, warning := range cfg.CardinalityWarnings() {
+		log.Warn().Str("check", "tag_cardinality").Msg(warning)
+	}
 
 	// Validate runtime configuration
 	ctx := context.Background()
@@ -53,25 +147,77 @@ func main() {
 			log.Fatal().Err(err).Msg("Runtime validation failed")
 		}
 	}
-	log.Info().Msg("Configuration validated successfully")
+	log.Info().
+		Str("version", version.Version).
+		Fields(cfg.StartupSummary()).
+		Msg("Configuration validated successfully")
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.OTelEnabled, version.Version)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize tracing")
+	}
+	if cfg.OTelEnabled {
+		log.Info().Msg("OpenTelemetry tracing enabled")
+	}
 
 	// Initialize cache
-	cacheStore, err := cache.NewCache(cfg.CacheDir)
+	cacheFileMode, cacheDirMode := cfg.CacheModes()
+	cacheStore, err := cache.NewCacheWithMode(cfg.CacheDir, cacheFileMode, cacheDirMode)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to initialize cache")
 	}
+	cacheStore.SetLocation(cfg.DisplayLocation())
 
-	// Initialize Slack notifier (may be nil if not configured)
+	// Initialize notifier (no-op if Slack is not configured)
+	var notifier notify.Notifier = notify.Nop{}
 	var slackNotifier *slack.Notifier
 	if cfg.SlackEnabled {
 		slackNotifier = slack.NewNotifier(cfg.SlackWebhookURL)
-		log.Info().Msg("Slack notifications enabled")
-	} else {
-		log.Info().Msg("Slack notifications disabled")
+		slackNotifier.SetSeverityWebhooks(cfg.SlackWebhookError, cfg.SlackWebhookInfo)
+		slackNotifier.SetMaxTextLength(cfg.SlackMaxMessageLength)
+		if proxyURL := cfg.Proxy(); proxyURL != nil {
+			slackNotifier.SetProxy(proxyURL)
+		}
+		notifier = slackNotifier
 	}
+	meteredNotifier := notify.NewMetered(notifier)
+	notifier = meteredNotifier
 
 	// Initialize Octopus client
 	octopusClient := octopus.NewClient(cfg.OctopusAPIKey, cfg.OctopusAccountNumber)
+	octopusClient.SetRetryBudget(cfg.OctopusMaxRetryElapsed, cfg.OctopusMaxRetryInterval)
+	octopusClient.SetRequestTimeout(cfg.OctopusRequestTimeout)
+	octopusClient.SetTransportTimeouts(octopus.TransportTimeouts{
+		Dial:           cfg.OctopusDialTimeout,
+		TLSHandshake:   cfg.OctopusTLSHandshakeTimeout,
+		ResponseHeader: cfg.OctopusResponseHeaderTimeout,
+	})
+	octopusClient.SetGrouping(cfg.TelemetryGrouping)
+	octopusClient.SetTimestampLayouts(cfg.TimestampLayouts())
+	if proxyURL := cfg.Proxy(); proxyURL != nil {
+		octopusClient.SetProxy(proxyURL)
+		log.Info().Str("proxy", proxyURL.Redacted()).Msg("Using outbound proxy")
+	}
+	if cfg.AuditResponses {
+		if err := octopusClient.SetAudit(cfg.AuditDir(), cfg.AuditRetention); err != nil {
+			log.Warn().Err(err).Msg("Failed to enable telemetry response auditing")
+		} else {
+			log.Info().Str("dir", cfg.AuditDir()).Int("retention", cfg.AuditRetention).Msg("Telemetry response auditing enabled")
+		}
+	}
+
+	if cfg.OctopusPersistToken {
+		if err := octopusClient.SetTokenFile(cfg.TokenFile()); err != nil {
+			log.Warn().Err(err).Msg("Failed to enable API token persistence")
+		}
+	}
+	// Validated above, so only a file changed since then can fail here
+	if token, err := cfg.ProvidedOctopusToken(); err != nil {
+		log.Warn().Err(err).Msg("Failed to read provided Octopus API token, authenticating with the API key")
+	} else if token != "" {
+		octopusClient.SetToken(token)
+		log.Info().Msg("Using provided Octopus API token")
+	}
 
 	// Authenticate and get meter GUID
 	authCtx := context.Background()
@@ -81,60 +227,56 @@ func main() {
 
 	log.Info().Msg("Octopus client initialized successfully")
 
-	// Create InfluxDB error handler that sends Slack notifications
-	influxErrorHandler := func(err error) {
-		log.Error().Err(err).Msg("InfluxDB write error")
-		if slackNotifier != nil {
-			if err := slackNotifier.SendError("InfluxDB Write", fmt.Sprintf("Async write failed: %v", err)); err != nil {
-				log.Error().Err(err).Msg("Error sending Slack error notification for InfluxDB")
-			}
-		}
-	}
-
-	// Initialize InfluxDB client with error handler and exponential backoff
+	// Initialize the data sink
 	var influxClient *influx.Client
-	expBackoff := backoff.NewExponentialBackOff()
-	expBackoff.MaxElapsedTime = cfg.InfluxConnectTimeout
-	expBackoff.InitialInterval = 1 * time.Second
-	expBackoff.MaxInterval = 5 * time.Second
-	expBackoff.Multiplier = 2.0
-
-	operation := func() error {
-		var err error
-		influxClient, err = influx.NewClientWithErrorHandler(
-			cfg.InfluxDBURL,
-			cfg.InfluxDBToken,
-			cfg.InfluxDBOrg,
-			cfg.InfluxDBBucket,
-			cfg.InfluxDBMeasurement,
-			influxErrorHandler,
-		)
-		return err
-	}
-
-	err = backoff.Retry(operation, expBackoff)
-	if err != nil {
-		log.Warn().Err(err).Msg("Failed to connect to InfluxDB after retries. Will cache data locally.")
-		if slackNotifier != nil {
-			if err := slackNotifier.SendWarning("InfluxDB", fmt.Sprintf("Failed to connect to InfluxDB: %v. Caching data locally.", err)); err != nil {
-				log.Error().Err(err).Msg("Error sending Slack warning notification for InfluxDB connection failure")
-			}
+	var parquetSink *parquetsink.Sink
+	if cfg.InfluxDBEnabled() {
+		influxClient = connectInflux(cfg, octopusClient, notifier)
+		if influxClient != nil {
+			defer influxClient.Close()
 		}
 	} else {
-		log.Info().Msg("InfluxDB client initialized successfully")
-		defer influxClient.Close()
+		parquetSink, err = parquetsink.NewSink(cfg.ParquetDir, cfg.ParquetFlushInterval)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to initialize Parquet sink")
+		}
+		log.Info().Str("dir", cfg.ParquetDir).Dur("flush_interval", cfg.ParquetFlushInterval).Msg("Writing data to Parquet files")
 	}
 
 	// Create monitor
-	appMonitor := monitor.New(cfg, octopusClient, influxClient, cacheStore, slackNotifier)
+	appMonitor := monitor.New(cfg, octopusClient, influxClient, cacheStore, notifier)
+	appMonitor.ParquetSink = parquetSink
+	if cfg.DedupWatermark {
+		if err := appMonitor.SetWatermarkStore(cache.NewFileWatermarkStore(cfg.WatermarkFile())); err != nil {
+			log.Warn().Err(err).Msg("Failed to load dedup watermark, not skipping already-stored readings")
+		}
+	}
+	if cfg.TransitionLog != "" {
+		transitionLog, closeTransitionLog, err := openTransitionLog(cfg.TransitionLog)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to open transition log, not logging state transitions")
+		} else {
+			defer closeTransitionLog()
+			appMonitor.SetTransitionLog(transitionLog)
+			log.Info().Str("target", cfg.TransitionLog).Msg("Logging state transitions")
+		}
+	}
+	// After the watermark, which tells an upgraded install it has already stored data
+	if cfg.OnboardingEnabled {
+		if err := appMonitor.SetOnboardingStore(cache.NewFileOnboardingStore(cfg.OnboardingFile())); err != nil {
+			log.Warn().Err(err).Msg("Failed to load onboarding state, not tracking the first data point")
+		}
+	}
 
 	// Initialize and start health check server
-	healthServer := health.NewServer(cfg.HealthServerAddr, "1.0.0")
+	healthServer := health.NewServer(cfg.HealthServerAddr, version.Version)
+	healthServer.SetBuildInfo(version.Commit, version.BuildDate)
+	healthServer.SetMaxConcurrentChecks(cfg.HealthMaxConcurrentChecks)
 
 	// Register health checkers
 	if influxClient != nil {
 		healthServer.RegisterChecker("influxdb", health.ContextChecker("InfluxDB", func(ctx context.Context) error {
-			return influxClient.CheckConnection(ctx)
+			return influxClient.CheckHealth(ctx)
 		}))
 	}
 
@@ -147,6 +289,13 @@ func main() {
 		return nil
 	}))
 
+	if slackNotifier != nil && cfg.SlackHealthCheckEnabled {
+		// Slack is not critical to data flow, so a broken webhook only degrades readiness
+		healthServer.RegisterChecker("slack", health.DegradedChecker("Slack", func(ctx context.Context) error {
+			return slackNotifier.CheckWebhook(ctx, cfg.SlackHealthCheckInterval)
+		}))
+	}
+
 	healthServer.RegisterChecker("cache", health.SimpleChecker("Cache", func() error {
 		// Check if cache is accessible
 		if cacheStore == nil {
@@ -155,12 +304,56 @@ func main() {
 		return nil
 	}))
 
-	if err := healthServer.Start(); err != nil {
-		log.Warn().Err(err).Msg("Failed to start health server")
+	healthServer.RegisterStats("sizing", func() interface{} {
+		return map[string]interface{}{
+			"telemetry_grouping":       cfg.TelemetryGrouping,
+			"estimated_points_per_day": cfg.EstimatedPointsPerDay(),
+		}
+	})
+
+	healthServer.RegisterStats("telemetry", func() interface{} {
+		stats := map[string]interface{}{
+			"octopus_telemetry_readings_skipped_total": octopusClient.SkippedReadingCount(),
+		}
+		if influxClient != nil {
+			stats["octopus_influx_nan_fields_dropped_total"] = influxClient.DroppedFieldCount()
+			stats["octopus_influx_nan_points_dropped_total"] = influxClient.DroppedPointCount()
+		}
+		return stats
+	})
+
+	healthServer.RegisterStats("cache_sync", func() interface{} {
+		return appMonitor.CacheSyncStats()
+	})
+	healthServer.RegisterMetrics("cache_sync", func(w io.Writer) {
+		appMonitor.CacheSyncStats().WriteMetrics(w)
+	})
+
+	healthServer.RegisterStats("notifications", func() interface{} {
+		return meteredNotifier.Stats()
+	})
+
+	healthServer.RegisterStats("recent", func() interface{} {
+		ctx, cancel := context.WithTimeout(context.Background(), recentStatsTimeout)
+		defer cancel()
+		return appMonitor.RecentStats(ctx, recentStatsWindow)
+	})
+
+	if cfg.DebugAuthUsername != "" {
+		healthServer.SetDebugAuth(cfg.DebugAuthUsername, cfg.DebugAuthPassword)
 	}
+	if cfg.DebugEndpointsEnabled {
+		healthServer.SetErrorsProvider(func() interface{} {
+			return appMonitor.LastErrors()
+		})
+		healthServer.SetTestAlertSender(appMonitor.SendTestAlert)
+		log.Info().Msg("Debug endpoints enabled")
+	}
+
+	healthServerStarted := startHealthServer(cfg, healthServer)
 
 	// Send startup notification
-	appMonitor.SendSlackInfo("Monitor Started", "Octopus Home Mini monitor has started successfully")
+	appMonitor.NotifyInfo("Monitor Started", "Octopus Home Mini monitor has started successfully")
 
 	// Try to sync any cached data on startup
 	appMonitor.SyncCache()
@@ -192,6 +385,35 @@ func main() {
 			Msg("Cache cleanup enabled")
 	}
 
+	// Start outdoor temperature collection if enabled
+	if cfg.WeatherEnabled {
+		provider, err := weather.NewProvider(cfg.WeatherProvider, cfg.WeatherAPIKey, cfg.WeatherLatitude, cfg.WeatherLongitude)
+		if err != nil {
+			log.Warn().Err(err).Msg("Weather integration disabled")
+		} else {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				appMonitor.RunWeather(stopChan, provider)
+			}()
+			log.Info().
+				Str("provider", cfg.WeatherProvider).
+				Dur("interval", cfg.WeatherInterval).
+				Str("measurement", cfg.WeatherMeasurement).
+				Msg("Weather integration enabled")
+		}
+	}
+
+	// Start data freshness watchdog if enabled
+	if cfg.MaxDataStaleness > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runStalenessWatchdog(appMonitor, cfg.MaxDataStaleness, stopChan)
+		}()
+		log.Info().Dur("max_staleness", cfg.MaxDataStaleness).Msg("Data freshness watchdog enabled")
+	}
+
 	// Wait for shutdown signal
 	<-sigChan
 	log.Info().Msg("Shutdown signal received, stopping monitor...")
@@ -200,6 +422,13 @@ func main() {
 	signal.Stop(sigChan)
 	close(sigChan)
 
+	// Stop new polls and cleanups, and let any in progress finish their writes
+	if appMonitor.Drain(cfg.ShutdownTimeout) {
+		log.Info().Msg("In-flight work drained")
+	} else {
+		log.Warn().Dur("timeout", cfg.ShutdownTimeout).Msg("Timed out waiting for in-flight work to finish")
+	}
+
 	// Signal goroutines to stop
 	close(stopChan)
 
@@ -225,25 +454,390 @@ func main() {
 
 	// Send shutdown notification
 	if appMonitor.Cache.Count() > 0 {
-		appMonitor.SendSlackWarning("Monitor Stopped", fmt.Sprintf("Monitor stopped with %d data points in cache", appMonitor.Cache.Count()))
+		appMonitor.NotifyWarning("Monitor Stopped", fmt.Sprintf("Monitor stopped with %d data points in cache", appMonitor.Cache.Count()))
 	} else {
-		appMonitor.SendSlackInfo("Monitor Stopped", "Monitor stopped gracefully")
+		appMonitor.NotifyInfo("Monitor Stopped", "Monitor stopped gracefully")
 	}
 
-	// Give Slack notification time to send
-	time.Sleep(500 * time.Millisecond)
-
 	// Stop health check server
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdownCancel()
-	if err := healthServer.Stop(shutdownCtx); err != nil {
-		log.Error().Err(err).Msg("Error stopping health server")
+	if healthServerStarted {
+		if err := healthServer.Stop(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("Error stopping health server")
+		}
 	}
 
 	// Cleanup resources
+	if parquetSink != nil {
+		if err := parquetSink.Close(); err != nil {
+			log.Error().Err(err).Int("buffered", parquetSink.Buffered()).Msg("Failed to flush Parquet data on shutdown")
+		}
+	}
 	if slackNotifier != nil {
 		slackNotifier.Close()
 	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Failed to flush traces on shutdown")
+	}
 
 	log.Info().Msg("Monitor stopped")
 }
+
+// connectInflux creates the InfluxDB client with exponential backoff. It returns nil if
+// InfluxDB is unreachable, in which case the monitor starts in cache mode. Meter tags are
+// left off when octopusClient is nil.
+func connectInflux(cfg *config.Config, octopusClient *octopus.Client, notifier notify.Notifier) *influx.Client {
+	// Create InfluxDB error handler that sends notifications
+	influxErrorHandler := func(err error) {
+		log.Error().Err(err).Msg("InfluxDB write error")
+		if err := notifier.SendError("InfluxDB Write", fmt.Sprintf("Async write failed: %v", err)); err != nil {
+			log.Error().Err(err).Msg("Error sending error notification for InfluxDB")
+		}
+	}
+
+	influxTLSConfig, err := cfg.InfluxTLSConfig()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid InfluxDB TLS configuration")
+	}
+	if cfg.InfluxTLSInsecureSkipVerify {
+		log.Warn().Msg("INFLUX_TLS_INSECURE_SKIP_VERIFY is enabled: InfluxDB certificates are NOT verified. Use INFLUX_CA_CERT_PATH instead where possible.")
+	}
+
+	// Initialize InfluxDB client with error handler and exponential backoff
+	var influxClient *influx.Client
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.MaxElapsedTime = cfg.InfluxConnectTimeout
+	expBackoff.InitialInterval = 1 * time.Second
+	expBackoff.MaxInterval = 5 * time.Second
+	expBackoff.Multiplier = 2.0
+
+	operation := func() error {
+		var err error
+		influxClient, err = influx.NewClientWithOptions(
+			cfg.InfluxDBURL,
+			cfg.InfluxDBToken,
+			cfg.InfluxDBOrg,
+			cfg.InfluxDBBucket,
+			cfg.InfluxDBMeasurement,
+			influxErrorHandler,
+			influx.Options{
+				ProxyURL:            cfg.Proxy(),
+				TLSConfig:           influxTLSConfig,
+				MaxIdleConns:        cfg.InfluxMaxIdleConns,
+				MaxIdleConnsPerHost: cfg.InfluxMaxIdleConnsPerHost,
+				IdleConnTimeout:     cfg.InfluxIdleConnTimeout,
+				KeepAlive:           cfg.InfluxKeepAlive,
+			},
+		)
+		if err != nil {
+			return err
+		}
+		if err := checkInfluxOrgAndBucket(cfg, influxClient); err != nil {
+			influxClient.Close()
+			influxClient = nil
+			return err
+		}
+		return nil
+	}
+
+	if err := backoff.Retry(operation, expBackoff); err != nil {
+		log.Warn().Err(err).Msg("Failed to connect to InfluxDB after retries. Will cache data locally.")
+		if err := notifier.SendWarning("InfluxDB", fmt.Sprintf("Failed to connect to InfluxDB: %v. Caching data locally.", err)); err != nil {
+			log.Error().Err(err).Msg("Error sending warning notification for InfluxDB connection failure")
+		}
+	} else {
+		log.Info().Msg("InfluxDB client initialized successfully")
+		if cfg.InfluxCreateBucket {
+			ensureInfluxBucket(cfg, influxClient)
+		}
+		influxClient.SetBackpressure(cfg.InfluxBackpressureEnabled, cfg.InfluxBackpressureMaxWait)
+		influxClient.SetExportFields(cfg.InfluxDBExportFields)
+		influxClient.SetIdempotentWrites(cfg.InfluxDBIdempotentWrites)
+		if cfg.ConsumptionUnit != config.ConsumptionUnitKWh {
+			influxClient.SetConsumptionUnit(cfg.ConsumptionUnit, cfg.ConsumptionScale())
+		}
+		influxClient.SetHealthCacheTTL(cfg.InfluxHealthCacheTTL)
+		// Validated at load, so the error is already ruled out
+		tags, _ := cfg.ExtraTags()
+		if cfg.InfluxDBMeterTags && octopusClient != nil {
+			tags["mpan"] = octopusClient.MPAN()
+			tags["meter_serial"] = octopusClient.MeterSerial()
+		}
+		influxClient.SetExtraTags(tags)
+	}
+
+	return influxClient
+}
+
+// checkInfluxOrgAndBucket confirms the configured org and bucket exist so a typo is
+// reported as such rather than as failing writes. An org or bucket that is definitely
+// missing stops the retries; other failures, such as an unreachable server, are retried.
+// A missing bucket is fine when it will be created. Tokens that cannot list orgs or
+// buckets, including write-only tokens that just see empty results, skip the check.
+func checkInfluxOrgAndBucket(cfg *config.Config, influxClient *influx.Client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.InfluxWriteTimeout)
+	defer cancel()
+
+	err := influxClient.CheckOrgAndBucket(ctx)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, influx.ErrBucketNotFound) && cfg.InfluxCreateBucket:
+		return nil
+	case errors.Is(err, influx.ErrBucketPermission), errors.Is(err, influx.ErrNotVisible):
+		log.Warn().Err(err).Msg("Cannot verify the InfluxDB org and bucket with this token, skipping the check")
+		return nil
+	case errors.Is(err, influx.ErrOrgNotFound), errors.Is(err, influx.ErrBucketNotFound):
+		return backoff.Permanent(err)
+	default:
+		return err
+	}
+}
+
+// ensureInfluxBucket creates the configured bucket if it is missing. Failures are logged
+// rather than fatal, since the bucket may exist but be invisible to a write-only token.
+func ensureInfluxBucket(cfg *config.Config, influxClient *influx.Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.InfluxWriteTimeout)
+	defer cancel()
+
+	created, err := influxClient.EnsureBucket(ctx, cfg.InfluxBucketRetention)
+	switch {
+	case errors.Is(err, influx.ErrBucketPermission):
+		log.Error().Err(err).Str("bucket", cfg.InfluxDBBucket).
+			Msg("Cannot create InfluxDB bucket: the token needs org-admin rights. Create the bucket manually or disable INFLUX_CREATE_BUCKET.")
+	case err != nil:
+		log.Error().Err(err).Str("bucket", cfg.InfluxDBBucket).Msg("Failed to create InfluxDB bucket")
+	case created:
+		log.Info().Str("bucket", cfg.InfluxDBBucket).Dur("retention", cfg.InfluxBucketRetention).Msg("Created InfluxDB bucket")
+	}
+}
+
+// runStalenessWatchdog exits the process if no data has been written within maxStaleness,
+// so that an orchestrator can restart a wedged monitor. Polls that succeed with no new
+// data keep it from firing, as there was nothing to write.
+func runStalenessWatchdog(m *monitor.Monitor, maxStaleness time.Duration, stopChan chan struct{}) {
+	ticker := time.NewTicker(maxStaleness / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			staleness, stale := m.DataStaleness(time.Now(), maxStaleness)
+			if !stale {
+				continue
+			}
+
+			log.Error().
+				Dur("staleness", staleness).
+				Dur("max_staleness", maxStaleness).
+				Msg("No data written within staleness limit, exiting")
+			m.NotifyError("Watchdog", fmt.Sprintf("No data written for %s, exiting so the service can be restarted", staleness.Round(time.Second)))
+			os.Exit(1)
+		case <-stopChan:
+			return
+		}
+	}
+}
+
+// startHealthServer starts healthServer unless HEALTH_SERVER_ENABLED is false, reporting
+// whether it is listening. The monitor runs on without it either way.
+func startHealthServer(cfg *config.Config, healthServer *health.Server) bool {
+	if !cfg.HealthServerEnabled {
+		log.Info().Msg("Health server disabled")
+		return false
+	}
+	if err := healthServer.Start(); err != nil {
+		log.Warn().Err(err).Msg("Failed to start health server")
+		return false
+	}
+	return true
+}
+
+// openTransitionLog opens the TRANSITION_LOG target: "stdout", "stderr" or a file that
+// entries are appended to. The returned close func is a no-op for the standard streams.
+func openTransitionLog(target string) (io.Writer, func() error, error) {
+	switch target {
+	case "stdout":
+		return os.Stdout, func() error { return nil }, nil
+	case "stderr":
+		return os.Stderr, func() error { return nil }, nil
+	}
+
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open transition log: %w", err)
+	}
+	return file, file.Close, nil
+}
+
+// runReplay writes the readings recorded in path through the write and cache pipeline
+// without calling the Octopus API, at speed times the pace they were recorded at, and
+// returns how many were replayed. Alerts are not sent.
+func runReplay(cfg *config.Config, path string, speed float64) (int, error) {
+	if speed < 0 {
+		return 0, fmt.Errorf("--replay-speed must not be negative, got %v", speed)
+	}
+	readings, err := monitor.LoadReplayFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	cacheFileMode, cacheDirMode := cfg.CacheModes()
+	cacheStore, err := cache.NewCacheWithMode(cfg.CacheDir, cacheFileMode, cacheDirMode)
+	if err != nil {
+		return 0, err
+	}
+
+	var influxClient *influx.Client
+	var parquetSink *parquetsink.Sink
+	if cfg.InfluxDBEnabled() {
+		influxClient = connectInflux(cfg, nil, notify.Nop{})
+		if influxClient != nil {
+			defer influxClient.Close()
+		}
+	} else {
+		parquetSink, err = parquetsink.NewSink(cfg.ParquetDir, cfg.ParquetFlushInterval)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	replayMonitor := monitor.New(cfg, nil, influxClient, cacheStore, notify.Nop{})
+	replayMonitor.ParquetSink = parquetSink
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	log.Info().Int("count", len(readings)).Float64("speed", speed).Str("file", path).Msg("Replaying recorded telemetry")
+	count, err := replayMonitor.Replay(ctx, readings, speed)
+
+	if parquetSink != nil {
+		if closeErr := parquetSink.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to flush Parquet data: %w", closeErr)
+		}
+	}
+	return count, err
+}
+
+// runCacheInfo prints details of the cache files in cacheDir, with times in loc, without
+// syncing or clearing anything
+func runCacheInfo(w io.Writer, cacheDir string, loc *time.Location) error {
+	cacheStore, err := cache.NewCache(cacheDir)
+	if err != nil {
+		return err
+	}
+
+	stats, err := cacheStore.Stats()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Cache directory: %s\n\n", cacheDir)
+	cache.PrintStats(w, stats, loc)
+	return nil
+}
+
+// runExportLineProtocol writes the cached points to w as line protocol under the configured
+// measurement, tagged and scaled to the consumption unit as the monitor writes its own.
+// Meter tags are left out, as they need the Octopus API.
+func runExportLineProtocol(w io.Writer, cfg *config.Config) (int, error) {
+	cacheStore, err := cache.NewCache(cfg.CacheDir)
+	if err != nil {
+		return 0, err
+	}
+	tags, err := cfg.ExtraTags()
+	if err != nil {
+		return 0, err
+	}
+	tags["source"] = "octopus_home_mini"
+	if cfg.ConsumptionUnit != config.ConsumptionUnitKWh {
+		tags["unit"] = cfg.ConsumptionUnit
+	}
+	return cacheStore.ExportLineProtocol(w, cfg.InfluxDBMeasurement, tags, cfg.ConsumptionScale())
+}
+
+// runReconcile compares the points stored in InfluxDB between the start and end in args
+// with those expected at the telemetry resolution, noting which gaps are still cached
+func runReconcile(w io.Writer, cfg *config.Config, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("--reconcile needs a start and end, got %d arguments", len(args))
+	}
+	if cfg.Sink != "" && cfg.Sink != config.SinkInfluxDB {
+		return fmt.Errorf("--reconcile needs the %s sink, got %s", config.SinkInfluxDB, cfg.Sink)
+	}
+	start, err := parseReconcileTime(args[0])
+	if err != nil {
+		return err
+	}
+	end, err := parseReconcileTime(args[1])
+	if err != nil {
+		return err
+	}
+
+	influxTLSConfig, err := cfg.InfluxTLSConfig()
+	if err != nil {
+		return fmt.Errorf("invalid InfluxDB TLS configuration: %w", err)
+	}
+	influxClient, err := influx.NewClientWithOptions(
+		cfg.InfluxDBURL,
+		cfg.InfluxDBToken,
+		cfg.InfluxDBOrg,
+		cfg.InfluxDBBucket,
+		cfg.InfluxDBMeasurement,
+		nil,
+		influx.Options{ProxyURL: cfg.Proxy(), TLSConfig: influxTLSConfig},
+	)
+	if err != nil {
+		return err
+	}
+	defer influxClient.Close()
+
+	cacheStore, err := cache.NewCache(cfg.CacheDir)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	report, err := reconcile.Run(ctx, influxClient, cacheStore, start, end, cfg.GroupingInterval())
+	if err != nil {
+		return err
+	}
+
+	report.Print(w)
+	return nil
+}
+
+// parseReconcileTime accepts an RFC 3339 timestamp or a UTC date
+func parseReconcileTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: want RFC 3339 (2006-01-02T15:04:05Z) or a date (2006-01-02)", value)
+}
+
+// runValidateConfig loads and validates the configuration, reporting pass or fail to w
+func runValidateConfig(w io.Writer) bool {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(w, "FAIL configuration: %v\n", err)
+		return false
+	}
+	fmt.Fprintln(w, "PASS configuration")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := cfg.ValidateRuntime(ctx); err != nil {
+		if strings.Contains(err.Error(), "warning") {
+			fmt.Fprintf(w, "WARN runtime: %v\n", err)
+			return true
+		}
+		fmt.Fprintf(w, "FAIL runtime: %v\n", err)
+		return false
+	}
+	fmt.Fprintln(w, "PASS runtime")
+	return true
+}
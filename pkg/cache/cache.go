@@ -1,14 +1,29 @@
 package cache
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"text/tabwriter"
 	"time"
 )
 
+// formatVersion is the current version of the persisted cache format.
+// Bump it and extend migratePoints when DataPoint gains fields that need conversion.
+const formatVersion = 1
+
+// fileEnvelope is the versioned on-disk representation of the cache
+type fileEnvelope struct {
+	Version int             `json:"version"`
+	Points  json.RawMessage `json:"points"`
+}
+
 // DataPoint represents a cached energy measurement
 type DataPoint struct {
 	Timestamp        time.Time `json:"timestamp"`
@@ -18,22 +33,62 @@ type DataPoint struct {
 	Consumption      float64   `json:"consumption"`
 }
 
+// FileInfo describes a single cache file on disk
+type FileInfo struct {
+	Path       string
+	Date       time.Time // Date encoded in the file name
+	Size       int64
+	PointCount int
+	Oldest     time.Time
+	Newest     time.Time
+}
+
+// Stats summarizes the cache files on disk
+type Stats struct {
+	Files       []FileInfo
+	TotalPoints int
+	TotalSize   int64
+	Oldest      time.Time
+	Newest      time.Time
+}
+
+// Default permissions for the cache directory and files, which hold consumption
+// data that should not be readable by other local users
+const (
+	DefaultDirMode  os.FileMode = 0700
+	DefaultFileMode os.FileMode = 0600
+)
+
 // Cache handles local storage of data points when InfluxDB is unavailable
 type Cache struct {
 	cacheDir string
+	fileMode os.FileMode
 	mu       sync.Mutex
 	data     []DataPoint
+	seqs     []uint64 // In-memory insertion sequence of each point in data, used by Snapshot
+	nextSeq  uint64
+	location *time.Location   // Timezone cache files are dated in
+	now      func() time.Time // Replaced in tests
 }
 
-// NewCache creates a new cache instance
+// NewCache creates a new cache instance readable only by the owner
 func NewCache(cacheDir string) (*Cache, error) {
-	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+	return NewCacheWithMode(cacheDir, DefaultFileMode, DefaultDirMode)
+}
+
+// NewCacheWithMode creates a new cache instance whose files are written with fileMode.
+// dirMode applies when the cache directory is created; an existing directory keeps its mode.
+func NewCacheWithMode(cacheDir string, fileMode, dirMode os.FileMode) (*Cache, error) {
+	if err := os.MkdirAll(cacheDir, dirMode); err != nil {
 		return nil, fmt.Errorf("failed to create cache directory: %w", err)
 	}
 
 	cache := &Cache{
 		cacheDir: cacheDir,
+		fileMode: fileMode,
 		data:     make([]DataPoint, 0),
+		location: time.Local,
+		now:      time.Now,
 	}
 
 	// Load existing cached data
@@ -51,6 +106,10 @@ func (c *Cache) Add(dataPoints []DataPoint) error {
 	defer c.mu.Unlock()
 
 	c.data = append(c.data, dataPoints...)
+	for range dataPoints {
+		c.seqs = append(c.seqs, c.nextSeq)
+		c.nextSeq++
+	}
 
 	return c.save()
 }
@@ -76,10 +135,53 @@ func (c *Cache) Clear() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.data = make([]DataPoint, 0)
+	c.setData(make([]DataPoint, 0))
 	return c.save()
 }
 
+// Prune removes all data points with a timestamp at or before upTo and persists
+// the result. It returns the number of points removed.
+func (c *Cache) Prune(upTo time.Time) (int, error) {
+	return c.removeWhere(func(dp DataPoint) bool {
+		return !dp.Timestamp.After(upTo)
+	})
+}
+
+// removeWhere drops the data points matching remove and saves the cache if any were dropped
+func (c *Cache) removeWhere(remove func(DataPoint) bool) (int, error) {
+	return c.removeWhereSeq(func(dp DataPoint, _ uint64) bool {
+		return remove(dp)
+	})
+}
+
+// removeWhereSeq is removeWhere with access to each point's insertion sequence
+func (c *Cache) removeWhereSeq(remove func(DataPoint, uint64) bool) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	remaining := make([]DataPoint, 0, len(c.data))
+	remainingSeqs := make([]uint64, 0, len(c.seqs))
+	for i, dp := range c.data {
+		if !remove(dp, c.seqs[i]) {
+			remaining = append(remaining, dp)
+			remainingSeqs = append(remainingSeqs, c.seqs[i])
+		}
+	}
+
+	removed := len(c.data) - len(remaining)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	c.data = remaining
+	c.seqs = remainingSeqs
+	if err := c.save(); err != nil {
+		return removed, err
+	}
+
+	return removed, nil
+}
+
 // Count returns the number of cached data points
 func (c *Cache) Count() int {
 	c.mu.Lock()
@@ -90,20 +192,49 @@ func (c *Cache) Count() int {
 
 // save persists the cache to disk
 func (c *Cache) save() error {
-	filename := filepath.Join(c.cacheDir, fmt.Sprintf("cache_%s.json", time.Now().Format("2006-01-02")))
+	filename := c.currentFile()
 
-	data, err := json.MarshalIndent(c.data, "", "  ")
+	data, err := encodePoints(c.data)
 	if err != nil {
 		return fmt.Errorf("failed to marshal cache data: %w", err)
 	}
 
-	if err := os.WriteFile(filename, data, 0644); err != nil {
+	if err := os.WriteFile(filename, data, c.fileMode); err != nil {
 		return fmt.Errorf("failed to write cache file: %w", err)
 	}
+	// WriteFile only applies the mode on creation and after the umask, so set it
+	// explicitly to also tighten files written by earlier versions
+	if err := os.Chmod(filename, c.fileMode); err != nil {
+		return fmt.Errorf("failed to set cache file permissions: %w", err)
+	}
 
 	return nil
 }
 
+// SetLocation dates cache files by the calendar day in loc rather than the host's local time
+func (c *Cache) SetLocation(loc *time.Location) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.location = loc
+}
+
+// currentFile returns the path of today's cache file, which save writes to.
+// The caller must hold mu.
+func (c *Cache) currentFile() string {
+	return filepath.Join(c.cacheDir, fmt.Sprintf("cache_%s.json", c.now().In(c.location).Format("2006-01-02")))
+}
+
+// setData replaces the cached points, assigning each a new insertion sequence.
+// The caller must hold mu.
+func (c *Cache) setData(points []DataPoint) {
+	c.data = points
+	c.seqs = make([]uint64, len(points))
+	for i := range points {
+		c.seqs[i] = c.nextSeq
+		c.nextSeq++
+	}
+}
+
 // Load loads cached data from disk
 func (c *Cache) Load() error {
 	c.mu.Lock()
@@ -117,7 +248,7 @@ func (c *Cache) Load() error {
 
 	if len(files) == 0 {
 		// No cache files found, start fresh
-		c.data = make([]DataPoint, 0)
+		c.setData(make([]DataPoint, 0))
 		return nil
 	}
 
@@ -129,13 +260,309 @@ func (c *Cache) Load() error {
 		return fmt.Errorf("failed to read cache file: %w", err)
 	}
 
-	if err := json.Unmarshal(data, &c.data); err != nil {
+	points, err := decodePoints(data)
+	if err != nil {
 		return fmt.Errorf("failed to unmarshal cache data: %w", err)
 	}
+	c.setData(points)
+
+	return nil
+}
+
+// encodePoints serializes data points in the current versioned format
+func encodePoints(points []DataPoint) ([]byte, error) {
+	raw, err := json.Marshal(points)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(fileEnvelope{
+		Version: formatVersion,
+		Points:  raw,
+	}, "", "  ")
+}
+
+// decodePoints parses cache data in either the legacy bare-array format or the
+// versioned envelope format, migrating older versions as needed
+func decodePoints(data []byte) ([]DataPoint, error) {
+	trimmed := bytes.TrimSpace(data)
+
+	// Legacy format: a bare JSON array of data points
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var points []DataPoint
+		if err := json.Unmarshal(trimmed, &points); err != nil {
+			return nil, err
+		}
+		return migratePoints(0, points)
+	}
+
+	var envelope fileEnvelope
+	if err := json.Unmarshal(trimmed, &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.Version < 1 || envelope.Version > formatVersion {
+		return nil, fmt.Errorf("unsupported cache format version %d", envelope.Version)
+	}
+
+	var points []DataPoint
+	if len(envelope.Points) > 0 {
+		if err := json.Unmarshal(envelope.Points, &points); err != nil {
+			return nil, err
+		}
+	}
+
+	return migratePoints(envelope.Version, points)
+}
+
+// migratePoints upgrades points read from an older format version to the current one.
+// Version 0 is the legacy bare-array format, which is field-compatible with version 1.
+func migratePoints(version int, points []DataPoint) ([]DataPoint, error) {
+	for v := version; v < formatVersion; v++ {
+		switch v {
+		case 0:
+			// v0 -> v1: envelope added, no field changes
+		default:
+			return nil, fmt.Errorf("no migration from cache format version %d", v)
+		}
+	}
+
+	if points == nil {
+		points = make([]DataPoint, 0)
+	}
+	return points, nil
+}
+
+// FlushToWriter serializes the current cache contents to w in the versioned JSON format.
+// This allows callers to back up the cache to external storage without the
+// cache knowing about the destination.
+func (c *Cache) FlushToWriter(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := encodePoints(c.data)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache data: %w", err)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write cache data: %w", err)
+	}
 
 	return nil
 }
 
+// LoadFromReader replaces the cache contents with data previously written by
+// FlushToWriter and persists the result to disk
+func (c *Cache) LoadFromReader(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read cache data: %w", err)
+	}
+
+	dataPoints, err := decodePoints(data)
+	if err != nil {
+		return fmt.Errorf("failed to decode cache data: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.setData(dataPoints)
+
+	return c.save()
+}
+
+// Stats inspects every cache file on disk and returns per-file and total details.
+// It does not modify the cache.
+func (c *Cache) Stats() (Stats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(c.cacheDir, "cache_*.json"))
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to list cache files: %w", err)
+	}
+
+	var stats Stats
+	for _, file := range files {
+		info, err := inspectFile(file)
+		if err != nil {
+			return Stats{}, err
+		}
+
+		stats.Files = append(stats.Files, info)
+		stats.TotalPoints += info.PointCount
+		stats.TotalSize += info.Size
+		if !info.Oldest.IsZero() && (stats.Oldest.IsZero() || info.Oldest.Before(stats.Oldest)) {
+			stats.Oldest = info.Oldest
+		}
+		if info.Newest.After(stats.Newest) {
+			stats.Newest = info.Newest
+		}
+	}
+
+	return stats, nil
+}
+
+// inspectFile reads a single cache file and summarizes its contents
+func inspectFile(path string) (FileInfo, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return FileInfo{}, fmt.Errorf("failed to stat cache file %s: %w", path, err)
+	}
+
+	info := FileInfo{
+		Path: path,
+		Size: fi.Size(),
+	}
+
+	name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "cache_"), ".json")
+	if date, err := time.Parse("2006-01-02", name); err == nil {
+		info.Date = date
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return FileInfo{}, fmt.Errorf("failed to read cache file %s: %w", path, err)
+	}
+
+	points, err := decodePoints(data)
+	if err != nil {
+		return FileInfo{}, fmt.Errorf("failed to unmarshal cache file %s: %w", path, err)
+	}
+
+	info.PointCount = len(points)
+	for _, dp := range points {
+		if info.Oldest.IsZero() || dp.Timestamp.Before(info.Oldest) {
+			info.Oldest = dp.Timestamp
+		}
+		if dp.Timestamp.After(info.Newest) {
+			info.Newest = dp.Timestamp
+		}
+	}
+
+	return info, nil
+}
+
+// PrintStats writes a table of cache files followed by totals, with point times in loc
+func PrintStats(w io.Writer, stats Stats, loc *time.Location) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tDATE\tPOINTS\tSIZE\tOLDEST\tNEWEST")
+	for _, file := range stats.Files {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\n",
+			filepath.Base(file.Path),
+			formatTime(file.Date, "2006-01-02"),
+			file.PointCount,
+			file.Size,
+			formatTime(file.Oldest.In(loc), time.RFC3339),
+			formatTime(file.Newest.In(loc), time.RFC3339),
+		)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nTotal: %d files, %d points, %d bytes\n", len(stats.Files), stats.TotalPoints, stats.TotalSize)
+	if stats.TotalPoints > 0 {
+		fmt.Fprintf(w, "Range: %s to %s\n", formatTime(stats.Oldest.In(loc), time.RFC3339), formatTime(stats.Newest.In(loc), time.RFC3339))
+	}
+}
+
+// formatTime formats t with layout, or "-" if t is unset
+func formatTime(t time.Time, layout string) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(layout)
+}
+
+// CleanupBySize removes the oldest cache files until the total size of the cache files is at
+// most maxBytes, regardless of age. Today's file holds the current cache contents and is never
+// removed, so the total can stay above maxBytes. It returns the number of files removed.
+func (c *Cache) CleanupBySize(maxBytes int64) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(c.cacheDir, "cache_*.json"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list cache files: %w", err)
+	}
+
+	sizes := make(map[string]int64, len(files))
+	var total int64
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		sizes[file] = info.Size()
+		total += info.Size()
+	}
+
+	// Date-stamped names sort chronologically
+	sort.Strings(files)
+	current := c.currentFile()
+	removed := 0
+	for _, file := range files {
+		if total <= maxBytes {
+			break
+		}
+		if file == current {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			return removed, fmt.Errorf("failed to remove cache file: %w", err)
+		}
+		total -= sizes[file]
+		removed++
+	}
+
+	return removed, nil
+}
+
+// Writable reports an error if a file cannot be created in the cache directory, e.g.
+// because its volume was unmounted or remounted read-only
+func (c *Cache) Writable() error {
+	f, err := os.CreateTemp(c.cacheDir, ".write_test-*")
+	if err != nil {
+		return fmt.Errorf("cache directory is not writable: %w", err)
+	}
+	name := f.Name()
+	f.Close()
+	os.Remove(name)
+	return nil
+}
+
+// Dir returns the directory holding the cache files
+func (c *Cache) Dir() string {
+	return c.cacheDir
+}
+
+// DropOldestFile removes the oldest cache file other than today's, which holds the
+// current cache contents. It returns the removed path, or "" if there was none to remove.
+func (c *Cache) DropOldestFile() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(c.cacheDir, "cache_*.json"))
+	if err != nil {
+		return "", fmt.Errorf("failed to list cache files: %w", err)
+	}
+
+	// Date-stamped names sort chronologically
+	sort.Strings(files)
+	current := c.currentFile()
+	for _, file := range files {
+		if file == current {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			return "", fmt.Errorf("failed to remove cache file: %w", err)
+		}
+		return file, nil
+	}
+
+	return "", nil
+}
+
 // CleanupOldFiles removes cache files older than the specified duration
 func (c *Cache) CleanupOldFiles(maxAge time.Duration) error {
 	files, err := filepath.Glob(filepath.Join(c.cacheDir, "cache_*.json"))
@@ -1,8 +1,10 @@
 package cache
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -64,6 +66,53 @@ func TestNewCache(t *testing.T) {
 	}
 }
 
+func TestNewCacheWithMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileMode os.FileMode
+		dirMode  os.FileMode
+	}{
+		{"owner only", DefaultFileMode, DefaultDirMode},
+		{"group read", 0640, 0750},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheDir := filepath.Join(t.TempDir(), "cache")
+
+			cache, err := NewCacheWithMode(cacheDir, tt.fileMode, tt.dirMode)
+			if err != nil {
+				t.Fatalf("NewCacheWithMode() error = %v", err)
+			}
+
+			// A file written by an earlier version is tightened on the next save
+			if err := os.WriteFile(cache.currentFile(), []byte("[]"), 0644); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			if err := cache.AddSingle(DataPoint{Timestamp: time.Now(), Demand: 100}); err != nil {
+				t.Fatalf("AddSingle() error = %v", err)
+			}
+
+			info, err := os.Stat(cache.currentFile())
+			if err != nil {
+				t.Fatalf("Stat() error = %v", err)
+			}
+			if got := info.Mode().Perm(); got != tt.fileMode {
+				t.Errorf("cache file mode = %o, want %o", got, tt.fileMode)
+			}
+
+			// The directory mode is subject to the umask, so only check nothing extra is granted
+			info, err = os.Stat(cacheDir)
+			if err != nil {
+				t.Fatalf("Stat() error = %v", err)
+			}
+			if got := info.Mode().Perm(); got&^tt.dirMode != 0 {
+				t.Errorf("cache directory mode = %o, want at most %o", got, tt.dirMode)
+			}
+		})
+	}
+}
+
 func TestCache_AddAndGetAll(t *testing.T) {
 	cacheDir := filepath.Join(os.TempDir(), "test_cache_add")
 	defer os.RemoveAll(cacheDir)
@@ -236,6 +285,34 @@ func TestCache_LoadAndSave(t *testing.T) {
 	}
 }
 
+func TestCache_FileDateInLocation(t *testing.T) {
+	cacheDir := t.TempDir()
+	c, err := NewCache(cacheDir)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	// 11:30 and 12:30 UTC fall either side of midnight in Auckland (UTC+12 in June)
+	auckland := time.FixedZone("NZST", 12*60*60)
+	c.SetLocation(auckland)
+
+	for _, tt := range []struct {
+		now  time.Time
+		want string
+	}{
+		{now: time.Date(2024, 6, 1, 11, 30, 0, 0, time.UTC), want: "cache_2024-06-01.json"},
+		{now: time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC), want: "cache_2024-06-02.json"},
+	} {
+		c.now = func() time.Time { return tt.now }
+		if err := c.AddSingle(DataPoint{Timestamp: tt.now, Demand: 100}); err != nil {
+			t.Fatalf("AddSingle() error = %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(cacheDir, tt.want)); err != nil {
+			t.Errorf("at %v: cache file %s not written: %v", tt.now, tt.want, err)
+		}
+	}
+}
+
 func TestCache_CleanupOldFiles(t *testing.T) {
 	cacheDir := filepath.Join(os.TempDir(), "test_cache_cleanup")
 	defer os.RemoveAll(cacheDir)
@@ -304,3 +381,489 @@ func TestCache_ConcurrentAccess(t *testing.T) {
 		t.Errorf("Count() = %d after concurrent writes, want 10", count)
 	}
 }
+
+func TestCache_FlushToWriterAndLoadFromReader(t *testing.T) {
+	srcDir := filepath.Join(os.TempDir(), "test_cache_flush_src")
+	dstDir := filepath.Join(os.TempDir(), "test_cache_flush_dst")
+	defer os.RemoveAll(srcDir)
+	defer os.RemoveAll(dstDir)
+
+	src, err := NewCache(srcDir)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	testData := []DataPoint{
+		{
+			Timestamp:        time.Now().Truncate(time.Second),
+			ConsumptionDelta: 0.5,
+			Demand:           1.2,
+			CostDelta:        0.15,
+			Consumption:      10.5,
+		},
+		{
+			Timestamp:        time.Now().Add(10 * time.Second).Truncate(time.Second),
+			ConsumptionDelta: 0.7,
+			Demand:           1.4,
+			CostDelta:        0.18,
+			Consumption:      11.2,
+		},
+	}
+	if err := src.Add(testData); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := src.FlushToWriter(&buf); err != nil {
+		t.Fatalf("FlushToWriter() error = %v", err)
+	}
+
+	dst, err := NewCache(dstDir)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	if err := dst.LoadFromReader(&buf); err != nil {
+		t.Fatalf("LoadFromReader() error = %v", err)
+	}
+
+	retrieved := dst.GetAll()
+	if len(retrieved) != len(testData) {
+		t.Fatalf("LoadFromReader() loaded %d items, want %d", len(retrieved), len(testData))
+	}
+	for i := range testData {
+		if !retrieved[i].Timestamp.Equal(testData[i].Timestamp) {
+			t.Errorf("item %d Timestamp = %v, want %v", i, retrieved[i].Timestamp, testData[i].Timestamp)
+		}
+		if retrieved[i].Consumption != testData[i].Consumption {
+			t.Errorf("item %d Consumption = %v, want %v", i, retrieved[i].Consumption, testData[i].Consumption)
+		}
+	}
+
+	// Loaded data should also be persisted to disk
+	reloaded, err := NewCache(dstDir)
+	if err != nil {
+		t.Fatalf("NewCache() reload error = %v", err)
+	}
+	if reloaded.Count() != len(testData) {
+		t.Errorf("reloaded Count() = %d, want %d", reloaded.Count(), len(testData))
+	}
+}
+
+func TestCache_LoadFromReader_InvalidData(t *testing.T) {
+	cacheDir := filepath.Join(os.TempDir(), "test_cache_load_invalid")
+	defer os.RemoveAll(cacheDir)
+
+	cache, err := NewCache(cacheDir)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	if err := cache.LoadFromReader(strings.NewReader("not json")); err == nil {
+		t.Error("LoadFromReader() expected error for invalid data, got nil")
+	}
+}
+
+func TestCache_Stats(t *testing.T) {
+	cacheDir := filepath.Join(os.TempDir(), "test_cache_stats")
+	defer os.RemoveAll(cacheDir)
+
+	cache, err := NewCache(cacheDir)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	content := `[
+		{"timestamp": "2024-03-01T10:00:00Z", "consumption": 1.0},
+		{"timestamp": "2024-03-01T09:00:00Z", "consumption": 2.0}
+	]`
+	if err := os.WriteFile(filepath.Join(cacheDir, "cache_2024-03-01.json"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write cache file: %v", err)
+	}
+
+	stats, err := cache.Stats()
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+
+	if len(stats.Files) != 1 {
+		t.Fatalf("Stats() returned %d files, want 1", len(stats.Files))
+	}
+
+	file := stats.Files[0]
+	if file.PointCount != 2 {
+		t.Errorf("PointCount = %d, want 2", file.PointCount)
+	}
+	if file.Date.Format("2006-01-02") != "2024-03-01" {
+		t.Errorf("Date = %v, want 2024-03-01", file.Date)
+	}
+	if !file.Oldest.Equal(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Oldest = %v, want 2024-03-01T09:00:00Z", file.Oldest)
+	}
+	if !file.Newest.Equal(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Newest = %v, want 2024-03-01T10:00:00Z", file.Newest)
+	}
+	if stats.TotalPoints != 2 || stats.TotalSize != file.Size {
+		t.Errorf("totals = %d points / %d bytes, want 2 points / %d bytes", stats.TotalPoints, stats.TotalSize, file.Size)
+	}
+}
+
+func TestPrintStats(t *testing.T) {
+	cacheDir := filepath.Join(os.TempDir(), "test_cache_print_stats")
+	defer os.RemoveAll(cacheDir)
+
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		t.Fatalf("Failed to create cache dir: %v", err)
+	}
+
+	files := map[string]string{
+		"cache_2024-01-01.json": `[
+			{"timestamp": "2024-01-01T10:00:00Z", "consumption": 1.0},
+			{"timestamp": "2024-01-01T12:00:00Z", "consumption": 2.0}
+		]`,
+		"cache_2024-01-02.json": `[
+			{"timestamp": "2024-01-02T08:30:00Z", "consumption": 3.0}
+		]`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(cacheDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write cache file: %v", err)
+		}
+	}
+
+	cache, err := NewCache(cacheDir)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	stats, err := cache.Stats()
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	PrintStats(&buf, stats, time.UTC)
+	output := buf.String()
+
+	wantContains := []string{
+		"cache_2024-01-01.json",
+		"cache_2024-01-02.json",
+		"2024-01-01T10:00:00Z",
+		"2024-01-01T12:00:00Z",
+		"Total: 2 files, 3 points",
+		"Range: 2024-01-01T10:00:00Z to 2024-01-02T08:30:00Z",
+	}
+	for _, want := range wantContains {
+		if !strings.Contains(output, want) {
+			t.Errorf("PrintStats() output missing %q\n%s", want, output)
+		}
+	}
+
+	// Inspection must not modify the cache files
+	for name, content := range files {
+		data, err := os.ReadFile(filepath.Join(cacheDir, name))
+		if err != nil {
+			t.Fatalf("Failed to read cache file: %v", err)
+		}
+		if string(data) != content {
+			t.Errorf("cache file %s was modified", name)
+		}
+	}
+}
+
+func TestCache_LoadLegacyFormat(t *testing.T) {
+	cacheDir := filepath.Join(os.TempDir(), "test_cache_legacy_format")
+	defer os.RemoveAll(cacheDir)
+
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		t.Fatalf("Failed to create cache dir: %v", err)
+	}
+
+	legacy := `[{"timestamp": "2024-01-01T10:00:00Z", "consumption_delta": 0.5, "consumption": 10.5}]`
+	if err := os.WriteFile(filepath.Join(cacheDir, "cache_2024-01-01.json"), []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write legacy cache file: %v", err)
+	}
+
+	cache, err := NewCache(cacheDir)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	retrieved := cache.GetAll()
+	if len(retrieved) != 1 {
+		t.Fatalf("Loaded %d items from legacy file, want 1", len(retrieved))
+	}
+	if retrieved[0].ConsumptionDelta != 0.5 || retrieved[0].Consumption != 10.5 {
+		t.Errorf("Loaded point = %+v, want consumption_delta 0.5 and consumption 10.5", retrieved[0])
+	}
+}
+
+func TestCache_LoadVersionedFormat(t *testing.T) {
+	cacheDir := filepath.Join(os.TempDir(), "test_cache_versioned_format")
+	defer os.RemoveAll(cacheDir)
+
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		t.Fatalf("Failed to create cache dir: %v", err)
+	}
+
+	v1 := `{"version": 1, "points": [{"timestamp": "2024-01-01T10:00:00Z", "demand": 1.2}]}`
+	if err := os.WriteFile(filepath.Join(cacheDir, "cache_2024-01-01.json"), []byte(v1), 0644); err != nil {
+		t.Fatalf("Failed to write v1 cache file: %v", err)
+	}
+
+	cache, err := NewCache(cacheDir)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	retrieved := cache.GetAll()
+	if len(retrieved) != 1 {
+		t.Fatalf("Loaded %d items from v1 file, want 1", len(retrieved))
+	}
+	if retrieved[0].Demand != 1.2 {
+		t.Errorf("Loaded Demand = %v, want 1.2", retrieved[0].Demand)
+	}
+
+	// Saving should always write the versioned envelope
+	if err := cache.AddSingle(DataPoint{Timestamp: time.Now(), Demand: 2.0}); err != nil {
+		t.Fatalf("AddSingle() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := cache.FlushToWriter(&buf); err != nil {
+		t.Fatalf("FlushToWriter() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"version": 1`) {
+		t.Errorf("FlushToWriter() output missing version marker:\n%s", buf.String())
+	}
+}
+
+func TestCache_LoadUnsupportedVersion(t *testing.T) {
+	if _, err := decodePoints([]byte(`{"version": 99, "points": []}`)); err == nil {
+		t.Error("decodePoints() expected error for unsupported version, got nil")
+	}
+}
+
+func TestCache_Prune(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	points := []DataPoint{
+		{Timestamp: base, Consumption: 1.0},
+		{Timestamp: base.Add(10 * time.Second), Consumption: 2.0},
+		{Timestamp: base.Add(20 * time.Second), Consumption: 3.0},
+	}
+
+	tests := []struct {
+		name          string
+		upTo          time.Time
+		wantRemoved   int
+		wantRemaining int
+	}{
+		{
+			name:          "prune subset",
+			upTo:          base.Add(10 * time.Second),
+			wantRemoved:   2,
+			wantRemaining: 1,
+		},
+		{
+			name:          "prune everything",
+			upTo:          base.Add(time.Minute),
+			wantRemoved:   3,
+			wantRemaining: 0,
+		},
+		{
+			name:          "prune nothing",
+			upTo:          base.Add(-time.Second),
+			wantRemoved:   0,
+			wantRemaining: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheDir := filepath.Join(os.TempDir(), "test_cache_prune")
+			defer os.RemoveAll(cacheDir)
+
+			cache, err := NewCache(cacheDir)
+			if err != nil {
+				t.Fatalf("NewCache() error = %v", err)
+			}
+			if err := cache.Add(points); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+
+			removed, err := cache.Prune(tt.upTo)
+			if err != nil {
+				t.Fatalf("Prune() error = %v", err)
+			}
+			if removed != tt.wantRemoved {
+				t.Errorf("Prune() removed = %d, want %d", removed, tt.wantRemoved)
+			}
+			if cache.Count() != tt.wantRemaining {
+				t.Errorf("Count() after Prune() = %d, want %d", cache.Count(), tt.wantRemaining)
+			}
+			for _, dp := range cache.GetAll() {
+				if !dp.Timestamp.After(tt.upTo) {
+					t.Errorf("point at %v should have been pruned", dp.Timestamp)
+				}
+			}
+
+			// Result should be persisted
+			reloaded, err := NewCache(cacheDir)
+			if err != nil {
+				t.Fatalf("NewCache() reload error = %v", err)
+			}
+			if reloaded.Count() != tt.wantRemaining {
+				t.Errorf("reloaded Count() = %d, want %d", reloaded.Count(), tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestCache_PruneConcurrentAdd(t *testing.T) {
+	cacheDir := filepath.Join(os.TempDir(), "test_cache_prune_concurrent")
+	defer os.RemoveAll(cacheDir)
+
+	cache, err := NewCache(cacheDir)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	old := time.Now().Add(-time.Hour)
+	for i := 0; i < 10; i++ {
+		if err := cache.AddSingle(DataPoint{Timestamp: old}); err != nil {
+			t.Fatalf("AddSingle() error = %v", err)
+		}
+	}
+
+	done := make(chan bool)
+	for i := 0; i < 10; i++ {
+		go func() {
+			cache.AddSingle(DataPoint{Timestamp: time.Now()})
+			done <- true
+		}()
+	}
+
+	removed, err := cache.Prune(old)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		<-done
+	}
+
+	if removed != 10 {
+		t.Errorf("Prune() removed = %d, want 10", removed)
+	}
+	if cache.Count() != 10 {
+		t.Errorf("Count() = %d, want 10 newer points to remain", cache.Count())
+	}
+}
+
+func TestCache_DropOldestFile(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewCache(dir)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	if err := cache.AddSingle(DataPoint{Timestamp: time.Now()}); err != nil {
+		t.Fatalf("AddSingle() error = %v", err)
+	}
+	for _, name := range []string{"cache_2024-01-02.json", "cache_2024-01-01.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(`[]`), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	for _, want := range []string{filepath.Join(dir, "cache_2024-01-01.json"), filepath.Join(dir, "cache_2024-01-02.json"), ""} {
+		dropped, err := cache.DropOldestFile()
+		if err != nil {
+			t.Fatalf("DropOldestFile() error = %v", err)
+		}
+		if dropped != want {
+			t.Errorf("DropOldestFile() = %q, want %q", dropped, want)
+		}
+	}
+
+	// Today's file holds the live cache and is never dropped
+	if _, err := os.Stat(cache.currentFile()); err != nil {
+		t.Errorf("current cache file removed: %v", err)
+	}
+}
+
+func TestCache_CleanupBySize(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewCache(dir)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	// Four 1000-byte files from a recent outage, all within any retention window
+	names := []string{"cache_2024-01-01.json", "cache_2024-01-02.json", "cache_2024-01-03.json", "cache_2024-01-04.json"}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), bytes.Repeat([]byte(" "), 1000), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	removed, err := cache.CleanupBySize(2500)
+	if err != nil {
+		t.Fatalf("CleanupBySize() error = %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("CleanupBySize() removed = %d, want 2", removed)
+	}
+
+	for i, name := range names {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if wantRemoved := i < 2; wantRemoved != os.IsNotExist(err) {
+			t.Errorf("%s removed = %v, want %v", name, os.IsNotExist(err), wantRemoved)
+		}
+	}
+
+	// Already within the limit
+	if removed, err := cache.CleanupBySize(2500); err != nil || removed != 0 {
+		t.Errorf("CleanupBySize() = %d, %v; want 0, nil", removed, err)
+	}
+}
+
+func TestFileWatermarkStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watermark.json")
+	store := NewFileWatermarkStore(path)
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() with no file error = %v", err)
+	}
+	if !got.IsZero() {
+		t.Errorf("Load() with no file = %v, want zero time", got)
+	}
+
+	watermark := time.Date(2026, 3, 1, 12, 30, 10, 0, time.UTC)
+	if err := store.Save(watermark); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != DefaultFileMode {
+		t.Errorf("watermark file mode = %o, want %o", perm, DefaultFileMode)
+	}
+
+	// A new store on the same path sees the saved watermark, as after a restart
+	got, err = NewFileWatermarkStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !got.Equal(watermark) {
+		t.Errorf("Load() = %v, want %v", got, watermark)
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := store.Load(); err == nil {
+		t.Error("Load() of a corrupt file succeeded, want error")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package cache
+
+import "errors"
+
+// FreeDiskSpace is not supported on this platform, so free space checks are skipped
+func FreeDiskSpace(dir string) (uint64, error) {
+	return 0, errors.New("free disk space is not supported on this platform")
+}
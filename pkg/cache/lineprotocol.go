@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Line protocol escaping: measurements escape commas and spaces; tag keys, tag values
+// and field keys also escape equals signs. Backslashes are written as they are.
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	keyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+// ExportLineProtocol writes the cached points to w as InfluxDB line protocol, oldest
+// first, under measurement with the given tags and nanosecond timestamps, ready for
+// `influx write`. Consumption fields are multiplied by consumptionScale, matching a
+// client writing in another unit. It returns the number of points written.
+func (c *Cache) ExportLineProtocol(w io.Writer, measurement string, tags map[string]string, consumptionScale float64) (int, error) {
+	points := c.GetAll()
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Timestamp.Before(points[j].Timestamp) })
+
+	// Tags are sorted by key, as InfluxDB recommends, and shared by every line
+	var series strings.Builder
+	series.WriteString(measurementEscaper.Replace(measurement))
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if tags[key] == "" {
+			continue // Line protocol has no empty tag values
+		}
+		series.WriteString("," + keyEscaper.Replace(key) + "=" + keyEscaper.Replace(tags[key]))
+	}
+	prefix := series.String() + " "
+
+	bw := bufio.NewWriter(w)
+	written := 0
+	for _, dp := range points {
+		bw.WriteString(prefix)
+		bw.WriteString("consumption_delta=" + formatField(dp.ConsumptionDelta*consumptionScale))
+		bw.WriteString(",demand=" + formatField(dp.Demand))
+		bw.WriteString(",cost_delta=" + formatField(dp.CostDelta))
+		bw.WriteString(",consumption=" + formatField(dp.Consumption*consumptionScale))
+		bw.WriteString(" " + strconv.FormatInt(dp.Timestamp.UnixNano(), 10) + "\n")
+		written++
+	}
+
+	return written, bw.Flush()
+}
+
+// formatField formats a float field value without an exponent. A bare number is a
+// float in line protocol; integers would need an "i" suffix.
+func formatField(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	protocol "github.com/influxdata/line-protocol"
+)
+
+func TestExportLineProtocol(t *testing.T) {
+	c, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	base := time.Date(2024, 3, 1, 12, 0, 0, 500, time.UTC)
+	if err := c.Add([]DataPoint{
+		{Timestamp: base.Add(10 * time.Second), ConsumptionDelta: 0.002, Demand: 450, CostDelta: 0.0005, Consumption: 1234.5},
+		{Timestamp: base, ConsumptionDelta: 0.001, Demand: 1e7, CostDelta: 0.0003, Consumption: 1234.498},
+		{Timestamp: base.Add(20 * time.Second), ConsumptionDelta: 0, Demand: 500, CostDelta: 0, Consumption: 1234.6},
+	}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	// Names needing every kind of escaping
+	tags := map[string]string{
+		"source":    "octopus_home_mini",
+		"meter id":  "home, main=1",
+		"empty":     "",
+		`back\note`: `a\b`,
+	}
+
+	var buf bytes.Buffer
+	n, err := c.ExportLineProtocol(&buf, "energy use,v2", tags, 1)
+	if err != nil {
+		t.Fatalf("ExportLineProtocol() error = %v", err)
+	}
+	if n != 3 {
+		t.Errorf("ExportLineProtocol() = %d points, want 3", n)
+	}
+
+	handler := protocol.NewMetricHandler()
+	metrics, err := protocol.NewParser(handler).Parse(buf.Bytes())
+	if err != nil {
+		t.Fatalf("output does not parse as line protocol: %v\n%s", err, buf.String())
+	}
+	if len(metrics) != 3 {
+		t.Fatalf("parsed %d lines, want 3\n%s", len(metrics), buf.String())
+	}
+
+	wantTags := map[string]string{"source": "octopus_home_mini", "meter id": "home, main=1", `back\note`: `a\b`}
+	for i, metric := range metrics {
+		if metric.Name() != "energy use,v2" {
+			t.Errorf("line %d measurement = %q, want %q", i, metric.Name(), "energy use,v2")
+		}
+		gotTags := make(map[string]string)
+		for _, tag := range metric.TagList() {
+			gotTags[tag.Key] = tag.Value
+		}
+		if len(gotTags) != len(wantTags) {
+			t.Errorf("line %d tags = %v, want %v", i, gotTags, wantTags)
+		}
+		for key, value := range wantTags {
+			if gotTags[key] != value {
+				t.Errorf("line %d tag %q = %q, want %q", i, key, gotTags[key], value)
+			}
+		}
+	}
+
+	// Oldest first, with nanosecond timestamps and exact float values
+	first := metrics[0]
+	if !first.Time().Equal(base) {
+		t.Errorf("first line time = %v, want %v", first.Time(), base)
+	}
+	fields := make(map[string]interface{})
+	for _, field := range first.FieldList() {
+		fields[field.Key] = field.Value
+	}
+	want := map[string]float64{"consumption_delta": 0.001, "demand": 1e7, "cost_delta": 0.0003, "consumption": 1234.498}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("first line field %s = %v (%T), want float %v", key, fields[key], fields[key], value)
+		}
+	}
+
+	// Every line carries all four fields
+	for i, metric := range metrics {
+		if got := len(metric.FieldList()); got != 4 {
+			t.Errorf("line %d has %d fields, want 4", i, got)
+		}
+	}
+}
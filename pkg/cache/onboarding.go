@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Onboarding records when monitoring of this install first started and when its first
+// data point arrived, so a new Home Mini that has not reported yet can be told apart
+// from one that stopped
+type Onboarding struct {
+	FirstStart time.Time `json:"first_start"`
+	FirstData  time.Time `json:"first_data"` // Zero until the first data point arrives
+}
+
+// OnboardingStore persists Onboarding across restarts
+type OnboardingStore interface {
+	// Load returns the persisted state, or the zero value if none was saved
+	Load() (Onboarding, error)
+	Save(state Onboarding) error
+}
+
+// FileOnboardingStore keeps the onboarding state in a small JSON file
+type FileOnboardingStore struct {
+	path string
+	mode os.FileMode
+}
+
+// NewFileOnboardingStore creates an onboarding store at path, written with the default cache file mode
+func NewFileOnboardingStore(path string) *FileOnboardingStore {
+	return &FileOnboardingStore{path: path, mode: DefaultFileMode}
+}
+
+// Load returns the persisted state, or the zero value if the file does not exist
+func (s *FileOnboardingStore) Load() (Onboarding, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Onboarding{}, nil
+		}
+		return Onboarding{}, fmt.Errorf("failed to read onboarding state: %w", err)
+	}
+
+	var state Onboarding
+	if err := json.Unmarshal(data, &state); err != nil {
+		return Onboarding{}, fmt.Errorf("failed to decode onboarding state: %w", err)
+	}
+	return state, nil
+}
+
+// Save writes the state atomically, like FileWatermarkStore.Save
+func (s *FileOnboardingStore) Save(state Onboarding) error {
+	data, err := json.Marshal(Onboarding{FirstStart: state.FirstStart.UTC(), FirstData: state.FirstData.UTC()})
+	if err != nil {
+		return fmt.Errorf("failed to encode onboarding state: %w", err)
+	}
+	if err := writeFileAtomic(s.path, data, s.mode); err != nil {
+		return fmt.Errorf("failed to save onboarding state: %w", err)
+	}
+	return nil
+}
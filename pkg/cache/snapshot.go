@@ -0,0 +1,42 @@
+package cache
+
+import "time"
+
+// Snapshot is an immutable point-in-time view of the cache. Pruning through a
+// snapshot only ever removes points it contains, so points added while a sync is
+// iterating the snapshot are left in the cache for the next sync.
+type Snapshot struct {
+	points  []DataPoint
+	nextSeq uint64 // Points with a sequence at or above this were added after the snapshot
+}
+
+// Snapshot returns a point-in-time copy of the cached points
+func (c *Cache) Snapshot() Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	points := make([]DataPoint, len(c.data))
+	copy(points, c.data)
+	return Snapshot{points: points, nextSeq: c.nextSeq}
+}
+
+// Points returns a copy of the points in the snapshot
+func (s Snapshot) Points() []DataPoint {
+	points := make([]DataPoint, len(s.points))
+	copy(points, s.points)
+	return points
+}
+
+// Len returns the number of points in the snapshot
+func (s Snapshot) Len() int {
+	return len(s.points)
+}
+
+// PruneSnapshot removes points that were in snap with a timestamp between from and
+// to inclusive, and persists the result. Points added after the snapshot was taken
+// are kept even if they fall within the range. It returns the number of points removed.
+func (c *Cache) PruneSnapshot(snap Snapshot, from, to time.Time) (int, error) {
+	return c.removeWhereSeq(func(dp DataPoint, seq uint64) bool {
+		return seq < snap.nextSeq && !dp.Timestamp.Before(from) && !dp.Timestamp.After(to)
+	})
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCache_PruneSnapshot(t *testing.T) {
+	cacheDir := filepath.Join(os.TempDir(), "test_cache_prune_snapshot")
+	defer os.RemoveAll(cacheDir)
+
+	cache, err := NewCache(cacheDir)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if err := cache.Add([]DataPoint{
+		{Timestamp: base, Consumption: 1.0},
+		{Timestamp: base.Add(10 * time.Second), Consumption: 2.0},
+		{Timestamp: base.Add(20 * time.Second), Consumption: 3.0},
+	}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	snap := cache.Snapshot()
+	if snap.Len() != 3 {
+		t.Fatalf("Snapshot().Len() = %d, want 3", snap.Len())
+	}
+
+	// Snapshot contents are isolated from both the caller and later adds
+	snap.Points()[0].Consumption = 99
+	if err := cache.AddSingle(DataPoint{Timestamp: base.Add(5 * time.Second), Consumption: 4.0}); err != nil {
+		t.Fatalf("AddSingle() error = %v", err)
+	}
+	if snap.Len() != 3 || snap.Points()[0].Consumption != 1.0 {
+		t.Errorf("snapshot changed after modification: %+v", snap.Points())
+	}
+
+	// The range covers the late point's timestamp, but it was not in the snapshot
+	removed, err := cache.PruneSnapshot(snap, base, base.Add(10*time.Second))
+	if err != nil {
+		t.Fatalf("PruneSnapshot() error = %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("PruneSnapshot() removed = %d, want 2", removed)
+	}
+
+	remaining := make(map[float64]bool)
+	for _, dp := range cache.GetAll() {
+		remaining[dp.Consumption] = true
+	}
+	if len(remaining) != 2 || !remaining[3.0] || !remaining[4.0] {
+		t.Errorf("remaining points = %v, want the out-of-range and late points", cache.GetAll())
+	}
+}
+
+func TestCache_SnapshotPruneConcurrentAdd(t *testing.T) {
+	cacheDir := filepath.Join(os.TempDir(), "test_cache_snapshot_concurrent")
+	defer os.RemoveAll(cacheDir)
+
+	cache, err := NewCache(cacheDir)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	// All points share a timestamp range, so a time-only prune would remove late adds too
+	ts := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 50; i++ {
+		if err := cache.AddSingle(DataPoint{Timestamp: ts, Consumption: float64(i)}); err != nil {
+			t.Fatalf("AddSingle() error = %v", err)
+		}
+	}
+
+	const adders, perAdder = 5, 20
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < adders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			for j := 0; j < perAdder; j++ {
+				if err := cache.AddSingle(DataPoint{Timestamp: ts, Consumption: -1}); err != nil {
+					t.Errorf("AddSingle() error = %v", err)
+				}
+			}
+		}()
+	}
+
+	snap := cache.Snapshot()
+	close(start)
+	removed, err := cache.PruneSnapshot(snap, ts, ts)
+	if err != nil {
+		t.Fatalf("PruneSnapshot() error = %v", err)
+	}
+	wg.Wait()
+
+	if removed != snap.Len() {
+		t.Errorf("PruneSnapshot() removed = %d, want snapshot size %d", removed, snap.Len())
+	}
+	if got := cache.Count(); got != adders*perAdder {
+		t.Errorf("Count() = %d, want %d concurrently added points kept", got, adders*perAdder)
+	}
+	for _, dp := range cache.GetAll() {
+		if dp.Consumption != -1 {
+			t.Errorf("snapshotted point %+v survived the prune", dp)
+		}
+	}
+}
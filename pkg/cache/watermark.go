@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WatermarkStore persists the timestamp of the newest point stored, so points at or
+// before it can be recognized as already seen after a restart
+type WatermarkStore interface {
+	// Load returns the persisted watermark, or the zero time if none was saved
+	Load() (time.Time, error)
+	Save(watermark time.Time) error
+}
+
+// FileWatermarkStore keeps the watermark in a small JSON file
+type FileWatermarkStore struct {
+	path string
+	mode os.FileMode
+}
+
+// NewFileWatermarkStore creates a watermark store at path, written with the default cache file mode
+func NewFileWatermarkStore(path string) *FileWatermarkStore {
+	return &FileWatermarkStore{path: path, mode: DefaultFileMode}
+}
+
+type watermarkFile struct {
+	Watermark time.Time `json:"watermark"`
+}
+
+// Load returns the persisted watermark, or the zero time if the file does not exist
+func (s *FileWatermarkStore) Load() (time.Time, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("failed to read watermark: %w", err)
+	}
+
+	var wf watermarkFile
+	if err := json.Unmarshal(data, &wf); err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode watermark: %w", err)
+	}
+	return wf.Watermark, nil
+}
+
+// Save writes the watermark through a temp file and rename, so a crash cannot leave
+// a truncated file behind
+func (s *FileWatermarkStore) Save(watermark time.Time) error {
+	data, err := json.Marshal(watermarkFile{Watermark: watermark.UTC()})
+	if err != nil {
+		return fmt.Errorf("failed to encode watermark: %w", err)
+	}
+	if err := writeFileAtomic(s.path, data, s.mode); err != nil {
+		return fmt.Errorf("failed to save watermark: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to path through a temp file in the same directory and a
+// rename, so readers never see a partly written file
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -2,32 +2,99 @@ package config
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/joho/godotenv"
 	"gopkg.in/yaml.v2"
 )
 
 const (
+	// Supported data sinks
+	SinkInfluxDB = "influxdb"
+	SinkParquet  = "parquet"
+
+	// Supported InfluxDB write modes: every raw point, one summary point per poll, or both
+	WriteModeRaw     = "raw"
+	WriteModeSummary = "summary"
+	WriteModeBoth    = "both"
+
+	// Supported units for consumption and consumption_delta
+	ConsumptionUnitKWh   = "kWh"
+	ConsumptionUnitWh    = "Wh"
+	ConsumptionUnitJoule = "J"
+
+	// Supported cache sync orders
+	CacheSyncOldest = "oldest"
+	CacheSyncNewest = "newest"
+
+	// Supported config env modes: every environment override, none (config.yaml only),
+	// or only those named in ConfigEnvAllowlist
+	ConfigEnvAll       = "all"
+	ConfigEnvFile      = "file"
+	ConfigEnvAllowlist = "allowlist"
+
 	// Validation constraints
 	minPollInterval = 10 * time.Second
 	maxPollInterval = 3600 * time.Second
 	minAPIKeyLength = 32
 	maxPathLength   = 4096
+
+	maxRoundingPlaces = 15
+
+	// Octopus API retry budget and per-request deadline defaults, lowered in Load to fit
+	// a shorter poll timeout when they are left unset
+	defaultOctopusMaxRetryElapsed  = 30 * time.Second
+	defaultOctopusMaxRetryInterval = 15 * time.Second
+	defaultOctopusRequestTimeout   = 10 * time.Second
 )
 
 var (
 	// Regular expressions for validation
 	validNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
-	validLogLevel  = map[string]bool{
+	// Line numbers reported by the YAML decoder, e.g. "line 3: cannot unmarshal ..."
+	yamlLineRegex = regexp.MustCompile(`line (\d+)`)
+	// Telemetry groupings supported by the Kraken smartMeterTelemetry query
+	telemetryGroupingIntervals = map[string]time.Duration{
+		"TEN_SECONDS":    10 * time.Second,
+		"ONE_MINUTE":     time.Minute,
+		"FIVE_MINUTES":   5 * time.Minute,
+		"THIRTY_MINUTES": 30 * time.Minute,
+		"ONE_HOUR":       time.Hour,
+	}
+	// Formatted and parsed back to check each TELEMETRY_TIMESTAMP_LAYOUTS entry
+	timestampLayoutCheck = time.Date(2024, 11, 23, 14, 5, 6, 0, time.UTC)
+	// Consumption units per kWh
+	consumptionUnitScale = map[string]float64{
+		ConsumptionUnitKWh:   1,
+		ConsumptionUnitWh:    1000,
+		ConsumptionUnitJoule: 3.6e6,
+	}
+	// Tags the client sets itself, which INFLUXDB_TAGS cannot override
+	reservedTagKeys = map[string]bool{"source": true, "mpan": true, "meter_serial": true, "unit": true}
+	// Tag keys that suggest a per-reading value, which belongs in a field
+	highCardinalityTagKeys = map[string]bool{
+		"time": true, "timestamp": true, "ts": true, "date": true, "datetime": true,
+		"reading": true, "value": true, "id": true, "uuid": true, "poll_id": true, "request_id": true,
+	}
+	// Tag values that look like timestamps, counters or UUIDs
+	highCardinalityTagValueRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}([T ].*)?|\d{9,}|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})$`)
+	// InfluxDB connectivity check retries, so InfluxDB still starting alongside the
+	// monitor (e.g. in the same compose file) doesn't produce a startup warning
+	influxCheckRetries  uint64 = 3
+	influxCheckInterval        = 500 * time.Millisecond
+	validLogLevel              = map[string]bool{
 		"debug": true,
 		"info":  true,
 		"warn":  true,
@@ -41,39 +108,238 @@ type Config struct {
 	OctopusAPIKey        string `yaml:"octopus_api_key"`
 	OctopusAccountNumber string `yaml:"octopus_account_number"`
 
+	// Data sink: "influxdb" (default) or "parquet" for local files without a database
+	Sink                 string        `yaml:"sink"`
+	ParquetDir           string        `yaml:"parquet_dir"`
+	ParquetFlushInterval time.Duration `yaml:"parquet_flush_interval_seconds"`
+
 	// InfluxDB
-	InfluxDBURL         string `yaml:"influxdb_url"`
-	InfluxDBToken       string `yaml:"influxdb_token"`
-	InfluxDBOrg         string `yaml:"influxdb_org"`
-	InfluxDBBucket      string `yaml:"influxdb_bucket"`
-	InfluxDBMeasurement string `yaml:"influxdb_measurement"`
+	InfluxDBURL          string `yaml:"influxdb_url"`
+	InfluxDBToken        string `yaml:"influxdb_token"`
+	InfluxDBOrg          string `yaml:"influxdb_org"`
+	InfluxDBBucket       string `yaml:"influxdb_bucket"`
+	InfluxDBMeasurement  string `yaml:"influxdb_measurement"`
+	InfluxDBMeterTags    bool   `yaml:"influxdb_meter_tags"`    // Tag points with mpan/meter_serial (increases cardinality)
+	InfluxDBExportFields bool   `yaml:"influxdb_export_fields"` // Write import_kwh/export_kwh split from the signed consumption delta
+	// Static tags added to every point, as comma-separated key=value pairs
+	InfluxDBTags string `yaml:"influxdb_tags"`
+	// Most distinct tag keys a point may carry, counting source, meter and unit tags (0 = no limit)
+	InfluxDBMaxTagKeys int `yaml:"influxdb_max_tag_keys"`
+	// Write whole-second UTC timestamps so rewriting a reading overwrites it instead of duplicating it
+	InfluxDBIdempotentWrites bool `yaml:"influxdb_idempotent_writes"`
+	// Unit consumption and consumption_delta are written in (kWh, Wh or J); other units add a unit tag
+	ConsumptionUnit string `yaml:"consumption_unit"`
+	// Per-poll summaries (min/max/avg demand, summed deltas) written instead of or alongside raw points
+	InfluxDBWriteMode          string `yaml:"influxdb_write_mode"`
+	InfluxDBSummaryMeasurement string `yaml:"influxdb_summary_measurement"` // Defaults to <measurement>_summary
+
+	// InfluxDB connection pool tuning (0 keeps the client default; negative keep-alive disables probes)
+	InfluxMaxIdleConns        int           `yaml:"influx_max_idle_conns"`
+	InfluxMaxIdleConnsPerHost int           `yaml:"influx_max_idle_conns_per_host"`
+	InfluxIdleConnTimeout     time.Duration `yaml:"influx_idle_conn_timeout_seconds"`
+	InfluxKeepAlive           time.Duration `yaml:"influx_keep_alive_seconds"`
+
+	// Create the bucket at startup if it is missing (needs an org-admin token; 0 retention keeps data forever)
+	InfluxCreateBucket    bool          `yaml:"influx_create_bucket"`
+	InfluxBucketRetention time.Duration `yaml:"influx_bucket_retention_seconds"`
+
+	// InfluxDB TLS (for self-hosted servers with private or self-signed certificates)
+	InfluxTLSInsecureSkipVerify bool   `yaml:"influx_tls_insecure_skip_verify"`
+	InfluxCACertPath            string `yaml:"influx_ca_cert_path"`
+
+	// Optional rounding of telemetry fields to a number of decimal places (nil = full precision)
+	RoundConsumptionDelta *int `yaml:"round_consumption_delta"`
+	RoundDemand           *int `yaml:"round_demand"`
+	RoundCostDelta        *int `yaml:"round_cost_delta"`
+	RoundConsumption      *int `yaml:"round_consumption"`
+
+	// Consumption deltas smaller than this (in kWh) are written as zero to filter meter
+	// noise at very low loads (0 = keep raw values)
+	MinConsumptionDelta float64 `yaml:"min_consumption_delta"`
+
+	// Octopus telemetry resolution (TEN_SECONDS, ONE_MINUTE, FIVE_MINUTES, THIRTY_MINUTES or ONE_HOUR)
+	TelemetryGrouping string `yaml:"telemetry_grouping"`
+	// Go time layouts tried in order for each reading's readAt, separated by "|"; layouts
+	// without a zone are read as UTC (empty = RFC 3339 only)
+	TelemetryTimestampLayouts string `yaml:"telemetry_timestamp_layouts"`
 
 	// Slack (optional)
 	SlackWebhookURL string `yaml:"slack_webhook_url"`
 	SlackEnabled    bool   `yaml:"slack_enabled"`
+	// Per-severity webhooks; errors and info messages fall back to slack_webhook_url when unset
+	SlackWebhookError string `yaml:"slack_webhook_error"`
+	SlackWebhookInfo  string `yaml:"slack_webhook_info"`
+	// Opt-in readiness check that posts a message to the webhook at most once per interval
+	SlackHealthCheckEnabled  bool          `yaml:"slack_health_check_enabled"`
+	SlackHealthCheckInterval time.Duration `yaml:"slack_health_check_interval_seconds"`
+	// Characters of text kept per message or field; longer text is cut with an ellipsis (0 = no cap)
+	SlackMaxMessageLength int `yaml:"slack_max_message_length"`
 
 	// Application settings
 	PollInterval time.Duration `yaml:"poll_interval_seconds"`
+	AlignPolls   bool          `yaml:"align_polls"` // Poll on wall-clock multiples of the interval rather than from process start
 	CacheDir     string        `yaml:"cache_dir"`
 	LogLevel     string        `yaml:"log_level"`
+	// IANA timezone for cache file dates, stats and log timestamps (empty = host local time)
+	DisplayTimezone string `yaml:"display_timezone"`
+	// Routine per-poll messages are logged only every Nth poll (1 = every poll)
+	LogSampleEveryN int `yaml:"log_sample_every_n"`
+	// Nudge the poll interval toward the cadence of returned readings, within the min/max bounds
+	AdaptivePollInterval bool          `yaml:"adaptive_poll_interval"`
+	AdaptivePollMin      time.Duration `yaml:"adaptive_poll_min_seconds"`
+	AdaptivePollMax      time.Duration `yaml:"adaptive_poll_max_seconds"`
 
 	// Timeout configurations
 	InfluxConnectTimeout      time.Duration `yaml:"influx_connect_timeout_seconds"`
 	InfluxWriteTimeout        time.Duration `yaml:"influx_write_timeout_seconds"`
+	InfluxBackpressureEnabled bool          `yaml:"influx_backpressure_enabled"`
+	InfluxBackpressureMaxWait time.Duration `yaml:"influx_backpressure_max_wait_seconds"`
+	InfluxHealthCacheTTL      time.Duration `yaml:"influx_health_cache_ttl_seconds"` // Reuse a health check result this long (0 = always ping)
 	PollTimeout               time.Duration `yaml:"poll_timeout_seconds"`
 	ShutdownTimeout           time.Duration `yaml:"shutdown_timeout_seconds"`
 	CacheSyncTimeout          time.Duration `yaml:"cache_sync_timeout_seconds"`
 	ReconnectMaxElapsedTime   time.Duration `yaml:"reconnect_max_elapsed_seconds"`
 	ConsecutiveErrorThreshold int           `yaml:"consecutive_error_threshold"`
 	MaxBackoffFactor          int           `yaml:"max_backoff_factor"`
+	MaxDataStaleness          time.Duration `yaml:"max_data_staleness_seconds"` // 0 disables the watchdog
+	CircuitOpenSkipPoll       bool          `yaml:"circuit_open_skip_poll"`     // Skip polls while the Octopus circuit breaker is open instead of counting errors
+	MaxPointsPerPoll          int           `yaml:"max_points_per_poll"`        // Points written inline per poll; the rest are cached (0 = unlimited)
+	MaxPointAge               time.Duration `yaml:"max_point_age_seconds"`      // Drop older points before writing or caching (0 = unlimited)
+
+	// A failed InfluxDB write of up to WriteRetryMaxPoints points is retried this many
+	// times, WriteRetryDelay apart, before the batch is cached (0 attempts = cache at once)
+	WriteRetryAttempts  int           `yaml:"write_retry_attempts"`
+	WriteRetryDelay     time.Duration `yaml:"write_retry_delay_seconds"`
+	WriteRetryMaxPoints int           `yaml:"write_retry_max_points"`
+
+	// Errors within this long of startup do not enter degraded mode (0 = none)
+	DegradedGracePeriod time.Duration `yaml:"degraded_grace_period_seconds"`
+
+	// While the Octopus API is down for maintenance it is polled this often instead of
+	// entering degraded mode (0 = the normal interval), and an alert is only sent once
+	// the maintenance has lasted MaintenanceAlertAfter (0 = at once)
+	MaintenancePollInterval time.Duration `yaml:"maintenance_poll_interval_seconds"`
+	MaintenanceAlertAfter   time.Duration `yaml:"maintenance_alert_after_seconds"`
+
+	// Longest range one poll requests (0 = unlimited). After degraded mode a larger gap
+	// is fetched in windows of this size, and backoff resets once it is caught up.
+	MaxPollWindow time.Duration `yaml:"max_poll_window_seconds"`
+
+	// Track a new install awaiting its first data point in the cache dir, alerting when
+	// it arrives. Off by default, as an existing install would see a spurious alert.
+	OnboardingEnabled bool `yaml:"onboarding_enabled"`
+	// Warn if a new install has reported no data this long after monitoring first
+	// started (0 = never warn)
+	FirstDataTimeout time.Duration `yaml:"first_data_timeout_seconds"`
+
+	// Flatline detection: alert when consumption stays at zero for this many consecutive
+	// readings within the active hours window (0 disables; equal hours means all day)
+	FlatlineThreshold       int `yaml:"flatline_threshold_readings"`
+	FlatlineActiveStartHour int `yaml:"flatline_active_start_hour"`
+	FlatlineActiveEndHour   int `yaml:"flatline_active_end_hour"`
+
+	// Octopus API retry budget
+	OctopusMaxRetryElapsed  time.Duration `yaml:"octopus_max_retry_elapsed_seconds"`
+	OctopusMaxRetryInterval time.Duration `yaml:"octopus_max_interval_seconds"`
+	// Deadline for each API request, so a slow response is retried within the retry budget (0 = none)
+	OctopusRequestTimeout time.Duration `yaml:"octopus_request_timeout_seconds"`
+	// Transport timeouts for Octopus API connections, so a stalled connection fails sooner and
+	// more specifically than OctopusRequestTimeout (0 = no limit)
+	OctopusDialTimeout           time.Duration `yaml:"octopus_dial_timeout_seconds"`
+	OctopusTLSHandshakeTimeout   time.Duration `yaml:"octopus_tls_handshake_timeout_seconds"`
+	OctopusResponseHeaderTimeout time.Duration `yaml:"octopus_response_header_timeout_seconds"`
+	// Keep the API token in the cache dir so restarts within its validity skip authentication
+	OctopusPersistToken bool `yaml:"octopus_persist_token"`
+	// Kraken token obtained elsewhere, used instead of authenticating with the API key until
+	// it expires: given directly or read from a file such as a mounted secret (not both)
+	OctopusToken     string `yaml:"octopus_token"`
+	OctopusTokenFile string `yaml:"octopus_token_file"`
+	// Persist the newest stored reading time and skip readings at or before it
+	DedupWatermark bool `yaml:"dedup_watermark"`
 
 	// Cache cleanup settings
 	CacheCleanupEnabled  bool          `yaml:"cache_cleanup_enabled"`
 	CacheCleanupInterval time.Duration `yaml:"cache_cleanup_interval_hours"`
 	CacheRetentionDays   int           `yaml:"cache_retention_days"`
+	CacheMaxSizeMB       int           `yaml:"cache_max_size_mb"` // Remove the oldest files beyond this total size (0 = no limit)
+
+	// Stop caching when free disk space falls below this many megabytes (0 disables the check),
+	// optionally removing the oldest cache files to make room first
+	CacheMinFreeDiskMB       int  `yaml:"cache_min_free_disk_mb"`
+	CacheDropOldestOnLowDisk bool `yaml:"cache_drop_oldest_on_low_disk"`
+
+	// After this many consecutive cache write failures (0 disables), buffer up to
+	// CacheMemoryBufferPoints in memory until the cache directory is writable again
+	CacheFailureThreshold   int `yaml:"cache_failure_threshold"`
+	CacheMemoryBufferPoints int `yaml:"cache_memory_buffer_points"`
+
+	// Permissions for cache files and a newly created cache directory, as octal strings.
+	// The defaults keep consumption data private; e.g. "0640"/"0750" grant group read access.
+	CacheFileMode string `yaml:"cache_file_mode"`
+	CacheDirMode  string `yaml:"cache_dir_mode"`
+
+	// Order cached points are synced after an outage: "oldest" (default) or "newest" first
+	CacheSyncOrder string `yaml:"cache_sync_order"`
+	// Query InfluxDB after a full cache sync and keep the cache if points are missing
+	VerifyCacheSync bool `yaml:"verify_cache_sync"`
+	// Cached points synced after each successful write while InfluxDB is healthy, draining
+	// a backlog left by brief outages (0 disables; MaxPointsPerPoll still bounds it)
+	CacheSyncBatchSize int `yaml:"cache_sync_batch_size"`
+
+	// Catch-up mode: a cache sync of at least CatchUpThreshold points (0 disables) is written
+	// in chunks of CatchUpChunkSize with CatchUpPause between them, and routine alerts give
+	// way to a start and a finish summary until the cache is drained
+	CatchUpThreshold int           `yaml:"catch_up_threshold"`
+	CatchUpChunkSize int           `yaml:"catch_up_chunk_size"`
+	CatchUpPause     time.Duration `yaml:"catch_up_pause_seconds"`
+
+	// Health server settings (disabled = no /health, /ready or /stats endpoints)
+	HealthServerEnabled bool   `yaml:"health_server_enabled"`
+	HealthServerAddr    string `yaml:"health_server_addr"`
+	// Most readiness checkers run at once per /ready probe (0 = unbounded)
+	HealthMaxConcurrentChecks int `yaml:"health_max_concurrent_checks"`
+	// Exposes diagnostic endpoints such as /errors on the health server
+	DebugEndpointsEnabled bool `yaml:"debug_endpoints_enabled"`
+	// HTTP basic-auth credentials for the debug endpoints (both empty = no auth)
+	DebugAuthUsername string `yaml:"debug_auth_username"`
+	DebugAuthPassword string `yaml:"debug_auth_password"`
+
+	// Telemetry response auditing (raw responses stored under <cache_dir>/audit)
+	AuditResponses bool `yaml:"audit_responses"`
+	AuditRetention int  `yaml:"audit_retention"` // Maximum number of audit files kept
+
+	// Where state transitions (InfluxDB health, degraded mode, the Octopus circuit breaker,
+	// the cache backlog) are logged as JSON lines: "stdout", "stderr" or a file appended
+	// to (empty = disabled)
+	TransitionLog string `yaml:"transition_log"`
 
-	// Health server settings
-	HealthServerAddr string `yaml:"health_server_addr"`
+	// Outbound proxy for Octopus, InfluxDB and Slack requests (http, https, socks5 or socks5h)
+	ProxyURL string `yaml:"proxy_url"`
+
+	// OpenTelemetry tracing of poll cycles, exported over OTLP/HTTP to the collector set
+	// by the standard OTEL_EXPORTER_OTLP_* environment variables
+	OTelEnabled bool `yaml:"otel_enabled"`
+
+	// Outdoor temperature fetched from a weather API every WeatherInterval and written to
+	// WeatherMeasurement, so Grafana can overlay consumption against it
+	WeatherEnabled     bool          `yaml:"weather_enabled"`
+	WeatherProvider    string        `yaml:"weather_provider"`
+	WeatherAPIKey      string        `yaml:"weather_api_key"`
+	WeatherLatitude    float64       `yaml:"weather_latitude"`
+	WeatherLongitude   float64       `yaml:"weather_longitude"`
+	WeatherInterval    time.Duration `yaml:"weather_interval_seconds"`
+	WeatherMeasurement string        `yaml:"weather_measurement"`
+
+	// Octopus Saving Sessions looked up every SavingSessionsInterval: upcoming sessions are
+	// announced and every session window is marked in SavingSessionsMeasurement
+	SavingSessionsEnabled     bool          `yaml:"saving_sessions_enabled"`
+	SavingSessionsInterval    time.Duration `yaml:"saving_sessions_interval_seconds"`
+	SavingSessionsMeasurement string        `yaml:"saving_sessions_measurement"`
+
+	// Which environment variables may override config.yaml: "all", "file" (none) or
+	// "allowlist" (the comma-separated names in ConfigEnvAllowlist)
+	ConfigEnvMode      string `yaml:"config_env_mode"`
+	ConfigEnvAllowlist string `yaml:"config_env_allowlist"`
 }
 
 // Load reads configuration from a YAML file and overrides with environment variables
@@ -86,8 +352,8 @@ func Load() (*Config, error) {
 		if err != nil {
 			return nil, fmt.Errorf("error reading config.yaml: %w", err)
 		}
-		if err := yaml.Unmarshal(yamlFile, cfg); err != nil {
-			return nil, fmt.Errorf("error unmarshalling config.yaml: %w", err)
+		if err := parseYAML(yamlFile, cfg); err != nil {
+			return nil, err
 		}
 	}
 
@@ -101,7 +367,18 @@ func Load() (*Config, error) {
 	// Post-processing and final adjustments
 	cfg.SlackEnabled = cfg.SlackEnabled && cfg.SlackWebhookURL != ""
 	cfg.CacheDir = sanitizePath(cfg.CacheDir)
+	cfg.ParquetDir = sanitizePath(cfg.ParquetDir)
 	cfg.LogLevel = strings.ToLower(cfg.LogLevel)
+	// Defaults give way to a shorter poll timeout; only values set explicitly are rejected
+	if cfg.OctopusMaxRetryElapsed == defaultOctopusMaxRetryElapsed && cfg.PollTimeout < cfg.OctopusMaxRetryElapsed {
+		cfg.OctopusMaxRetryElapsed = cfg.PollTimeout
+	}
+	if cfg.OctopusMaxRetryInterval == defaultOctopusMaxRetryInterval && cfg.OctopusMaxRetryElapsed < cfg.OctopusMaxRetryInterval {
+		cfg.OctopusMaxRetryInterval = cfg.OctopusMaxRetryElapsed
+	}
+	if cfg.OctopusRequestTimeout == defaultOctopusRequestTimeout && cfg.PollTimeout < cfg.OctopusRequestTimeout {
+		cfg.OctopusRequestTimeout = cfg.PollTimeout
+	}
 
 	if err := cfg.Validate(); err != nil {
 		return nil, err
@@ -110,114 +387,531 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// parseYAML decodes config.yaml contents into cfg. Decoder errors are rewritten to name
+// the offending line and key along with a hint on the likely cause.
+func parseYAML(data []byte, cfg *Config) error {
+	err := yaml.Unmarshal(data, cfg)
+	if err == nil {
+		return nil
+	}
+
+	lines := strings.Split(string(data), "\n")
+	var problems []string
+	hint := "check indentation uses spaces rather than tabs and quote values containing ':' or '#'"
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		problems = typeErr.Errors
+		hint = "check the value has the right type, e.g. numbers for *_seconds fields and true/false for flags"
+	} else {
+		problems = []string{strings.TrimPrefix(err.Error(), "yaml: ")}
+	}
+
+	for i, problem := range problems {
+		problems[i] = describeYAMLProblem(problem, lines)
+	}
+
+	return fmt.Errorf("config.yaml is malformed: %s (hint: %s)", strings.Join(problems, "; "), hint)
+}
+
+// describeYAMLProblem appends the key found on the reported line so the message points at a field
+func describeYAMLProblem(problem string, lines []string) string {
+	match := yamlLineRegex.FindStringSubmatch(problem)
+	if match == nil {
+		return problem
+	}
+	lineNum, err := strconv.Atoi(match[1])
+	if err != nil || lineNum < 1 || lineNum > len(lines) {
+		return problem
+	}
+
+	key, _, found := strings.Cut(strings.TrimSpace(lines[lineNum-1]), ":")
+	if !found || key == "" || strings.HasPrefix(key, "#") {
+		return problem
+	}
+	return fmt.Sprintf("%s [field %q]", problem, strings.TrimSpace(key))
+}
+
 // defaultConfig returns a new Config with default values
 func defaultConfig() *Config {
 	return &Config{
+		Sink:                      SinkInfluxDB,
+		SlackHealthCheckInterval:  3600 * time.Second, // 1 hour
+		SlackMaxMessageLength:     3000,
+		ParquetDir:                "./data",
+		ParquetFlushInterval:      3600 * time.Second, // 1 hour
 		InfluxDBURL:               "http://localhost:8086",
 		InfluxDBBucket:            "octopus_energy",
 		InfluxDBMeasurement:       "energy_consumption",
+		InfluxDBWriteMode:         WriteModeRaw,
+		ConsumptionUnit:           ConsumptionUnitKWh,
+		InfluxDBMaxTagKeys:        8,
+		TelemetryGrouping:         "TEN_SECONDS",
 		PollInterval:              30 * time.Second,
+		AdaptivePollMin:           minPollInterval,
+		AdaptivePollMax:           120 * time.Second,
 		CacheDir:                  "./cache",
 		LogLevel:                  "info",
+		LogSampleEveryN:           1,
+		AuditRetention:            100,
 		InfluxConnectTimeout:      30 * time.Second,
 		InfluxWriteTimeout:        10 * time.Second,
+		InfluxBackpressureEnabled: true,
+		CircuitOpenSkipPoll:       true,
+		InfluxBackpressureMaxWait: 30 * time.Second,
+		InfluxHealthCacheTTL:      5 * time.Second,
+		InfluxMaxIdleConns:        100,
+		InfluxMaxIdleConnsPerHost: 100,
+		InfluxIdleConnTimeout:     90 * time.Second,
 		PollTimeout:               30 * time.Second,
 		ShutdownTimeout:           5 * time.Second,
 		CacheSyncTimeout:          60 * time.Second,
 		ReconnectMaxElapsedTime:   300 * time.Second, // 5 minutes
 		ConsecutiveErrorThreshold: 3,
 		MaxBackoffFactor:          4,
+		DegradedGracePeriod:       60 * time.Second,
+		MaintenancePollInterval:   5 * time.Minute,
+		MaintenanceAlertAfter:     1 * time.Hour,
+		WriteRetryAttempts:        2,
+		WriteRetryDelay:           1 * time.Second,
+		WriteRetryMaxPoints:       1000,
+		OctopusMaxRetryElapsed:    defaultOctopusMaxRetryElapsed,
+		OctopusMaxRetryInterval:   defaultOctopusMaxRetryInterval,
+		OctopusRequestTimeout:     defaultOctopusRequestTimeout,
 		CacheCleanupEnabled:       true,
 		CacheCleanupInterval:      24 * time.Hour,
 		CacheRetentionDays:        7,
+		CacheFileMode:             "0600",
+		CacheDirMode:              "0700",
+		CacheSyncOrder:            CacheSyncOldest,
+		CacheSyncBatchSize:        100,
+		CatchUpChunkSize:          500,
+		CatchUpPause:              1 * time.Second,
+		CacheFailureThreshold:     3,
+		WeatherProvider:           "openweathermap",
+		WeatherInterval:           900 * time.Second, // 15 minutes
+		WeatherMeasurement:        "weather",
+		SavingSessionsInterval:    3600 * time.Second, // 1 hour
+		SavingSessionsMeasurement: "saving_sessions",
+		CacheMemoryBufferPoints:   8640, // A day of ten-second readings
+		HealthServerEnabled:       true,
 		HealthServerAddr:          ":8080",
 		SlackEnabled:              true,
+
+		// Octopus API transport limits, each within OctopusRequestTimeout
+		OctopusDialTimeout:           5 * time.Second,
+		OctopusTLSHandshakeTimeout:   5 * time.Second,
+		OctopusResponseHeaderTimeout: 8 * time.Second,
+
+		ConfigEnvMode:    ConfigEnvAll,
+		FirstDataTimeout: 24 * time.Hour,
 	}
 }
 
-// overrideWithEnv overrides config fields with values from environment variables if they are set
+// overrideWithEnv overrides config fields with values from environment variables if they
+// are set and ConfigEnvMode allows them
 func overrideWithEnv(cfg *Config) {
-	if val := getEnv("OCTOPUS_API_KEY", ""); val != "" {
+	allowed := resolveEnvMode(cfg)
+	if allowed == nil {
+		return
+	}
+	env := envReader{allowed: allowed}
+
+	if val := env.getEnv("OCTOPUS_API_KEY", ""); val != "" {
 		cfg.OctopusAPIKey = strings.TrimSpace(val)
 	}
-	if val := getEnv("OCTOPUS_ACCOUNT_NUMBER", ""); val != "" {
+	if val := env.getEnv("OCTOPUS_ACCOUNT_NUMBER", ""); val != "" {
 		cfg.OctopusAccountNumber = strings.TrimSpace(val)
 	}
-	if val := getEnv("INFLUXDB_URL", ""); val != "" {
+	if val := env.getEnv("INFLUXDB_URL", ""); val != "" {
 		cfg.InfluxDBURL = strings.TrimSpace(val)
 	}
-	if val := getEnv("INFLUXDB_TOKEN", ""); val != "" {
+	if val := env.getEnv("INFLUXDB_TOKEN", ""); val != "" {
 		cfg.InfluxDBToken = strings.TrimSpace(val)
 	}
-	if val := getEnv("INFLUXDB_ORG", ""); val != "" {
+	if val := env.getEnv("INFLUXDB_ORG", ""); val != "" {
 		cfg.InfluxDBOrg = strings.TrimSpace(val)
 	}
-	if val := getEnv("INFLUXDB_BUCKET", ""); val != "" {
+	if val := env.getEnv("INFLUXDB_BUCKET", ""); val != "" {
 		cfg.InfluxDBBucket = strings.TrimSpace(val)
 	}
-	if val := getEnv("INFLUXDB_MEASUREMENT", ""); val != "" {
+	if val := env.getEnv("INFLUXDB_MEASUREMENT", ""); val != "" {
 		cfg.InfluxDBMeasurement = strings.TrimSpace(val)
 	}
-	if val := getEnv("SLACK_WEBHOOK_URL", ""); val != "" {
+	if val, isSet := env.getEnvAsBoolPtr("INFLUXDB_METER_TAGS"); isSet {
+		cfg.InfluxDBMeterTags = *val
+	}
+	if val := env.getEnv("INFLUXDB_TAGS", ""); val != "" {
+		cfg.InfluxDBTags = val
+	}
+	if val, isSet := env.getEnvAsIntPtr("INFLUXDB_MAX_TAG_KEYS"); isSet {
+		cfg.InfluxDBMaxTagKeys = *val
+	}
+	if val, isSet := env.getEnvAsBoolPtr("INFLUXDB_EXPORT_FIELDS"); isSet {
+		cfg.InfluxDBExportFields = *val
+	}
+	if val, isSet := env.getEnvAsBoolPtr("INFLUXDB_IDEMPOTENT_WRITES"); isSet {
+		cfg.InfluxDBIdempotentWrites = *val
+	}
+	if val := env.getEnv("CONSUMPTION_UNIT", ""); val != "" {
+		cfg.ConsumptionUnit = strings.TrimSpace(val)
+	}
+	if val := env.getEnv("INFLUXDB_WRITE_MODE", ""); val != "" {
+		cfg.InfluxDBWriteMode = strings.ToLower(strings.TrimSpace(val))
+	}
+	if val := env.getEnv("INFLUXDB_SUMMARY_MEASUREMENT", ""); val != "" {
+		cfg.InfluxDBSummaryMeasurement = strings.TrimSpace(val)
+	}
+	if val := env.getEnv("SINK", ""); val != "" {
+		cfg.Sink = strings.ToLower(strings.TrimSpace(val))
+	}
+	if val := env.getEnv("PARQUET_DIR", ""); val != "" {
+		cfg.ParquetDir = val
+	}
+	if val, isSet := env.getEnvAsIntPtr("PARQUET_FLUSH_INTERVAL_SECONDS"); isSet {
+		cfg.ParquetFlushInterval = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsBoolPtr("INFLUX_TLS_INSECURE_SKIP_VERIFY"); isSet {
+		cfg.InfluxTLSInsecureSkipVerify = *val
+	}
+	if val := env.getEnv("INFLUX_CA_CERT_PATH", ""); val != "" {
+		cfg.InfluxCACertPath = strings.TrimSpace(val)
+	}
+	if val, isSet := env.getEnvAsIntPtr("ROUND_CONSUMPTION_DELTA"); isSet {
+		cfg.RoundConsumptionDelta = val
+	}
+	if val, isSet := env.getEnvAsIntPtr("ROUND_DEMAND"); isSet {
+		cfg.RoundDemand = val
+	}
+	if val, isSet := env.getEnvAsIntPtr("ROUND_COST_DELTA"); isSet {
+		cfg.RoundCostDelta = val
+	}
+	if val, isSet := env.getEnvAsIntPtr("ROUND_CONSUMPTION"); isSet {
+		cfg.RoundConsumption = val
+	}
+	if val, isSet := env.getEnvAsFloatPtr("MIN_CONSUMPTION_DELTA"); isSet {
+		cfg.MinConsumptionDelta = *val
+	}
+	if val, isSet := env.getEnvAsBoolPtr("DEBUG_ENDPOINTS_ENABLED"); isSet {
+		cfg.DebugEndpointsEnabled = *val
+	}
+	if val := env.getEnv("DEBUG_AUTH_USERNAME", ""); val != "" {
+		cfg.DebugAuthUsername = strings.TrimSpace(val)
+	}
+	if val := env.getEnv("DEBUG_AUTH_PASSWORD", ""); val != "" {
+		cfg.DebugAuthPassword = val
+	}
+	if val, isSet := env.getEnvAsBoolPtr("VERIFY_CACHE_SYNC"); isSet {
+		cfg.VerifyCacheSync = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("CACHE_SYNC_BATCH_SIZE"); isSet {
+		cfg.CacheSyncBatchSize = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("CATCH_UP_THRESHOLD"); isSet {
+		cfg.CatchUpThreshold = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("CATCH_UP_CHUNK_SIZE"); isSet {
+		cfg.CatchUpChunkSize = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("CATCH_UP_PAUSE_SECONDS"); isSet {
+		cfg.CatchUpPause = time.Duration(*val) * time.Second
+	}
+	if val := env.getEnv("CACHE_FILE_MODE", ""); val != "" {
+		cfg.CacheFileMode = strings.TrimSpace(val)
+	}
+	if val := env.getEnv("CACHE_DIR_MODE", ""); val != "" {
+		cfg.CacheDirMode = strings.TrimSpace(val)
+	}
+	if val := env.getEnv("CACHE_SYNC_ORDER", ""); val != "" {
+		cfg.CacheSyncOrder = strings.ToLower(strings.TrimSpace(val))
+	}
+	if val := env.getEnv("TELEMETRY_GROUPING", ""); val != "" {
+		cfg.TelemetryGrouping = strings.ToUpper(strings.TrimSpace(val))
+	}
+	if val := env.getEnv("TELEMETRY_TIMESTAMP_LAYOUTS", ""); val != "" {
+		cfg.TelemetryTimestampLayouts = val
+	}
+	if val := env.getEnv("SLACK_WEBHOOK_URL", ""); val != "" {
 		cfg.SlackWebhookURL = strings.TrimSpace(val)
 	}
-	if val, isSet := getEnvAsBoolPtr("SLACK_ENABLED"); isSet {
+	if val := env.getEnv("SLACK_WEBHOOK_ERROR", ""); val != "" {
+		cfg.SlackWebhookError = strings.TrimSpace(val)
+	}
+	if val := env.getEnv("SLACK_WEBHOOK_INFO", ""); val != "" {
+		cfg.SlackWebhookInfo = strings.TrimSpace(val)
+	}
+	if val, isSet := env.getEnvAsBoolPtr("SLACK_ENABLED"); isSet {
 		cfg.SlackEnabled = *val
 	}
-	if val, isSet := getEnvAsIntPtr("POLL_INTERVAL_SECONDS"); isSet {
+	if val, isSet := env.getEnvAsBoolPtr("SLACK_HEALTH_CHECK_ENABLED"); isSet {
+		cfg.SlackHealthCheckEnabled = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("SLACK_HEALTH_CHECK_INTERVAL_SECONDS"); isSet {
+		cfg.SlackHealthCheckInterval = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsIntPtr("SLACK_MAX_MESSAGE_LENGTH"); isSet {
+		cfg.SlackMaxMessageLength = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("POLL_INTERVAL_SECONDS"); isSet {
 		cfg.PollInterval = time.Duration(*val) * time.Second
 	}
-	if val := getEnv("CACHE_DIR", ""); val != "" {
+	if val, isSet := env.getEnvAsBoolPtr("ALIGN_POLLS"); isSet {
+		cfg.AlignPolls = *val
+	}
+	if val, isSet := env.getEnvAsBoolPtr("ADAPTIVE_POLL_INTERVAL"); isSet {
+		cfg.AdaptivePollInterval = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("ADAPTIVE_POLL_MIN_SECONDS"); isSet {
+		cfg.AdaptivePollMin = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsIntPtr("ADAPTIVE_POLL_MAX_SECONDS"); isSet {
+		cfg.AdaptivePollMax = time.Duration(*val) * time.Second
+	}
+	if val := env.getEnv("CACHE_DIR", ""); val != "" {
 		cfg.CacheDir = val
 	}
-	if val := getEnv("LOG_LEVEL", ""); val != "" {
+	if val := env.getEnv("LOG_LEVEL", ""); val != "" {
 		cfg.LogLevel = val
 	}
-	if val, isSet := getEnvAsIntPtr("INFLUX_CONNECT_TIMEOUT_SECONDS"); isSet {
+	if val := env.getEnv("DISPLAY_TIMEZONE", ""); val != "" {
+		cfg.DisplayTimezone = strings.TrimSpace(val)
+	}
+	if val, isSet := env.getEnvAsIntPtr("LOG_SAMPLE_EVERY_N"); isSet {
+		cfg.LogSampleEveryN = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("INFLUX_CONNECT_TIMEOUT_SECONDS"); isSet {
 		cfg.InfluxConnectTimeout = time.Duration(*val) * time.Second
 	}
-	if val, isSet := getEnvAsIntPtr("INFLUX_WRITE_TIMEOUT_SECONDS"); isSet {
+	if val, isSet := env.getEnvAsIntPtr("INFLUX_WRITE_TIMEOUT_SECONDS"); isSet {
 		cfg.InfluxWriteTimeout = time.Duration(*val) * time.Second
 	}
-	if val, isSet := getEnvAsIntPtr("POLL_TIMEOUT_SECONDS"); isSet {
+	if val, isSet := env.getEnvAsBoolPtr("INFLUX_BACKPRESSURE_ENABLED"); isSet {
+		cfg.InfluxBackpressureEnabled = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("INFLUX_BACKPRESSURE_MAX_WAIT_SECONDS"); isSet {
+		cfg.InfluxBackpressureMaxWait = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsIntPtr("INFLUX_HEALTH_CACHE_TTL_SECONDS"); isSet {
+		cfg.InfluxHealthCacheTTL = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsIntPtr("INFLUX_MAX_IDLE_CONNS"); isSet {
+		cfg.InfluxMaxIdleConns = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("INFLUX_MAX_IDLE_CONNS_PER_HOST"); isSet {
+		cfg.InfluxMaxIdleConnsPerHost = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("INFLUX_IDLE_CONN_TIMEOUT_SECONDS"); isSet {
+		cfg.InfluxIdleConnTimeout = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsIntPtr("INFLUX_KEEP_ALIVE_SECONDS"); isSet {
+		cfg.InfluxKeepAlive = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsBoolPtr("INFLUX_CREATE_BUCKET"); isSet {
+		cfg.InfluxCreateBucket = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("INFLUX_BUCKET_RETENTION_SECONDS"); isSet {
+		cfg.InfluxBucketRetention = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsIntPtr("POLL_TIMEOUT_SECONDS"); isSet {
 		cfg.PollTimeout = time.Duration(*val) * time.Second
 	}
-	if val, isSet := getEnvAsIntPtr("SHUTDOWN_TIMEOUT_SECONDS"); isSet {
+	if val, isSet := env.getEnvAsIntPtr("WRITE_RETRY_ATTEMPTS"); isSet {
+		cfg.WriteRetryAttempts = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("WRITE_RETRY_DELAY_SECONDS"); isSet {
+		cfg.WriteRetryDelay = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsIntPtr("WRITE_RETRY_MAX_POINTS"); isSet {
+		cfg.WriteRetryMaxPoints = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("SHUTDOWN_TIMEOUT_SECONDS"); isSet {
 		cfg.ShutdownTimeout = time.Duration(*val) * time.Second
 	}
-	if val, isSet := getEnvAsIntPtr("CACHE_SYNC_TIMEOUT_SECONDS"); isSet {
+	if val, isSet := env.getEnvAsIntPtr("CACHE_SYNC_TIMEOUT_SECONDS"); isSet {
 		cfg.CacheSyncTimeout = time.Duration(*val) * time.Second
 	}
-	if val, isSet := getEnvAsIntPtr("RECONNECT_MAX_ELAPSED_SECONDS"); isSet {
+	if val, isSet := env.getEnvAsIntPtr("RECONNECT_MAX_ELAPSED_SECONDS"); isSet {
 		cfg.ReconnectMaxElapsedTime = time.Duration(*val) * time.Second
 	}
-	if val, isSet := getEnvAsIntPtr("CONSECUTIVE_ERROR_THRESHOLD"); isSet {
+	if val, isSet := env.getEnvAsIntPtr("CONSECUTIVE_ERROR_THRESHOLD"); isSet {
 		cfg.ConsecutiveErrorThreshold = *val
 	}
-	if val, isSet := getEnvAsIntPtr("MAX_BACKOFF_FACTOR"); isSet {
+	if val, isSet := env.getEnvAsIntPtr("MAX_BACKOFF_FACTOR"); isSet {
 		cfg.MaxBackoffFactor = *val
 	}
-	if val, isSet := getEnvAsBoolPtr("CACHE_CLEANUP_ENABLED"); isSet {
+	if val, isSet := env.getEnvAsIntPtr("DEGRADED_GRACE_PERIOD_SECONDS"); isSet {
+		cfg.DegradedGracePeriod = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsIntPtr("MAINTENANCE_POLL_INTERVAL_SECONDS"); isSet {
+		cfg.MaintenancePollInterval = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsIntPtr("MAINTENANCE_ALERT_AFTER_SECONDS"); isSet {
+		cfg.MaintenanceAlertAfter = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsIntPtr("MAX_POLL_WINDOW_SECONDS"); isSet {
+		cfg.MaxPollWindow = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsBoolPtr("ONBOARDING_ENABLED"); isSet {
+		cfg.OnboardingEnabled = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("FIRST_DATA_TIMEOUT_SECONDS"); isSet {
+		cfg.FirstDataTimeout = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsIntPtr("MAX_DATA_STALENESS_SECONDS"); isSet {
+		cfg.MaxDataStaleness = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsIntPtr("MAX_POINTS_PER_POLL"); isSet {
+		cfg.MaxPointsPerPoll = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("MAX_POINT_AGE_SECONDS"); isSet {
+		cfg.MaxPointAge = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsBoolPtr("CIRCUIT_OPEN_SKIP_POLL"); isSet {
+		cfg.CircuitOpenSkipPoll = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("FLATLINE_THRESHOLD_READINGS"); isSet {
+		cfg.FlatlineThreshold = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("FLATLINE_ACTIVE_START_HOUR"); isSet {
+		cfg.FlatlineActiveStartHour = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("FLATLINE_ACTIVE_END_HOUR"); isSet {
+		cfg.FlatlineActiveEndHour = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("OCTOPUS_MAX_RETRY_ELAPSED_SECONDS"); isSet {
+		cfg.OctopusMaxRetryElapsed = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsIntPtr("OCTOPUS_MAX_INTERVAL_SECONDS"); isSet {
+		cfg.OctopusMaxRetryInterval = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsIntPtr("OCTOPUS_REQUEST_TIMEOUT_SECONDS"); isSet {
+		cfg.OctopusRequestTimeout = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsIntPtr("OCTOPUS_DIAL_TIMEOUT_SECONDS"); isSet {
+		cfg.OctopusDialTimeout = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsIntPtr("OCTOPUS_TLS_HANDSHAKE_TIMEOUT_SECONDS"); isSet {
+		cfg.OctopusTLSHandshakeTimeout = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsIntPtr("OCTOPUS_RESPONSE_HEADER_TIMEOUT_SECONDS"); isSet {
+		cfg.OctopusResponseHeaderTimeout = time.Duration(*val) * time.Second
+	}
+	if val, isSet := env.getEnvAsBoolPtr("OCTOPUS_PERSIST_TOKEN"); isSet {
+		cfg.OctopusPersistToken = *val
+	}
+	if val := env.getEnv("OCTOPUS_TOKEN", ""); val != "" {
+		cfg.OctopusToken = strings.TrimSpace(val)
+	}
+	if val := env.getEnv("OCTOPUS_TOKEN_FILE", ""); val != "" {
+		cfg.OctopusTokenFile = strings.TrimSpace(val)
+	}
+	if val, isSet := env.getEnvAsBoolPtr("DEDUP_WATERMARK"); isSet {
+		cfg.DedupWatermark = *val
+	}
+	if val, isSet := env.getEnvAsBoolPtr("CACHE_CLEANUP_ENABLED"); isSet {
 		cfg.CacheCleanupEnabled = *val
 	}
-	if val, isSet := getEnvAsIntPtr("CACHE_CLEANUP_INTERVAL_HOURS"); isSet {
+	if val, isSet := env.getEnvAsIntPtr("CACHE_CLEANUP_INTERVAL_HOURS"); isSet {
 		cfg.CacheCleanupInterval = time.Duration(*val) * time.Hour
 	}
-	if val, isSet := getEnvAsIntPtr("CACHE_RETENTION_DAYS"); isSet {
+	if val, isSet := env.getEnvAsIntPtr("CACHE_RETENTION_DAYS"); isSet {
 		cfg.CacheRetentionDays = *val
 	}
-	if val := getEnv("HEALTH_SERVER_ADDR", ""); val != "" {
+	if val, isSet := env.getEnvAsIntPtr("CACHE_MAX_SIZE_MB"); isSet {
+		cfg.CacheMaxSizeMB = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("CACHE_MIN_FREE_DISK_MB"); isSet {
+		cfg.CacheMinFreeDiskMB = *val
+	}
+	if val, isSet := env.getEnvAsBoolPtr("CACHE_DROP_OLDEST_ON_LOW_DISK"); isSet {
+		cfg.CacheDropOldestOnLowDisk = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("CACHE_FAILURE_THRESHOLD"); isSet {
+		cfg.CacheFailureThreshold = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("CACHE_MEMORY_BUFFER_POINTS"); isSet {
+		cfg.CacheMemoryBufferPoints = *val
+	}
+	if val, isSet := env.getEnvAsBoolPtr("HEALTH_SERVER_ENABLED"); isSet {
+		cfg.HealthServerEnabled = *val
+	}
+	if val := env.getEnv("HEALTH_SERVER_ADDR", ""); val != "" {
 		cfg.HealthServerAddr = val
 	}
+	if val, isSet := env.getEnvAsIntPtr("HEALTH_MAX_CONCURRENT_CHECKS"); isSet {
+		cfg.HealthMaxConcurrentChecks = *val
+	}
+	if val, isSet := env.getEnvAsBoolPtr("AUDIT_RESPONSES"); isSet {
+		cfg.AuditResponses = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("AUDIT_RETENTION"); isSet {
+		cfg.AuditRetention = *val
+	}
+	if val := env.getEnv("TRANSITION_LOG", ""); val != "" {
+		cfg.TransitionLog = strings.TrimSpace(val)
+	}
+	if val := env.getEnv("PROXY_URL", ""); val != "" {
+		cfg.ProxyURL = strings.TrimSpace(val)
+	}
+	if val, isSet := env.getEnvAsBoolPtr("OTEL_ENABLED"); isSet {
+		cfg.OTelEnabled = *val
+	}
+	if val, isSet := env.getEnvAsBoolPtr("WEATHER_ENABLED"); isSet {
+		cfg.WeatherEnabled = *val
+	}
+	if val := env.getEnv("WEATHER_PROVIDER", ""); val != "" {
+		cfg.WeatherProvider = strings.ToLower(strings.TrimSpace(val))
+	}
+	if val := env.getEnv("WEATHER_API_KEY", ""); val != "" {
+		cfg.WeatherAPIKey = strings.TrimSpace(val)
+	}
+	if val, isSet := env.getEnvAsFloatPtr("WEATHER_LATITUDE"); isSet {
+		cfg.WeatherLatitude = *val
+	}
+	if val, isSet := env.getEnvAsFloatPtr("WEATHER_LONGITUDE"); isSet {
+		cfg.WeatherLongitude = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("WEATHER_INTERVAL_SECONDS"); isSet {
+		cfg.WeatherInterval = time.Duration(*val) * time.Second
+	}
+	if val := env.getEnv("WEATHER_MEASUREMENT", ""); val != "" {
+		cfg.WeatherMeasurement = strings.TrimSpace(val)
+	}
+	if val, isSet := env.getEnvAsBoolPtr("SAVING_SESSIONS_ENABLED"); isSet {
+		cfg.SavingSessionsEnabled = *val
+	}
+	if val, isSet := env.getEnvAsIntPtr("SAVING_SESSIONS_INTERVAL_SECONDS"); isSet {
+		cfg.SavingSessionsInterval = time.Duration(*val) * time.Second
+	}
+	if val := env.getEnv("SAVING_SESSIONS_MEASUREMENT", ""); val != "" {
+		cfg.SavingSessionsMeasurement = strings.TrimSpace(val)
+	}
 }
 
 // Validate checks if required configuration values are present and valid
 func (c *Config) Validate() error {
-	// Validate Octopus API credentials
-	if c.OctopusAPIKey == "" {
+	// Validate the config env mode first, as it decides where the remaining values came from
+	switch c.ConfigEnvMode {
+	case "", ConfigEnvAll, ConfigEnvFile:
+	case ConfigEnvAllowlist:
+		if strings.TrimSpace(strings.ReplaceAll(c.ConfigEnvAllowlist, ",", "")) == "" {
+			return fmt.Errorf("CONFIG_ENV_ALLOWLIST is required when CONFIG_ENV_MODE is allowlist")
+		}
+	default:
+		return fmt.Errorf("CONFIG_ENV_MODE must be one of: all, file, allowlist")
+	}
+
+	// Validate Octopus API credentials. A provided token stands in for the API key until
+	// it expires.
+	if c.OctopusToken != "" && c.OctopusTokenFile != "" {
+		return fmt.Errorf("OCTOPUS_TOKEN and OCTOPUS_TOKEN_FILE cannot both be set")
+	}
+	token, err := c.ProvidedOctopusToken()
+	if err != nil {
+		return err
+	}
+	if c.OctopusAPIKey == "" && token == "" {
 		return fmt.Errorf("OCTOPUS_API_KEY is required")
 	}
-	if len(c.OctopusAPIKey) < minAPIKeyLength {
+	if c.OctopusAPIKey != "" && len(c.OctopusAPIKey) < minAPIKeyLength {
 		return fmt.Errorf("OCTOPUS_API_KEY must be at least %d characters", minAPIKeyLength)
 	}
 	if c.OctopusAccountNumber == "" {
@@ -228,44 +922,58 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("OCTOPUS_ACCOUNT_NUMBER format is invalid")
 	}
 
-	// Validate InfluxDB configuration
-	if c.InfluxDBURL == "" {
-		return fmt.Errorf("INFLUXDB_URL is required")
+	// Validate data sink
+	switch c.Sink {
+	case "", SinkInfluxDB:
+		if err := c.validateInfluxDB(); err != nil {
+			return err
+		}
+	case SinkParquet:
+		if c.ParquetDir == "" {
+			return fmt.Errorf("PARQUET_DIR is required when SINK is parquet")
+		}
+		if c.ParquetFlushInterval < 1*time.Second {
+			return fmt.Errorf("PARQUET_FLUSH_INTERVAL_SECONDS must be at least 1 second")
+		}
+		// Parquet files are always written in kWh
+		if c.ConsumptionUnit != "" && c.ConsumptionUnit != ConsumptionUnitKWh {
+			return fmt.Errorf("CONSUMPTION_UNIT must be kWh when SINK is parquet")
+		}
+	default:
+		return fmt.Errorf("SINK must be one of: influxdb, parquet")
 	}
-	if err := validateURL(c.InfluxDBURL, "INFLUXDB_URL"); err != nil {
+	if err := c.validateWeather(); err != nil {
 		return err
 	}
-	if c.InfluxDBToken == "" {
-		return fmt.Errorf("INFLUXDB_TOKEN is required")
-	}
-	if c.InfluxDBOrg == "" {
-		return fmt.Errorf("INFLUXDB_ORG is required")
-	}
-	if !validNameRegex.MatchString(c.InfluxDBOrg) {
-		return fmt.Errorf("INFLUXDB_ORG must contain only alphanumeric characters, underscores, and hyphens")
-	}
-	if !validNameRegex.MatchString(c.InfluxDBBucket) {
-		return fmt.Errorf("INFLUXDB_BUCKET must contain only alphanumeric characters, underscores, and hyphens")
-	}
-	if c.InfluxDBMeasurement == "" {
-		return fmt.Errorf("INFLUXDB_MEASUREMENT is required")
-	}
-	if !validNameRegex.MatchString(c.InfluxDBMeasurement) {
-		return fmt.Errorf("INFLUXDB_MEASUREMENT must contain only alphanumeric characters, underscores, and hyphens")
+	if c.SavingSessionsEnabled {
+		if c.SavingSessionsInterval < 5*time.Minute {
+			return fmt.Errorf("SAVING_SESSIONS_INTERVAL_SECONDS must be at least 300 seconds")
+		}
+		if !validNameRegex.MatchString(c.SavingSessionsMeasurement) {
+			return fmt.Errorf("SAVING_SESSIONS_MEASUREMENT must contain only alphanumeric characters, underscores, and hyphens")
+		}
 	}
 
 	// Validate Slack webhook URL if enabled
 	if c.SlackEnabled {
-		if err := validateURL(c.SlackWebhookURL, "SLACK_WEBHOOK_URL"); err != nil {
+		if err := validateSlackWebhook(c.SlackWebhookURL, "SLACK_WEBHOOK_URL"); err != nil {
 			return err
 		}
-		// Ensure it's a hooks.slack.com URL (or example.com for testing)
-		parsedURL, err := url.Parse(c.SlackWebhookURL)
-		if err != nil {
-			return fmt.Errorf("SLACK_WEBHOOK_URL is not a valid URL: %w", err)
+		if c.SlackWebhookError != "" {
+			if err := validateSlackWebhook(c.SlackWebhookError, "SLACK_WEBHOOK_ERROR"); err != nil {
+				return err
+			}
 		}
-		if parsedURL.Host != "hooks.slack.com" && parsedURL.Host != "example.com" {
-			return fmt.Errorf("SLACK_WEBHOOK_URL must be a hooks.slack.com URL")
+		if c.SlackWebhookInfo != "" {
+			if err := validateSlackWebhook(c.SlackWebhookInfo, "SLACK_WEBHOOK_INFO"); err != nil {
+				return err
+			}
+		}
+		if c.SlackHealthCheckEnabled && c.SlackHealthCheckInterval < 60*time.Second {
+			return fmt.Errorf("SLACK_HEALTH_CHECK_INTERVAL_SECONDS must be at least 60 seconds")
+		}
+		if c.SlackMaxMessageLength != 0 && c.SlackMaxMessageLength < 100 {
+			return fmt.Errorf("SLACK_MAX_MESSAGE_LENGTH must be 0 (no cap) or at least 100")
 		}
 	}
 
@@ -276,6 +984,20 @@ func (c *Config) Validate() error {
 	if c.PollInterval > maxPollInterval {
 		return fmt.Errorf("POLL_INTERVAL_SECONDS must be at most %d seconds", int(maxPollInterval.Seconds()))
 	}
+	if c.AdaptivePollInterval {
+		if c.AlignPolls {
+			return fmt.Errorf("ADAPTIVE_POLL_INTERVAL and ALIGN_POLLS cannot both be enabled")
+		}
+		if c.AdaptivePollMin < minPollInterval {
+			return fmt.Errorf("ADAPTIVE_POLL_MIN_SECONDS must be at least %d seconds", int(minPollInterval.Seconds()))
+		}
+		if c.AdaptivePollMax > maxPollInterval {
+			return fmt.Errorf("ADAPTIVE_POLL_MAX_SECONDS must be at most %d seconds", int(maxPollInterval.Seconds()))
+		}
+		if c.AdaptivePollMax < c.AdaptivePollMin {
+			return fmt.Errorf("ADAPTIVE_POLL_MAX_SECONDS must be at least ADAPTIVE_POLL_MIN_SECONDS")
+		}
+	}
 
 	// Validate cache directory
 	if c.CacheDir == "" {
@@ -284,11 +1006,89 @@ func (c *Config) Validate() error {
 	if len(c.CacheDir) > maxPathLength {
 		return fmt.Errorf("CACHE_DIR path is too long (max %d characters)", maxPathLength)
 	}
+	if mode, err := parseFileMode(c.CacheFileMode, 0600); err != nil || mode&0600 != 0600 {
+		return fmt.Errorf("CACHE_FILE_MODE must be an octal mode such as 0600 that lets the owner read and write")
+	}
+	if mode, err := parseFileMode(c.CacheDirMode, 0700); err != nil || mode&0700 != 0700 {
+		return fmt.Errorf("CACHE_DIR_MODE must be an octal mode such as 0700 that gives the owner full access")
+	}
+
+	// Validate InfluxDB CA certificate
+	if c.InfluxCACertPath != "" {
+		if _, err := c.InfluxTLSConfig(); err != nil {
+			return err
+		}
+	}
+
+	// Validate rounding
+	for name, places := range map[string]*int{
+		"ROUND_CONSUMPTION_DELTA": c.RoundConsumptionDelta,
+		"ROUND_DEMAND":            c.RoundDemand,
+		"ROUND_COST_DELTA":        c.RoundCostDelta,
+		"ROUND_CONSUMPTION":       c.RoundConsumption,
+	} {
+		if places != nil && (*places < 0 || *places > maxRoundingPlaces) {
+			return fmt.Errorf("%s must be between 0 and %d", name, maxRoundingPlaces)
+		}
+	}
+	if c.MinConsumptionDelta < 0 {
+		return fmt.Errorf("MIN_CONSUMPTION_DELTA must not be negative")
+	}
+
+	// Validate cache sync order (empty syncs oldest first)
+	switch c.CacheSyncOrder {
+	case "", CacheSyncOldest, CacheSyncNewest:
+	default:
+		return fmt.Errorf("CACHE_SYNC_ORDER must be one of: oldest, newest")
+	}
+	if c.CacheSyncBatchSize < 0 {
+		return fmt.Errorf("CACHE_SYNC_BATCH_SIZE must not be negative")
+	}
+
+	if c.HealthMaxConcurrentChecks < 0 {
+		return fmt.Errorf("HEALTH_MAX_CONCURRENT_CHECKS must not be negative")
+	}
+	if c.HealthServerEnabled && c.HealthServerAddr == "" {
+		return fmt.Errorf("HEALTH_SERVER_ADDR must be set when HEALTH_SERVER_ENABLED is true")
+	}
+	if c.CatchUpThreshold < 0 {
+		return fmt.Errorf("CATCH_UP_THRESHOLD must not be negative")
+	}
+	if c.CatchUpThreshold > 0 {
+		if c.CatchUpChunkSize < 1 {
+			return fmt.Errorf("CATCH_UP_CHUNK_SIZE must be at least 1")
+		}
+		if c.CatchUpPause < 0 {
+			return fmt.Errorf("CATCH_UP_PAUSE_SECONDS must not be negative")
+		}
+	}
+
+	// Validate telemetry grouping (empty uses the API client's default of TEN_SECONDS)
+	if _, ok := telemetryGroupingIntervals[c.TelemetryGrouping]; c.TelemetryGrouping != "" && !ok {
+		return fmt.Errorf("TELEMETRY_GROUPING must be one of: TEN_SECONDS, ONE_MINUTE, FIVE_MINUTES, THIRTY_MINUTES, ONE_HOUR")
+	}
+
+	for _, layout := range c.TimestampLayouts() {
+		// A layout is only usable if the reference time formats to something else and back
+		formatted := timestampLayoutCheck.Format(layout)
+		if parsed, err := time.Parse(layout, formatted); formatted == layout || err != nil || parsed.IsZero() {
+			return fmt.Errorf("TELEMETRY_TIMESTAMP_LAYOUTS has an invalid Go time layout: %q", layout)
+		}
+	}
 
 	// Validate log level
 	if !validLogLevel[c.LogLevel] {
 		return fmt.Errorf("LOG_LEVEL must be one of: debug, info, warn, error")
 	}
+	if c.DisplayTimezone != "" {
+		if _, err := time.LoadLocation(c.DisplayTimezone); err != nil {
+			return fmt.Errorf("DISPLAY_TIMEZONE %q is not a valid IANA timezone: %w", c.DisplayTimezone, err)
+		}
+	}
+
+	if c.LogSampleEveryN < 1 {
+		return fmt.Errorf("LOG_SAMPLE_EVERY_N must be at least 1")
+	}
 
 	// Validate timeout configurations
 	if c.InfluxConnectTimeout < 1*time.Second {
@@ -297,9 +1097,45 @@ func (c *Config) Validate() error {
 	if c.InfluxWriteTimeout < 1*time.Second {
 		return fmt.Errorf("INFLUX_WRITE_TIMEOUT_SECONDS must be at least 1 second")
 	}
+	if c.InfluxBackpressureEnabled && c.InfluxBackpressureMaxWait < 1*time.Second {
+		return fmt.Errorf("INFLUX_BACKPRESSURE_MAX_WAIT_SECONDS must be at least 1 second")
+	}
+	if c.InfluxHealthCacheTTL < 0 {
+		return fmt.Errorf("INFLUX_HEALTH_CACHE_TTL_SECONDS must not be negative")
+	}
+	if c.InfluxHealthCacheTTL >= c.PollInterval {
+		return fmt.Errorf("INFLUX_HEALTH_CACHE_TTL_SECONDS must be less than POLL_INTERVAL_SECONDS")
+	}
+	if c.InfluxMaxIdleConns < 0 {
+		return fmt.Errorf("INFLUX_MAX_IDLE_CONNS must not be negative")
+	}
+	if c.InfluxMaxIdleConnsPerHost < 0 {
+		return fmt.Errorf("INFLUX_MAX_IDLE_CONNS_PER_HOST must not be negative")
+	}
+	if c.InfluxIdleConnTimeout < 0 {
+		return fmt.Errorf("INFLUX_IDLE_CONN_TIMEOUT_SECONDS must not be negative")
+	}
+	if c.InfluxBucketRetention < 0 {
+		return fmt.Errorf("INFLUX_BUCKET_RETENTION_SECONDS must not be negative")
+	}
+	if c.InfluxBucketRetention > 0 && c.InfluxBucketRetention < time.Hour {
+		return fmt.Errorf("INFLUX_BUCKET_RETENTION_SECONDS must be 0 (forever) or at least 3600")
+	}
 	if c.PollTimeout < 1*time.Second {
 		return fmt.Errorf("POLL_TIMEOUT_SECONDS must be at least 1 second")
 	}
+	if c.WriteRetryAttempts < 0 {
+		return fmt.Errorf("WRITE_RETRY_ATTEMPTS must not be negative")
+	}
+	if c.WriteRetryDelay < 0 {
+		return fmt.Errorf("WRITE_RETRY_DELAY_SECONDS must not be negative")
+	}
+	if c.WriteRetryMaxPoints < 0 {
+		return fmt.Errorf("WRITE_RETRY_MAX_POINTS must not be negative")
+	}
+	if c.WriteRetryDelay*time.Duration(c.WriteRetryAttempts) >= c.PollTimeout {
+		return fmt.Errorf("WRITE_RETRY_ATTEMPTS x WRITE_RETRY_DELAY_SECONDS must be less than POLL_TIMEOUT_SECONDS")
+	}
 	if c.ShutdownTimeout < 1*time.Second {
 		return fmt.Errorf("SHUTDOWN_TIMEOUT_SECONDS must be at least 1 second")
 	}
@@ -315,13 +1151,440 @@ func (c *Config) Validate() error {
 	if c.MaxBackoffFactor < 1 {
 		return fmt.Errorf("MAX_BACKOFF_FACTOR must be at least 1")
 	}
+	if c.DegradedGracePeriod < 0 {
+		return fmt.Errorf("DEGRADED_GRACE_PERIOD_SECONDS must not be negative")
+	}
+	if c.MaintenancePollInterval < 0 {
+		return fmt.Errorf("MAINTENANCE_POLL_INTERVAL_SECONDS must not be negative")
+	}
+	if c.MaintenanceAlertAfter < 0 {
+		return fmt.Errorf("MAINTENANCE_ALERT_AFTER_SECONDS must not be negative")
+	}
+	if c.MaxPollWindow < 0 {
+		return fmt.Errorf("MAX_POLL_WINDOW_SECONDS must not be negative")
+	}
+	if c.MaxPollWindow > 0 {
+		// Each window must outpace the slowest degraded interval, or the gap never closes
+		interval := c.PollInterval
+		if c.AdaptivePollInterval && c.AdaptivePollMax > interval {
+			interval = c.AdaptivePollMax
+		}
+		if slowest := interval * time.Duration(c.MaxBackoffFactor); c.MaxPollWindow <= slowest {
+			return fmt.Errorf("MAX_POLL_WINDOW_SECONDS must be longer than the slowest degraded poll interval (%s)", slowest)
+		}
+	}
+	if c.FirstDataTimeout < 0 {
+		return fmt.Errorf("FIRST_DATA_TIMEOUT_SECONDS must not be negative")
+	}
+	if c.MaxDataStaleness < 0 {
+		return fmt.Errorf("MAX_DATA_STALENESS_SECONDS must not be negative")
+	}
+	if c.MaxDataStaleness > 0 && c.MaxDataStaleness < c.PollInterval {
+		return fmt.Errorf("MAX_DATA_STALENESS_SECONDS must be at least POLL_INTERVAL_SECONDS")
+	}
+	if c.MaxPointsPerPoll < 0 {
+		return fmt.Errorf("MAX_POINTS_PER_POLL must not be negative")
+	}
+	if c.MaxPointAge < 0 {
+		return fmt.Errorf("MAX_POINT_AGE_SECONDS must not be negative")
+	}
+	if c.FlatlineThreshold < 0 {
+		return fmt.Errorf("FLATLINE_THRESHOLD_READINGS must not be negative")
+	}
+	if c.FlatlineActiveStartHour < 0 || c.FlatlineActiveStartHour > 23 {
+		return fmt.Errorf("FLATLINE_ACTIVE_START_HOUR must be between 0 and 23")
+	}
+	if c.FlatlineActiveEndHour < 0 || c.FlatlineActiveEndHour > 23 {
+		return fmt.Errorf("FLATLINE_ACTIVE_END_HOUR must be between 0 and 23")
+	}
+	if c.OctopusMaxRetryElapsed < 1*time.Second {
+		return fmt.Errorf("OCTOPUS_MAX_RETRY_ELAPSED_SECONDS must be at least 1 second")
+	}
+	if c.OctopusMaxRetryElapsed > c.PollTimeout {
+		return fmt.Errorf("OCTOPUS_MAX_RETRY_ELAPSED_SECONDS must not exceed POLL_TIMEOUT_SECONDS")
+	}
+	if c.OctopusMaxRetryInterval < 1*time.Second {
+		return fmt.Errorf("OCTOPUS_MAX_INTERVAL_SECONDS must be at least 1 second")
+	}
+	if c.OctopusMaxRetryInterval > c.OctopusMaxRetryElapsed {
+		return fmt.Errorf("OCTOPUS_MAX_INTERVAL_SECONDS must not exceed OCTOPUS_MAX_RETRY_ELAPSED_SECONDS")
+	}
+	if c.OctopusRequestTimeout < 0 {
+		return fmt.Errorf("OCTOPUS_REQUEST_TIMEOUT_SECONDS must not be negative")
+	}
+	if c.OctopusRequestTimeout > c.PollTimeout {
+		return fmt.Errorf("OCTOPUS_REQUEST_TIMEOUT_SECONDS must not exceed POLL_TIMEOUT_SECONDS")
+	}
+	for name, timeout := range map[string]time.Duration{
+		"OCTOPUS_DIAL_TIMEOUT_SECONDS":            c.OctopusDialTimeout,
+		"OCTOPUS_TLS_HANDSHAKE_TIMEOUT_SECONDS":   c.OctopusTLSHandshakeTimeout,
+		"OCTOPUS_RESPONSE_HEADER_TIMEOUT_SECONDS": c.OctopusResponseHeaderTimeout,
+	} {
+		if timeout < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
 	if c.CacheRetentionDays < 1 {
 		return fmt.Errorf("CACHE_RETENTION_DAYS must be at least 1")
 	}
+	if c.CacheMaxSizeMB < 0 {
+		return fmt.Errorf("CACHE_MAX_SIZE_MB must not be negative")
+	}
+	if c.CacheMinFreeDiskMB < 0 {
+		return fmt.Errorf("CACHE_MIN_FREE_DISK_MB must not be negative")
+	}
+	if c.CacheFailureThreshold < 0 {
+		return fmt.Errorf("CACHE_FAILURE_THRESHOLD must not be negative")
+	}
+	if c.CacheFailureThreshold > 0 && c.CacheMemoryBufferPoints < 1 {
+		return fmt.Errorf("CACHE_MEMORY_BUFFER_POINTS must be at least 1 when CACHE_FAILURE_THRESHOLD is set")
+	}
+
+	if (c.DebugAuthUsername == "") != (c.DebugAuthPassword == "") {
+		return fmt.Errorf("DEBUG_AUTH_USERNAME and DEBUG_AUTH_PASSWORD must be set together")
+	}
+
+	if c.AuditResponses && c.AuditRetention < 1 {
+		return fmt.Errorf("AUDIT_RETENTION must be at least 1 when AUDIT_RESPONSES is enabled")
+	}
+
+	// Validate proxy URL
+	if c.ProxyURL != "" {
+		if err := validateProxyURL(c.ProxyURL); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+// InfluxTLSConfig returns the TLS settings for InfluxDB connections, or nil when the
+// system defaults apply
+func (c *Config) InfluxTLSConfig() (*tls.Config, error) {
+	if !c.InfluxTLSInsecureSkipVerify && c.InfluxCACertPath == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		//nolint:gosec // Explicitly requested for self-signed certificates
+		InsecureSkipVerify: c.InfluxTLSInsecureSkipVerify,
+	}
+
+	if c.InfluxCACertPath != "" {
+		pem, err := os.ReadFile(c.InfluxCACertPath)
+		if err != nil {
+			return nil, fmt.Errorf("INFLUX_CA_CERT_PATH could not be read: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("INFLUX_CA_CERT_PATH contains no valid PEM certificates")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// GroupingInterval returns the time between telemetry points for the configured grouping
+func (c *Config) GroupingInterval() time.Duration {
+	if interval, ok := telemetryGroupingIntervals[c.TelemetryGrouping]; ok {
+		return interval
+	}
+	return telemetryGroupingIntervals["TEN_SECONDS"]
+}
+
+// TimestampLayouts returns the layouts in TelemetryTimestampLayouts, or RFC 3339 when unset
+func (c *Config) TimestampLayouts() []string {
+	var layouts []string
+	for _, layout := range strings.Split(c.TelemetryTimestampLayouts, "|") {
+		if layout = strings.TrimSpace(layout); layout != "" {
+			layouts = append(layouts, layout)
+		}
+	}
+	if len(layouts) == 0 {
+		return []string{time.RFC3339}
+	}
+	return layouts
+}
+
+// EstimatedPointsPerDay returns the expected number of points written per day,
+// useful for sizing InfluxDB retention and the local cache. The client polls a
+// single smart meter per account, so this is one point per grouping interval.
+func (c *Config) EstimatedPointsPerDay() int {
+	return int((24 * time.Hour) / c.GroupingInterval())
+}
+
+// ExtraTags returns the static tags in InfluxDBTags. Keys and values must be non-empty,
+// and keys must be unique and not one of the tags the client sets itself.
+func (c *Config) ExtraTags() (map[string]string, error) {
+	tags := make(map[string]string)
+	if strings.TrimSpace(c.InfluxDBTags) == "" {
+		return tags, nil
+	}
+
+	for _, pair := range strings.Split(c.InfluxDBTags, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" || value == "" {
+			return nil, fmt.Errorf("INFLUXDB_TAGS entry %q must be key=value", strings.TrimSpace(pair))
+		}
+		if reservedTagKeys[key] {
+			return nil, fmt.Errorf("INFLUXDB_TAGS cannot set %q, which the monitor sets itself", key)
+		}
+		if _, dup := tags[key]; dup {
+			return nil, fmt.Errorf("INFLUXDB_TAGS sets %q more than once", key)
+		}
+		tags[key] = value
+	}
+	return tags, nil
+}
+
+// TagKeys returns the sorted tag keys points are written with: source, the meter and unit
+// tags when enabled, and InfluxDBTags
+func (c *Config) TagKeys() []string {
+	keys := []string{"source"}
+	if c.InfluxDBMeterTags {
+		keys = append(keys, "mpan", "meter_serial")
+	}
+	if c.ConsumptionUnit != "" && c.ConsumptionUnit != ConsumptionUnitKWh {
+		keys = append(keys, "unit")
+	}
+	tags, _ := c.ExtraTags()
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CardinalityWarnings describes InfluxDBTags entries that look like per-reading values,
+// such as timestamps or IDs. A static tag is one series, but one templated or changed
+// per deployment or run multiplies them, so these are logged at startup.
+func (c *Config) CardinalityWarnings() []string {
+	tags, err := c.ExtraTags()
+	if err != nil {
+		return nil
+	}
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var warnings []string
+	for _, key := range keys {
+		switch {
+		case highCardinalityTagKeys[strings.ToLower(key)]:
+			warnings = append(warnings, fmt.Sprintf("tag %q is named like a per-reading value; store those as fields, not tags", key))
+		case highCardinalityTagValueRegex.MatchString(tags[key]):
+			warnings = append(warnings, fmt.Sprintf("tag %q has value %q, which looks like a timestamp or ID; each distinct value starts a new series", key, tags[key]))
+		}
+	}
+	return warnings
+}
+
+// ProvidedOctopusToken returns the pre-obtained Kraken token from OctopusToken or the
+// file at OctopusTokenFile, or "" when neither is set
+func (c *Config) ProvidedOctopusToken() (string, error) {
+	if c.OctopusTokenFile == "" {
+		return c.OctopusToken, nil
+	}
+
+	data, err := os.ReadFile(c.OctopusTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read OCTOPUS_TOKEN_FILE: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("OCTOPUS_TOKEN_FILE %s is empty", c.OctopusTokenFile)
+	}
+	return token, nil
+}
+
+// AuditDir returns the directory where raw telemetry responses are stored
+func (c *Config) AuditDir() string {
+	return filepath.Join(c.CacheDir, "audit")
+}
+
+// TokenFile returns the file the Octopus API token is persisted to
+func (c *Config) TokenFile() string {
+	return filepath.Join(c.CacheDir, "octopus_token.json")
+}
+
+// WatermarkFile returns the file the dedup watermark is persisted to
+func (c *Config) WatermarkFile() string {
+	return filepath.Join(c.CacheDir, "watermark.json")
+}
+
+// DisplayLocation returns the timezone for human-facing dates and times: DisplayTimezone,
+// or the host's local time when it is unset
+func (c *Config) DisplayLocation() *time.Location {
+	if c.DisplayTimezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(c.DisplayTimezone)
+	if err != nil {
+		return time.Local // Rejected by Validate
+	}
+	return loc
+}
+
+// ConsumptionScale returns the consumption units per kWh for ConsumptionUnit, or 1 for
+// kWh and when unset
+func (c *Config) ConsumptionScale() float64 {
+	if scale, ok := consumptionUnitScale[c.ConsumptionUnit]; ok {
+		return scale
+	}
+	return 1 // Rejected by Validate
+}
+
+// OnboardingFile returns the file recording when monitoring first started and first saw data
+func (c *Config) OnboardingFile() string {
+	return filepath.Join(c.CacheDir, "onboarding.json")
+}
+
+// Proxy returns the parsed proxy URL, or nil if no proxy is configured
+func (c *Config) Proxy() *url.URL {
+	if c.ProxyURL == "" {
+		return nil
+	}
+	proxyURL, err := url.Parse(c.ProxyURL)
+	if err != nil {
+		return nil
+	}
+	return proxyURL
+}
+
+// validateInfluxDB validates the InfluxDB connection settings
+func (c *Config) validateInfluxDB() error {
+	if c.InfluxDBURL == "" {
+		return fmt.Errorf("INFLUXDB_URL is required")
+	}
+	if err := validateURL(c.InfluxDBURL, "INFLUXDB_URL"); err != nil {
+		return err
+	}
+	if c.InfluxDBToken == "" {
+		return fmt.Errorf("INFLUXDB_TOKEN is required")
+	}
+	if c.InfluxDBOrg == "" {
+		return fmt.Errorf("INFLUXDB_ORG is required")
+	}
+	if !validNameRegex.MatchString(c.InfluxDBOrg) {
+		return fmt.Errorf("INFLUXDB_ORG must contain only alphanumeric characters, underscores, and hyphens")
+	}
+	if !validNameRegex.MatchString(c.InfluxDBBucket) {
+		return fmt.Errorf("INFLUXDB_BUCKET must contain only alphanumeric characters, underscores, and hyphens")
+	}
+	if c.InfluxDBMeasurement == "" {
+		return fmt.Errorf("INFLUXDB_MEASUREMENT is required")
+	}
+	if !validNameRegex.MatchString(c.InfluxDBMeasurement) {
+		return fmt.Errorf("INFLUXDB_MEASUREMENT must contain only alphanumeric characters, underscores, and hyphens")
+	}
+	switch c.InfluxDBWriteMode {
+	case "", WriteModeRaw, WriteModeSummary, WriteModeBoth:
+	default:
+		return fmt.Errorf("INFLUXDB_WRITE_MODE must be one of: raw, summary, both")
+	}
+	if _, ok := consumptionUnitScale[c.ConsumptionUnit]; !ok && c.ConsumptionUnit != "" {
+		return fmt.Errorf("CONSUMPTION_UNIT must be one of: kWh, Wh, J")
+	}
+	if c.InfluxDBSummaryMeasurement != "" && !validNameRegex.MatchString(c.InfluxDBSummaryMeasurement) {
+		return fmt.Errorf("INFLUXDB_SUMMARY_MEASUREMENT must contain only alphanumeric characters, underscores, and hyphens")
+	}
+	if c.InfluxDBMaxTagKeys < 0 {
+		return fmt.Errorf("INFLUXDB_MAX_TAG_KEYS must not be negative")
+	}
+	if _, err := c.ExtraTags(); err != nil {
+		return err
+	}
+	// Every tag key multiplies the series InfluxDB indexes, so cap how many are configured
+	if keys := c.TagKeys(); c.InfluxDBMaxTagKeys > 0 && len(keys) > c.InfluxDBMaxTagKeys {
+		return fmt.Errorf("points would carry %d tag keys (%s), more than INFLUXDB_MAX_TAG_KEYS (%d)",
+			len(keys), strings.Join(keys, ", "), c.InfluxDBMaxTagKeys)
+	}
+	return nil
+}
+
+// validateWeather checks the weather settings when the integration is enabled
+func (c *Config) validateWeather() error {
+	if !c.WeatherEnabled {
+		return nil
+	}
+	if !c.InfluxDBEnabled() {
+		return fmt.Errorf("WEATHER_ENABLED requires the influxdb sink")
+	}
+	switch c.WeatherProvider {
+	case "openweathermap":
+	default:
+		return fmt.Errorf("WEATHER_PROVIDER must be one of: openweathermap")
+	}
+	if c.WeatherAPIKey == "" {
+		return fmt.Errorf("WEATHER_API_KEY is required when WEATHER_ENABLED is set")
+	}
+	if c.WeatherLatitude < -90 || c.WeatherLatitude > 90 {
+		return fmt.Errorf("WEATHER_LATITUDE must be between -90 and 90")
+	}
+	if c.WeatherLongitude < -180 || c.WeatherLongitude > 180 {
+		return fmt.Errorf("WEATHER_LONGITUDE must be between -180 and 180")
+	}
+	if c.WeatherInterval < time.Minute {
+		return fmt.Errorf("WEATHER_INTERVAL_SECONDS must be at least 60 seconds")
+	}
+	if !validNameRegex.MatchString(c.WeatherMeasurement) {
+		return fmt.Errorf("WEATHER_MEASUREMENT must contain only alphanumeric characters, underscores, and hyphens")
+	}
+	return nil
+}
+
+// SummaryMeasurement returns the measurement that per-poll summary points are written to
+func (c *Config) SummaryMeasurement() string {
+	if c.InfluxDBSummaryMeasurement != "" {
+		return c.InfluxDBSummaryMeasurement
+	}
+	return c.InfluxDBMeasurement + "_summary"
+}
+
+// InfluxDBEnabled reports whether data is written to InfluxDB
+func (c *Config) InfluxDBEnabled() bool {
+	return c.Sink != SinkParquet
+}
+
+// StartupSummary returns the effective runtime settings worth logging once at startup.
+// Secrets are never included: InfluxDB is reported by host only, so credentials in the
+// URL and the token stay out of logs, and Slack only by whether it is enabled.
+func (c *Config) StartupSummary() map[string]interface{} {
+	fileMode, dirMode := c.CacheModes()
+	summary := map[string]interface{}{
+		"poll_interval":            c.PollInterval.String(),
+		"telemetry_grouping":       c.TelemetryGrouping,
+		"estimated_points_per_day": c.EstimatedPointsPerDay(),
+		"sink":                     c.Sink,
+		"cache_dir":                c.CacheDir,
+		"cache_file_mode":          fmt.Sprintf("%04o", fileMode),
+		"cache_dir_mode":           fmt.Sprintf("%04o", dirMode),
+		"slack_enabled":            c.SlackEnabled,
+		"weather_enabled":          c.WeatherEnabled,
+		"saving_sessions_enabled":  c.SavingSessionsEnabled,
+		"health_addr":              c.HealthServerAddr,
+		"log_level":                c.LogLevel,
+	}
+	if !c.HealthServerEnabled {
+		summary["health_addr"] = "disabled"
+	}
+
+	if c.InfluxDBEnabled() {
+		if u, err := url.Parse(c.InfluxDBURL); err == nil {
+			summary["influxdb_host"] = u.Host
+		}
+		summary["influxdb_bucket"] = c.InfluxDBBucket
+	} else {
+		summary["parquet_dir"] = c.ParquetDir
+	}
+	return summary
+}
+
 // ValidateRuntime performs runtime validation checks including connectivity
 // This should be called after Validate() to verify the system can start up properly
 func (c *Config) ValidateRuntime(ctx context.Context) error {
@@ -331,6 +1594,9 @@ func (c *Config) ValidateRuntime(ctx context.Context) error {
 	}
 
 	// Validate InfluxDB connectivity (optional - just health check, not full auth)
+	if !c.InfluxDBEnabled() {
+		return nil
+	}
 	if err := c.validateInfluxDBConnectivity(ctx); err != nil {
 		// Only warn about InfluxDB connectivity issues, don't fail startup
 		// The application can run in cache-only mode
@@ -340,6 +1606,31 @@ func (c *Config) ValidateRuntime(ctx context.Context) error {
 	return nil
 }
 
+// CacheModes returns the cache file and directory permissions, defaulting to owner-only access
+func (c *Config) CacheModes() (fileMode, dirMode os.FileMode) {
+	fileMode, err := parseFileMode(c.CacheFileMode, 0600)
+	if err != nil {
+		fileMode = 0600
+	}
+	dirMode, err = parseFileMode(c.CacheDirMode, 0700)
+	if err != nil {
+		dirMode = 0700
+	}
+	return fileMode, dirMode
+}
+
+// parseFileMode parses an octal permission string such as "0640", returning fallback when empty
+func parseFileMode(value string, fallback os.FileMode) (os.FileMode, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid file mode %q", value)
+	}
+	return os.FileMode(mode), nil
+}
+
 // validateCacheDirectory ensures the cache directory exists and is writable
 func (c *Config) validateCacheDirectory() error {
 	// Check if directory exists
@@ -347,7 +1638,8 @@ func (c *Config) validateCacheDirectory() error {
 	if err != nil {
 		if os.IsNotExist(err) {
 			// Try to create it
-			if err := os.MkdirAll(c.CacheDir, 0o755); err != nil {
+			_, dirMode := c.CacheModes()
+			if err := os.MkdirAll(c.CacheDir, dirMode); err != nil {
 				return fmt.Errorf("failed to create cache directory %s: %w", c.CacheDir, err)
 			}
 		} else {
@@ -374,18 +1666,41 @@ func (c *Config) validateCacheDirectory() error {
 	return nil
 }
 
-// validateInfluxDBConnectivity performs a basic health check on the InfluxDB URL
+// validateInfluxDBConnectivity performs a basic health check on the InfluxDB URL, retrying
+// a few times with backoff within ctx before reporting the last failure
 func (c *Config) validateInfluxDBConnectivity(ctx context.Context) error {
 	// Try to reach the InfluxDB health endpoint
 	healthURL := strings.TrimSuffix(c.InfluxDBURL, "/") + "/health"
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL, nil)
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+	}
+	tlsConfig, err := c.InfluxTLSConfig()
 	if err != nil {
-		return fmt.Errorf("failed to create health check request: %w", err)
+		return err
+	}
+	if proxyURL := c.Proxy(); proxyURL != nil || tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if proxyURL != nil {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+		if tlsConfig != nil {
+			transport.TLSClientConfig = tlsConfig
+		}
+		client.Transport = transport
 	}
 
-	client := &http.Client{
-		Timeout: 5 * time.Second,
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = influxCheckInterval
+	operation := func() error { return c.checkInfluxDBHealth(ctx, client, healthURL) }
+	return backoff.Retry(operation, backoff.WithContext(backoff.WithMaxRetries(b, influxCheckRetries), ctx))
+}
+
+// checkInfluxDBHealth makes a single request to the InfluxDB health endpoint
+func (c *Config) checkInfluxDBHealth(ctx context.Context, client *http.Client, healthURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL, nil)
+	if err != nil {
+		return backoff.Permanent(fmt.Errorf("failed to create health check request: %w", err))
 	}
 
 	resp, err := client.Do(req)
@@ -402,23 +1717,63 @@ func (c *Config) validateInfluxDBConnectivity(ctx context.Context) error {
 	return nil
 }
 
-func getEnv(key, defaultValue string) string {
+// resolveEnvMode settles ConfigEnvMode and ConfigEnvAllowlist and returns the filter for
+// the variables that may override config, or nil when none may. CONFIG_ENV_MODE and
+// CONFIG_ENV_ALLOWLIST can tighten the mode from the environment but not loosen a
+// restrictive mode set in config.yaml.
+func resolveEnvMode(cfg *Config) func(string) bool {
+	if cfg.ConfigEnvMode == "" || cfg.ConfigEnvMode == ConfigEnvAll {
+		if val := os.Getenv("CONFIG_ENV_MODE"); val != "" {
+			cfg.ConfigEnvMode = strings.ToLower(strings.TrimSpace(val))
+		}
+		if val := os.Getenv("CONFIG_ENV_ALLOWLIST"); val != "" && cfg.ConfigEnvAllowlist == "" {
+			cfg.ConfigEnvAllowlist = val
+		}
+	}
+
+	switch cfg.ConfigEnvMode {
+	case "", ConfigEnvAll:
+		return func(string) bool { return true }
+	case ConfigEnvAllowlist:
+		allowlist := make(map[string]bool)
+		for _, key := range strings.Split(cfg.ConfigEnvAllowlist, ",") {
+			if key = strings.ToUpper(strings.TrimSpace(key)); key != "" {
+				allowlist[key] = true
+			}
+		}
+		return func(key string) bool { return allowlist[key] }
+	default:
+		// File only, or an unknown mode that Validate rejects
+		return nil
+	}
+}
+
+// envReader reads environment variables for overrideWithEnv, skipping any that its
+// ConfigEnvMode filter rejects. A nil filter allows every variable.
+type envReader struct {
+	allowed func(key string) bool
+}
+
+func (e envReader) getEnv(key, defaultValue string) string {
+	if e.allowed != nil && !e.allowed(key) {
+		return defaultValue
+	}
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
-func getEnvAsInt(key string, defaultValue int) int {
-	valueStr := getEnv(key, "")
+func (e envReader) getEnvAsInt(key string, defaultValue int) int {
+	valueStr := e.getEnv(key, "")
 	if value, err := strconv.Atoi(valueStr); err == nil {
 		return value
 	}
 	return defaultValue
 }
 
-func getEnvAsBool(key string, defaultValue bool) bool {
-	valueStr := getEnv(key, "")
+func (e envReader) getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := e.getEnv(key, "")
 	if valueStr == "" {
 		return defaultValue
 	}
@@ -430,8 +1785,8 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 }
 
 // Helper functions to get env vars as pointers to distinguish between unset and zero-value
-func getEnvAsIntPtr(key string) (*int, bool) {
-	valueStr := getEnv(key, "")
+func (e envReader) getEnvAsIntPtr(key string) (*int, bool) {
+	valueStr := e.getEnv(key, "")
 	if valueStr == "" {
 		return nil, false
 	}
@@ -441,8 +1796,19 @@ func getEnvAsIntPtr(key string) (*int, bool) {
 	return nil, false
 }
 
-func getEnvAsBoolPtr(key string) (*bool, bool) {
-	valueStr := getEnv(key, "")
+func (e envReader) getEnvAsFloatPtr(key string) (*float64, bool) {
+	valueStr := e.getEnv(key, "")
+	if valueStr == "" {
+		return nil, false
+	}
+	if value, err := strconv.ParseFloat(valueStr, 64); err == nil {
+		return &value, true
+	}
+	return nil, false
+}
+
+func (e envReader) getEnvAsBoolPtr(key string) (*bool, bool) {
+	valueStr := e.getEnv(key, "")
 	if valueStr == "" {
 		return nil, false
 	}
@@ -453,6 +1819,22 @@ func getEnvAsBoolPtr(key string) (*bool, bool) {
 	return &value, true
 }
 
+// validateSlackWebhook validates a Slack webhook URL
+func validateSlackWebhook(urlStr, fieldName string) error {
+	if err := validateURL(urlStr, fieldName); err != nil {
+		return err
+	}
+	// Ensure it's a hooks.slack.com URL (or example.com for testing)
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil {
+		return fmt.Errorf("%s is not a valid URL: %w", fieldName, err)
+	}
+	if parsedURL.Host != "hooks.slack.com" && parsedURL.Host != "example.com" {
+		return fmt.Errorf("%s must be a hooks.slack.com URL", fieldName)
+	}
+	return nil
+}
+
 // validateURL validates a URL to prevent SSRF and other attacks
 func validateURL(urlStr, fieldName string) error {
 	if urlStr == "" {
@@ -496,6 +1878,26 @@ func validateURL(urlStr, fieldName string) error {
 	return nil
 }
 
+// validateProxyURL validates the outbound proxy URL
+func validateProxyURL(proxyURL string) error {
+	parsedURL, err := url.Parse(proxyURL)
+	if err != nil {
+		return fmt.Errorf("PROXY_URL is not a valid URL: %w", err)
+	}
+
+	switch parsedURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("PROXY_URL must use http, https, socks5 or socks5h scheme")
+	}
+
+	if parsedURL.Host == "" {
+		return fmt.Errorf("PROXY_URL must have a host")
+	}
+
+	return nil
+}
+
 // sanitizePath cleans and validates a file path to prevent path traversal attacks
 func sanitizePath(path string) string {
 	// Clean the path (removes .., ., extra slashes, etc.)
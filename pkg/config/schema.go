@@ -0,0 +1,233 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JSONSchema describes config.yaml as a JSON Schema (draft 2020-12), for editor
+// autocomplete and validating a file before deploying it
+type JSONSchema struct {
+	Schema               string                    `json:"$schema"`
+	Title                string                    `json:"title"`
+	Type                 string                    `json:"type"`
+	Properties           map[string]SchemaProperty `json:"properties"`
+	Required             []string                  `json:"required"`
+	AnyOf                []SchemaRequirement       `json:"anyOf,omitempty"`
+	AdditionalProperties bool                      `json:"additionalProperties"`
+}
+
+// SchemaRequirement is one alternative set of fields, of which a config must have at
+// least one set
+type SchemaRequirement struct {
+	Required []string `json:"required"`
+}
+
+// SchemaProperty describes one config.yaml field
+type SchemaProperty struct {
+	Type        string      `json:"type"`
+	Description string      `json:"description,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+	Enum        []string    `json:"enum,omitempty"`
+	Minimum     *float64    `json:"minimum,omitempty"`
+	Maximum     *float64    `json:"maximum,omitempty"`
+	MinLength   *int        `json:"minLength,omitempty"`
+	Pattern     string      `json:"pattern,omitempty"`
+	Format      string      `json:"format,omitempty"`
+}
+
+// schemaRequired lists the fields Validate always requires. They may instead be set
+// through the environment, which the schema cannot see.
+var schemaRequired = []string{"octopus_account_number"}
+
+// schemaCredentials lists the fields that can each authenticate with the Octopus API,
+// one of which Validate requires
+var schemaCredentials = []SchemaRequirement{
+	{Required: []string{"octopus_api_key"}},
+	{Required: []string{"octopus_token"}},
+	{Required: []string{"octopus_token_file"}},
+}
+
+// schemaConstraints mirrors the per-field rules in Validate, keyed by YAML name. Rules
+// that depend on other fields (e.g. timeouts bounded by poll_timeout_seconds) are left out.
+var schemaConstraints = map[string]SchemaProperty{
+	"octopus_api_key":             {MinLength: intPtr(minAPIKeyLength)},
+	"octopus_account_number":      {MinLength: intPtr(2)},
+	"sink":                        {Enum: []string{SinkInfluxDB, SinkParquet}},
+	"influxdb_url":                {Format: "uri"},
+	"influxdb_org":                {Pattern: validNameRegex.String()},
+	"influxdb_bucket":             {Pattern: validNameRegex.String()},
+	"influxdb_measurement":        {Pattern: validNameRegex.String()},
+	"influxdb_write_mode":         {Enum: []string{WriteModeRaw, WriteModeSummary, WriteModeBoth}},
+	"influxdb_max_tag_keys":       {Minimum: floatPtr(0)},
+	"consumption_unit":            {Enum: []string{ConsumptionUnitKWh, ConsumptionUnitWh, ConsumptionUnitJoule}},
+	"telemetry_grouping":          {Enum: sortedKeys(telemetryGroupingIntervals)},
+	"log_level":                   {Enum: sortedKeys(validLogLevel)},
+	"cache_sync_order":            {Enum: []string{CacheSyncOldest, CacheSyncNewest}},
+	"cache_file_mode":             {Pattern: "^[0-7]{1,4}$"},
+	"cache_dir_mode":              {Pattern: "^[0-7]{1,4}$"},
+	"slack_webhook_url":           {Format: "uri"},
+	"slack_webhook_error":         {Format: "uri"},
+	"slack_webhook_info":          {Format: "uri"},
+	"slack_max_message_length":    {Minimum: floatPtr(0)},
+	"weather_provider":            {Enum: []string{"openweathermap"}},
+	"weather_measurement":         {Pattern: validNameRegex.String()},
+	"saving_sessions_measurement": {Pattern: validNameRegex.String()},
+	"config_env_mode":             {Enum: []string{ConfigEnvAll, ConfigEnvFile, ConfigEnvAllowlist}},
+
+	"poll_interval_seconds":             {Minimum: floatPtr(minPollInterval.Seconds()), Maximum: floatPtr(maxPollInterval.Seconds())},
+	"adaptive_poll_min_seconds":         {Minimum: floatPtr(minPollInterval.Seconds())},
+	"adaptive_poll_max_seconds":         {Maximum: floatPtr(maxPollInterval.Seconds())},
+	"parquet_flush_interval_seconds":    {Minimum: floatPtr(1)},
+	"log_sample_every_n":                {Minimum: floatPtr(1)},
+	"influx_connect_timeout_seconds":    {Minimum: floatPtr(1)},
+	"influx_write_timeout_seconds":      {Minimum: floatPtr(1)},
+	"influx_health_cache_ttl_seconds":   {Minimum: floatPtr(0)},
+	"influx_max_idle_conns":             {Minimum: floatPtr(0)},
+	"influx_max_idle_conns_per_host":    {Minimum: floatPtr(0)},
+	"influx_idle_conn_timeout_seconds":  {Minimum: floatPtr(0)},
+	"influx_bucket_retention_seconds":   {Minimum: floatPtr(0)},
+	"poll_timeout_seconds":              {Minimum: floatPtr(1)},
+	"shutdown_timeout_seconds":          {Minimum: floatPtr(1)},
+	"cache_sync_timeout_seconds":        {Minimum: floatPtr(1)},
+	"reconnect_max_elapsed_seconds":     {Minimum: floatPtr(10)},
+	"consecutive_error_threshold":       {Minimum: floatPtr(1)},
+	"max_backoff_factor":                {Minimum: floatPtr(1)},
+	"degraded_grace_period_seconds":     {Minimum: floatPtr(0)},
+	"maintenance_poll_interval_seconds": {Minimum: floatPtr(0)},
+	"maintenance_alert_after_seconds":   {Minimum: floatPtr(0)},
+	"max_poll_window_seconds":           {Minimum: floatPtr(0)},
+	"first_data_timeout_seconds":        {Minimum: floatPtr(0)},
+	"max_data_staleness_seconds":        {Minimum: floatPtr(0)},
+	"max_points_per_poll":               {Minimum: floatPtr(0)},
+	"max_point_age_seconds":             {Minimum: floatPtr(0)},
+	"flatline_threshold_readings":       {Minimum: floatPtr(0)},
+	"flatline_active_start_hour":        {Minimum: floatPtr(0), Maximum: floatPtr(23)},
+	"flatline_active_end_hour":          {Minimum: floatPtr(0), Maximum: floatPtr(23)},
+	"octopus_max_retry_elapsed_seconds": {Minimum: floatPtr(1)},
+	"octopus_max_interval_seconds":      {Minimum: floatPtr(1)},
+	"octopus_request_timeout_seconds":   {Minimum: floatPtr(0)},
+	"cache_retention_days":              {Minimum: floatPtr(1)},
+	"cache_max_size_mb":                 {Minimum: floatPtr(0)},
+	"cache_min_free_disk_mb":            {Minimum: floatPtr(0)},
+	"cache_failure_threshold":           {Minimum: floatPtr(0)},
+	"cache_sync_batch_size":             {Minimum: floatPtr(0)},
+	"catch_up_threshold":                {Minimum: floatPtr(0)},
+	"health_max_concurrent_checks":      {Minimum: floatPtr(0)},
+	"write_retry_attempts":              {Minimum: floatPtr(0)},
+	"write_retry_delay_seconds":         {Minimum: floatPtr(0)},
+	"write_retry_max_points":            {Minimum: floatPtr(0)},
+	"catch_up_chunk_size":               {Minimum: floatPtr(1)},
+	"catch_up_pause_seconds":            {Minimum: floatPtr(0)},
+	"round_consumption_delta":           {Minimum: floatPtr(0), Maximum: floatPtr(maxRoundingPlaces)},
+	"round_demand":                      {Minimum: floatPtr(0), Maximum: floatPtr(maxRoundingPlaces)},
+	"round_cost_delta":                  {Minimum: floatPtr(0), Maximum: floatPtr(maxRoundingPlaces)},
+	"round_consumption":                 {Minimum: floatPtr(0), Maximum: floatPtr(maxRoundingPlaces)},
+	"min_consumption_delta":             {Minimum: floatPtr(0)},
+	"weather_latitude":                  {Minimum: floatPtr(-90), Maximum: floatPtr(90)},
+	"weather_longitude":                 {Minimum: floatPtr(-180), Maximum: floatPtr(180)},
+	"weather_interval_seconds":          {Minimum: floatPtr(60)},
+	"saving_sessions_interval_seconds":  {Minimum: floatPtr(300)},
+
+	"octopus_dial_timeout_seconds":            {Minimum: floatPtr(0)},
+	"octopus_tls_handshake_timeout_seconds":   {Minimum: floatPtr(0)},
+	"octopus_response_header_timeout_seconds": {Minimum: floatPtr(0)},
+}
+
+// Schema returns the JSON Schema for config.yaml. Field names and types come from the
+// Config struct and defaults from defaultConfig, so new fields appear automatically.
+func Schema() JSONSchema {
+	schema := JSONSchema{
+		Schema:     "https://json-schema.org/draft/2020-12/schema",
+		Title:      "Octopus Home Mini Monitor configuration",
+		Type:       "object",
+		Properties: make(map[string]SchemaProperty),
+		Required:   schemaRequired,
+		AnyOf:      schemaCredentials,
+	}
+
+	defaults := reflect.ValueOf(defaultConfig()).Elem()
+	configType := defaults.Type()
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		property := schemaConstraints[name]
+		property.Type, property.Description = schemaType(field.Type, name)
+		if value := schemaDefault(defaults.Field(i), name); value != nil {
+			property.Default = value
+		}
+		schema.Properties[name] = property
+	}
+
+	return schema
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// schemaType returns the JSON type for a field, describing the unit of durations,
+// which config.yaml gives as a whole number of the unit named by the key's suffix
+func schemaType(t reflect.Type, name string) (string, string) {
+	if t == durationType {
+		return "integer", "Duration in " + durationUnitName(name)
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean", ""
+	case reflect.Int, reflect.Int64:
+		return "integer", ""
+	case reflect.Float64:
+		return "number", ""
+	default:
+		return "string", ""
+	}
+}
+
+// schemaDefault converts a default value to its config.yaml form, or nil for none
+func schemaDefault(value reflect.Value, name string) interface{} {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Type() == durationType {
+		return int64(time.Duration(value.Int()) / durationUnit(name))
+	}
+	return value.Interface()
+}
+
+func durationUnit(name string) time.Duration {
+	if strings.HasSuffix(name, "_hours") {
+		return time.Hour
+	}
+	return time.Second
+}
+
+func durationUnitName(name string) string {
+	if strings.HasSuffix(name, "_hours") {
+		return "hours"
+	}
+	return "seconds"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func intPtr(n int) *int { return &n }
+
+func floatPtr(f float64) *float64 { return &f }
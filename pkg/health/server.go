@@ -2,14 +2,29 @@ package health
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"math"
+	"net"
 	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
+const (
+	// unixAddrPrefix marks an address as a Unix domain socket path
+	unixAddrPrefix = "unix:"
+	// unixSocketMode restricts the socket to the owner and group
+	unixSocketMode = 0o660
+)
+
 // Status represents the health status of a component
 type Status string
 
@@ -30,6 +45,8 @@ type HealthResponse struct {
 	Status     Status                     `json:"status"`
 	Timestamp  string                     `json:"timestamp"`
 	Version    string                     `json:"version,omitempty"`
+	Commit     string                     `json:"commit,omitempty"`
+	BuildDate  string                     `json:"build_date,omitempty"`
 	Components map[string]ComponentHealth `json:"components,omitempty"`
 }
 
@@ -40,16 +57,56 @@ type ReadinessResponse struct {
 	Components map[string]ComponentHealth `json:"components"`
 }
 
+// StatsResponse represents the /stats endpoint response
+type StatsResponse struct {
+	Timestamp string                 `json:"timestamp"`
+	Stats     map[string]interface{} `json:"stats"`
+}
+
+// ErrorsResponse represents the /errors endpoint response
+type ErrorsResponse struct {
+	Timestamp string      `json:"timestamp"`
+	Errors    interface{} `json:"errors"`
+}
+
+// TestAlertResponse represents the /test-alert endpoint response
+type TestAlertResponse struct {
+	Sent      bool   `json:"sent"`
+	Timestamp string `json:"timestamp"`
+	Error     string `json:"error,omitempty"`
+}
+
+// AlertSender sends a test notification, returning the delivery error
+type AlertSender func() error
+
+// StatsProvider returns a JSON-serializable snapshot of operational statistics
+type StatsProvider func() interface{}
+
+// MetricsProvider writes metrics in the Prometheus text exposition format, e.g. with
+// WriteCounter and WriteHistogram
+type MetricsProvider func(w io.Writer)
+
 // Checker is a function that checks the health of a component
 type Checker func(ctx context.Context) ComponentHealth
 
 // Server provides health check endpoints
 type Server struct {
-	addr     string
-	server   *http.Server
-	version  string
-	checkers map[string]Checker
-	mu       sync.RWMutex
+	addr      string
+	server    *http.Server
+	version   string
+	commit    string
+	buildDate string
+	checkers  map[string]Checker
+	stats     map[string]StatsProvider
+	metrics   map[string]MetricsProvider
+	errors    StatsProvider // Serves /errors when set; nil leaves the endpoint disabled
+	testAlert AlertSender   // Serves /test-alert when set; nil leaves the endpoint disabled
+	// Most checkers /ready runs at once; 0 runs them all together
+	maxConcurrentChecks int
+	// Basic-auth credentials required by debug endpoints; empty leaves them open
+	debugUser     string
+	debugPassword string
+	mu            sync.RWMutex
 }
 
 // NewServer creates a new health check server
@@ -58,9 +115,17 @@ func NewServer(addr, version string) *Server {
 		addr:     addr,
 		version:  version,
 		checkers: make(map[string]Checker),
+		stats:    make(map[string]StatsProvider),
+		metrics:  make(map[string]MetricsProvider),
 	}
 }
 
+// SetBuildInfo sets the commit and build date reported by /health
+func (s *Server) SetBuildInfo(commit, buildDate string) {
+	s.commit = commit
+	s.buildDate = buildDate
+}
+
 // RegisterChecker registers a health checker for a component
 func (s *Server) RegisterChecker(name string, checker Checker) {
 	s.mu.Lock()
@@ -68,15 +133,58 @@ func (s *Server) RegisterChecker(name string, checker Checker) {
 	s.checkers[name] = checker
 }
 
+// RegisterStats registers a statistics provider exposed under name at /stats
+func (s *Server) RegisterStats(name string, provider StatsProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats[name] = provider
+}
+
+// RegisterMetrics registers a metrics provider served under name at /metrics
+func (s *Server) RegisterMetrics(name string, provider MetricsProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics[name] = provider
+}
+
+// SetErrorsProvider enables the /errors debug endpoint, which reports the value
+// returned by provider. Without a provider /errors responds 404.
+func (s *Server) SetErrorsProvider(provider StatsProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors = provider
+}
+
+// SetTestAlertSender enables the /test-alert debug endpoint, which sends a notification
+// through sender on POST. Without a sender /test-alert responds 404.
+func (s *Server) SetTestAlertSender(sender AlertSender) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.testAlert = sender
+}
+
+// SetMaxConcurrentChecks limits how many checkers /ready runs at once, so a probe
+// against many components doesn't hit every backend simultaneously. 0 removes the limit.
+func (s *Server) SetMaxConcurrentChecks(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxConcurrentChecks = n
+}
+
+// SetDebugAuth requires HTTP basic auth with username and password on the debug
+// endpoints. /health, /ready, /stats and /metrics stay open for probes and scrapers.
+func (s *Server) SetDebugAuth(username, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.debugUser = username
+	s.debugPassword = password
+}
+
 // Start starts the health check HTTP server
 func (s *Server) Start() error {
-	mux := http.NewServeMux()
-	mux.HandleFunc("/health", s.healthHandler)
-	mux.HandleFunc("/ready", s.readinessHandler)
-
 	s.server = &http.Server{
 		Addr:         s.addr,
-		Handler:      mux,
+		Handler:      s.routes(),
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  120 * time.Second,
@@ -84,6 +192,21 @@ func (s *Server) Start() error {
 
 	log.Printf("Starting health check server on %s", s.addr)
 
+	if socketPath, ok := s.socketPath(); ok {
+		listener, err := listenUnix(socketPath)
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+				log.Printf("Health server error: %v", err)
+			}
+		}()
+
+		return nil
+	}
+
 	go func() {
 		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Printf("Health server error: %v", err)
@@ -93,6 +216,72 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// routes registers the endpoints, wrapping the debug endpoints in requireDebugAuth
+func (s *Server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", s.healthHandler)
+	mux.HandleFunc("/ready", s.readinessHandler)
+	mux.HandleFunc("/stats", s.statsHandler)
+	mux.HandleFunc("/metrics", s.metricsHandler)
+	mux.HandleFunc("/errors", s.requireDebugAuth(s.errorsHandler))
+	mux.HandleFunc("/test-alert", s.requireDebugAuth(s.testAlertHandler))
+	return mux
+}
+
+// requireDebugAuth rejects requests without the SetDebugAuth credentials with 401
+func (s *Server) requireDebugAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		wantUser, wantPassword := s.debugUser, s.debugPassword
+		s.mu.RUnlock()
+
+		if wantUser != "" || wantPassword != "" {
+			user, password, ok := r.BasicAuth()
+			// Compare both fields every time so the response time doesn't reveal which was wrong
+			userMatch := subtle.ConstantTimeCompare([]byte(user), []byte(wantUser))
+			passwordMatch := subtle.ConstantTimeCompare([]byte(password), []byte(wantPassword))
+			if !ok || userMatch&passwordMatch != 1 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="debug", charset="UTF-8"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+// socketPath returns the Unix socket path if the address uses the unix: form
+func (s *Server) socketPath() (string, bool) {
+	if !strings.HasPrefix(s.addr, unixAddrPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(s.addr, unixAddrPrefix), true
+}
+
+// listenUnix listens on a Unix domain socket, replacing any stale socket file
+func listenUnix(path string) (net.Listener, error) {
+	if path == "" {
+		return nil, fmt.Errorf("unix socket path is empty")
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on unix socket %s: %w", path, err)
+	}
+
+	if err := os.Chmod(path, unixSocketMode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set permissions on unix socket %s: %w", path, err)
+	}
+
+	return listener, nil
+}
+
 // Stop gracefully stops the health check server
 func (s *Server) Stop(ctx context.Context) error {
 	if s.server == nil {
@@ -100,7 +289,16 @@ func (s *Server) Stop(ctx context.Context) error {
 	}
 
 	log.Println("Stopping health check server...")
-	return s.server.Shutdown(ctx)
+	err := s.server.Shutdown(ctx)
+
+	// Remove the socket file so the path can be reused
+	if socketPath, ok := s.socketPath(); ok {
+		if rmErr := os.Remove(socketPath); rmErr != nil && !os.IsNotExist(rmErr) && err == nil {
+			err = fmt.Errorf("failed to remove unix socket %s: %w", socketPath, rmErr)
+		}
+	}
+
+	return err
 }
 
 // healthHandler handles the /health endpoint (liveness check)
@@ -110,6 +308,8 @@ func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
 		Status:    StatusHealthy,
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 		Version:   s.version,
+		Commit:    s.commit,
+		BuildDate: s.buildDate,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -128,21 +328,32 @@ func (s *Server) readinessHandler(w http.ResponseWriter, r *http.Request) {
 	for name, checker := range s.checkers {
 		checkers[name] = checker
 	}
+	limit := s.maxConcurrentChecks
 	s.mu.RUnlock()
 
 	components := make(map[string]ComponentHealth)
 	ready := true
 
-	// Check all components in parallel
+	// Check all components in parallel, at most limit at a time when set
 	var wg sync.WaitGroup
 	var mu sync.Mutex
+	var slots chan struct{}
+	if limit > 0 {
+		slots = make(chan struct{}, limit)
+	}
 
 	for name, checker := range checkers {
 		wg.Add(1)
 		go func(componentName string, check Checker) {
 			defer wg.Done()
 
-			health := check(ctx)
+			var health ComponentHealth
+			if acquireSlot(ctx, slots) {
+				health = check(ctx)
+				releaseSlot(slots)
+			} else {
+				health = ComponentHealth{Status: StatusUnhealthy, Message: "check timed out waiting to run"}
+			}
 
 			mu.Lock()
 			components[componentName] = health
@@ -171,6 +382,157 @@ func (s *Server) readinessHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// acquireSlot takes a slot from slots, waiting until one is free or ctx is done.
+// A nil slots is unbounded and always succeeds.
+func acquireSlot(ctx context.Context, slots chan struct{}) bool {
+	if slots == nil {
+		return true
+	}
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// releaseSlot returns a slot taken by acquireSlot
+func releaseSlot(slots chan struct{}) {
+	if slots != nil {
+		<-slots
+	}
+}
+
+// statsHandler handles the /stats endpoint
+func (s *Server) statsHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	providers := make(map[string]StatsProvider, len(s.stats))
+	for name, provider := range s.stats {
+		providers[name] = provider
+	}
+	s.mu.RUnlock()
+
+	stats := make(map[string]interface{}, len(providers))
+	for name, provider := range providers {
+		stats[name] = provider()
+	}
+
+	response := StatsResponse{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Stats:     stats,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	//nolint:errcheck // Error logged implicitly by HTTP layer
+	json.NewEncoder(w).Encode(response)
+}
+
+// metricsHandler handles the /metrics endpoint, writing each provider's metrics in
+// name order so scrapes are stable
+func (s *Server) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	names := make([]string, 0, len(s.metrics))
+	providers := make(map[string]MetricsProvider, len(s.metrics))
+	for name, provider := range s.metrics {
+		names = append(names, name)
+		providers[name] = provider
+	}
+	s.mu.RUnlock()
+	sort.Strings(names)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	for _, name := range names {
+		providers[name](w)
+	}
+}
+
+// WriteCounter writes a counter in the Prometheus text exposition format
+func WriteCounter(w io.Writer, name, help string, value int64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, value)
+}
+
+// WriteHistogram writes a cumulative histogram in the Prometheus text exposition format.
+// buckets maps each upper bound, including "+Inf", to the count at or below it.
+func WriteHistogram(w io.Writer, name, help string, buckets map[string]int64, sum float64, count int64) {
+	bounds := make([]string, 0, len(buckets))
+	for bound := range buckets {
+		bounds = append(bounds, bound)
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bucketBound(bounds[i]) < bucketBound(bounds[j]) })
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for _, bound := range bounds {
+		fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", name, bound, buckets[bound])
+	}
+	fmt.Fprintf(w, "%s_sum %s\n", name, strconv.FormatFloat(sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count %d\n", name, count)
+}
+
+// bucketBound parses a histogram bucket bound, sorting unparseable ones last with +Inf
+func bucketBound(bound string) float64 {
+	value, err := strconv.ParseFloat(bound, 64)
+	if err != nil {
+		return math.Inf(1)
+	}
+	return value
+}
+
+// errorsHandler handles the /errors debug endpoint
+func (s *Server) errorsHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	provider := s.errors
+	s.mu.RUnlock()
+
+	if provider == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	response := ErrorsResponse{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Errors:    provider(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	//nolint:errcheck // Error logged implicitly by HTTP layer
+	json.NewEncoder(w).Encode(response)
+}
+
+// testAlertHandler handles the /test-alert debug endpoint, responding 502 when the
+// notification could not be delivered
+func (s *Server) testAlertHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	sender := s.testAlert
+	s.mu.RUnlock()
+
+	if sender == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := TestAlertResponse{Sent: true}
+	statusCode := http.StatusOK
+	if err := sender(); err != nil {
+		response.Sent = false
+		response.Error = err.Error()
+		statusCode = http.StatusBadGateway
+	}
+	response.Timestamp = time.Now().UTC().Format(time.RFC3339)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	//nolint:errcheck // Error logged implicitly by HTTP layer
+	json.NewEncoder(w).Encode(response)
+}
+
 // SimpleChecker creates a simple health checker from a function
 func SimpleChecker(name string, checkFunc func() error) Checker {
 	return func(ctx context.Context) ComponentHealth {
@@ -202,3 +564,20 @@ func ContextChecker(name string, checkFunc func(ctx context.Context) error) Chec
 		}
 	}
 }
+
+// DegradedChecker creates a health checker for a non-critical component: failures are
+// reported as degraded, which does not fail readiness
+func DegradedChecker(name string, checkFunc func(ctx context.Context) error) Checker {
+	return func(ctx context.Context) ComponentHealth {
+		if err := checkFunc(ctx); err != nil {
+			return ComponentHealth{
+				Status:  StatusDegraded,
+				Message: fmt.Sprintf("%s degraded: %v", name, err),
+			}
+		}
+		return ComponentHealth{
+			Status:  StatusHealthy,
+			Message: fmt.Sprintf("%s is healthy", name),
+		}
+	}
+}
@@ -3,9 +3,16 @@ package health
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -52,6 +59,226 @@ func TestHealthHandler(t *testing.T) {
 	}
 }
 
+func TestHealthHandler_BuildInfo(t *testing.T) {
+	server := NewServer(":8080", "v1.2.3")
+	server.SetBuildInfo("abc1234", "2024-01-01T00:00:00Z")
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	w := httptest.NewRecorder()
+
+	server.healthHandler(w, req)
+
+	var response HealthResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if response.Version != "v1.2.3" || response.Commit != "abc1234" || response.BuildDate != "2024-01-01T00:00:00Z" {
+		t.Errorf("build info = %q/%q/%q, want v1.2.3/abc1234/2024-01-01T00:00:00Z",
+			response.Version, response.Commit, response.BuildDate)
+	}
+}
+
+func TestStatsHandler(t *testing.T) {
+	server := NewServer(":8080", "1.0.0")
+	server.RegisterStats("sizing", func() interface{} {
+		return map[string]int{"estimated_points_per_day": 8640}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	w := httptest.NewRecorder()
+
+	server.statsHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("status code = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	var response struct {
+		Stats map[string]map[string]int `json:"stats"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if got := response.Stats["sizing"]["estimated_points_per_day"]; got != 8640 {
+		t.Errorf("estimated_points_per_day = %v, want 8640", got)
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	server := NewServer(":8080", "1.0.0")
+	server.RegisterMetrics("sync", func(w io.Writer) {
+		WriteHistogram(w, "sync_seconds", "Sync duration.", map[string]int64{"+Inf": 3, "10": 2, "0.5": 1}, 4.5, 3)
+	})
+	server.RegisterMetrics("alerts", func(w io.Writer) {
+		WriteCounter(w, "alerts_sent_total", "Alerts sent.", 7)
+	})
+
+	w := httptest.NewRecorder()
+	server.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("status code = %v, want %v", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want the Prometheus text format", got)
+	}
+
+	// Providers in name order, buckets in bound order
+	want := `# HELP alerts_sent_total Alerts sent.
+# TYPE alerts_sent_total counter
+alerts_sent_total 7
+# HELP sync_seconds Sync duration.
+# TYPE sync_seconds histogram
+sync_seconds_bucket{le="0.5"} 1
+sync_seconds_bucket{le="10"} 2
+sync_seconds_bucket{le="+Inf"} 3
+sync_seconds_sum 4.5
+sync_seconds_count 3
+`
+	if got := w.Body.String(); got != want {
+		t.Errorf("metrics =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestErrorsHandler(t *testing.T) {
+	server := NewServer(":8080", "1.0.0")
+
+	// Disabled until a provider is set
+	w := httptest.NewRecorder()
+	server.errorsHandler(w, httptest.NewRequest(http.MethodGet, "/errors", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status code without provider = %v, want %v", w.Code, http.StatusNotFound)
+	}
+
+	server.SetErrorsProvider(func() interface{} {
+		return map[string]map[string]string{"octopus": {"message": "request timed out"}}
+	})
+
+	w = httptest.NewRecorder()
+	server.errorsHandler(w, httptest.NewRequest(http.MethodGet, "/errors", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status code = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	var response struct {
+		Errors map[string]map[string]string `json:"errors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if got := response.Errors["octopus"]["message"]; got != "request timed out" {
+		t.Errorf("octopus message = %q, want %q", got, "request timed out")
+	}
+}
+
+func TestTestAlertHandler(t *testing.T) {
+	server := NewServer(":8080", "1.0.0")
+	routes := server.routes()
+
+	// Disabled until a sender is set
+	w := httptest.NewRecorder()
+	routes.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/test-alert", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status code without sender = %v, want %v", w.Code, http.StatusNotFound)
+	}
+
+	var sent []string
+	var sendErr error
+	server.SetTestAlertSender(func() error {
+		sent = append(sent, "Test Alert")
+		return sendErr
+	})
+
+	w = httptest.NewRecorder()
+	routes.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test-alert", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status code = %v, want %v", w.Code, http.StatusMethodNotAllowed)
+	}
+	if len(sent) != 0 {
+		t.Errorf("GET sent %d alerts, want none", len(sent))
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantSent bool
+	}{
+		{"delivered", nil, http.StatusOK, true},
+		{"delivery failed", errors.New("slack returned client error status: 404"), http.StatusBadGateway, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent, sendErr = nil, tt.err
+
+			w := httptest.NewRecorder()
+			routes.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/test-alert", nil))
+
+			if len(sent) != 1 {
+				t.Errorf("alerts sent = %d, want 1", len(sent))
+			}
+			if w.Code != tt.wantCode {
+				t.Errorf("status code = %v, want %v", w.Code, tt.wantCode)
+			}
+
+			var response TestAlertResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Sent != tt.wantSent {
+				t.Errorf("sent = %v, want %v", response.Sent, tt.wantSent)
+			}
+			if tt.err != nil && response.Error != tt.err.Error() {
+				t.Errorf("error = %q, want %q", response.Error, tt.err.Error())
+			}
+		})
+	}
+}
+
+func TestDebugAuth(t *testing.T) {
+	server := NewServer(":8080", "1.0.0")
+	server.SetErrorsProvider(func() interface{} { return map[string]string{} })
+	server.SetDebugAuth("admin", "s3cret")
+	routes := server.routes()
+
+	tests := []struct {
+		name     string
+		path     string
+		user     string
+		password string
+		noAuth   bool
+		wantCode int
+	}{
+		{"debug route with credentials", "/errors", "admin", "s3cret", false, http.StatusOK},
+		{"debug route without credentials", "/errors", "", "", true, http.StatusUnauthorized},
+		{"debug route with wrong password", "/errors", "admin", "wrong", false, http.StatusUnauthorized},
+		{"debug route with wrong username", "/errors", "root", "s3cret", false, http.StatusUnauthorized},
+		{"health stays open", "/health", "", "", true, http.StatusOK},
+		{"readiness stays open", "/ready", "", "", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if !tt.noAuth {
+				req.SetBasicAuth(tt.user, tt.password)
+			}
+			w := httptest.NewRecorder()
+			routes.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("status code = %v, want %v", w.Code, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 response has no WWW-Authenticate header")
+			}
+		})
+	}
+}
+
 func TestReadinessHandler_AllHealthy(t *testing.T) {
 	server := NewServer(":8080", "1.0.0")
 
@@ -130,6 +357,32 @@ func TestReadinessHandler_OneUnhealthy(t *testing.T) {
 	}
 }
 
+func TestReadinessHandler_DegradedStaysReady(t *testing.T) {
+	server := NewServer(":8080", "1.0.0")
+
+	server.RegisterChecker("slack", DegradedChecker("Slack", func(ctx context.Context) error {
+		return fmt.Errorf("webhook returned status: 404")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ready", nil)
+	w := httptest.NewRecorder()
+
+	server.readinessHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("status code = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	var response ReadinessResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if response.Components["slack"].Status != StatusDegraded {
+		t.Errorf("slack status = %v, want %v", response.Components["slack"].Status, StatusDegraded)
+	}
+}
+
 func TestReadinessHandler_NoCheckers(t *testing.T) {
 	server := NewServer(":8080", "1.0.0")
 
@@ -152,6 +405,47 @@ func TestReadinessHandler_NoCheckers(t *testing.T) {
 	}
 }
 
+func TestReadinessHandler_MaxConcurrentChecks(t *testing.T) {
+	server := NewServer(":8080", "1.0.0")
+	server.SetMaxConcurrentChecks(2)
+
+	var running, peak atomic.Int32
+	for i := 0; i < 8; i++ {
+		server.RegisterChecker(fmt.Sprintf("component_%d", i), func(ctx context.Context) ComponentHealth {
+			now := running.Add(1)
+			defer running.Add(-1)
+			for {
+				old := peak.Load()
+				if now <= old || peak.CompareAndSwap(old, now) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			return ComponentHealth{Status: StatusHealthy}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/ready", nil)
+	w := httptest.NewRecorder()
+
+	server.readinessHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("status code = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	var response ReadinessResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Components) != 8 {
+		t.Errorf("components count = %v, want 8", len(response.Components))
+	}
+	if got := peak.Load(); got != 2 {
+		t.Errorf("peak concurrent checks = %d, want 2", got)
+	}
+}
+
 func TestSimpleChecker(t *testing.T) {
 	// Test healthy checker
 	healthyChecker := SimpleChecker("test", func() error {
@@ -327,3 +621,64 @@ func TestConcurrentCheckerRegistration(t *testing.T) {
 		t.Errorf("checkers count = %v, want 10", len(server.checkers))
 	}
 }
+
+func TestServer_UnixSocket(t *testing.T) {
+	// Keep the path short - Unix socket paths are limited to ~108 bytes
+	dir, err := os.MkdirTemp("", "hs")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "health.sock")
+
+	server := NewServer("unix:"+socketPath, "1.0.0")
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	info, err := os.Stat(socketPath)
+	if err != nil {
+		t.Fatalf("socket file not created: %v", err)
+	}
+	if info.Mode().Perm() != unixSocketMode {
+		t.Errorf("socket permissions = %v, want %v", info.Mode().Perm(), os.FileMode(unixSocketMode))
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socketPath)
+			},
+		},
+		Timeout: 5 * time.Second,
+	}
+
+	resp, err := client.Get("http://unix/health")
+	if err != nil {
+		t.Fatalf("GET /health over unix socket error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status code = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	var response HealthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Status != StatusHealthy {
+		t.Errorf("status = %v, want %v", response.Status, StatusHealthy)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Stop(ctx); err != nil {
+		t.Errorf("Stop() error = %v", err)
+	}
+
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Error("Stop() should remove the socket file")
+	}
+}
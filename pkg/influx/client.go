@@ -2,23 +2,136 @@ package influx
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
 	"github.com/influxdata/influxdb-client-go/v2/api"
+	http2 "github.com/influxdata/influxdb-client-go/v2/api/http"
 	"github.com/influxdata/influxdb-client-go/v2/api/write"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	protocol "github.com/influxdata/line-protocol"
 	"github.com/sony/gobreaker"
 )
 
+const (
+	// defaultBackpressurePause is used when a 429 response carries no Retry-After header
+	defaultBackpressurePause = 5 * time.Second
+	// defaultBackpressureMaxWait is the longest pause honored inline before giving up
+	defaultBackpressureMaxWait = 30 * time.Second
+
+	// Connection pool defaults, matching the transport influxdb2 builds for itself
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 100
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
+// ErrBucketPermission is returned by EnsureBucket when the token may not look up or create buckets
+var ErrBucketPermission = errors.New("token lacks permission to manage buckets")
+
+// Errors returned by CheckOrgAndBucket, telling a misconfigured org or bucket apart
+// from an unreachable server
+var (
+	ErrOrgNotFound      = errors.New("organization not found")
+	ErrBucketNotFound   = errors.New("bucket not found")
+	ErrConnectionFailed = errors.New("connection to InfluxDB failed")
+	// ErrNotVisible means the lookup came back empty, as it does for a token that may
+	// write to the bucket but not list orgs or buckets, so existence is unconfirmed
+	ErrNotVisible = errors.New("organization or bucket not visible to the token")
+)
+
 // ErrorHandler is a callback function for handling write errors
 type ErrorHandler func(err error)
 
+// FailedBatchHandler is called with the points of a batch the async WriteAPI failed to
+// write, so the caller can keep them for a later write
+type FailedBatchHandler func(points []DataPoint, err error)
+
+// BackpressureError is returned when InfluxDB rejects a write with 429 Too Many Requests.
+// It indicates a transient overload rather than a hard failure.
+type BackpressureError struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+// Error implements the error interface
+func (e *BackpressureError) Error() string {
+	return fmt.Sprintf("InfluxDB backpressure (retry after %v): %v", e.RetryAfter, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *BackpressureError) Unwrap() error {
+	return e.Err
+}
+
+// IsBackpressure reports whether err was caused by InfluxDB backpressure
+func IsBackpressure(err error) bool {
+	var bpErr *BackpressureError
+	return errors.As(err, &bpErr)
+}
+
+// fieldTypeConflictRegex matches InfluxDB's rejection of a value whose type differs from
+// the one already stored for the field, e.g. `field type conflict: input field "demand"
+// on measurement "energy" is type integer, already exists as type float`
+var fieldTypeConflictRegex = regexp.MustCompile(`field type conflict: input field "([^"]+)" on measurement "([^"]+)" is type (\w+), already exists as type (\w+)`)
+
+// FieldTypeConflictError is returned when InfluxDB rejects a write because the field was
+// previously stored with a different type. Retrying or caching cannot fix it: the
+// conflicting data must be deleted or written to another bucket or measurement.
+type FieldTypeConflictError struct {
+	Measurement  string
+	Field        string
+	Type         string // Type of the rejected value
+	ExistingType string // Type already stored for the field
+	Err          error
+}
+
+// Error implements the error interface
+func (e *FieldTypeConflictError) Error() string {
+	return fmt.Sprintf("InfluxDB field type conflict: field %q on measurement %q is written as %s but stored as %s",
+		e.Field, e.Measurement, e.Type, e.ExistingType)
+}
+
+// Unwrap returns the underlying error
+func (e *FieldTypeConflictError) Unwrap() error {
+	return e.Err
+}
+
+// IsFieldTypeConflict reports whether err was caused by an InfluxDB field type conflict
+func IsFieldTypeConflict(err error) bool {
+	var conflictErr *FieldTypeConflictError
+	return errors.As(err, &conflictErr)
+}
+
+// fieldTypeConflict converts a write error reporting a field type conflict into a
+// *FieldTypeConflictError, returning other errors unchanged
+func fieldTypeConflict(err error) error {
+	if err == nil || IsFieldTypeConflict(err) {
+		return err
+	}
+	match := fieldTypeConflictRegex.FindStringSubmatch(err.Error())
+	if match == nil {
+		return err
+	}
+	return &FieldTypeConflictError{Field: match[1], Measurement: match[2], Type: match[3], ExistingType: match[4], Err: err}
+}
+
 // Client handles writing data to InfluxDB
 type Client struct {
 	client         influxdb2.Client
+	transport      *http.Transport // Set when Options customize the transport; nil uses influxdb2's own
 	writeAPI       api.WriteAPI
 	bucket         string
 	org            string
@@ -27,6 +140,28 @@ type Client struct {
 	stopChan       chan struct{}
 	circuitBreaker *gobreaker.CircuitBreaker
 	wg             sync.WaitGroup // Tracks the error monitoring goroutine
+
+	// Counters for data dropped by sanitization
+	droppedFields atomic.Int64
+	droppedPoints atomic.Int64
+
+	// Runtime settings and backpressure state - protected by mu
+	mu                  sync.Mutex
+	extraTags           map[string]string
+	exportFields        bool
+	idempotentWrites    bool
+	consumptionUnit     string  // Tags energy points when set
+	consumptionScale    float64 // Units per kWh; 0 writes kWh unscaled
+	pausedUntil         time.Time
+	backpressureEnabled bool
+	backpressureMaxWait time.Duration
+	asyncFailureHandler ErrorHandler
+	failedBatchHandler  FailedBatchHandler
+
+	// Last health check result, reused by CheckHealth for healthCacheTTL
+	healthCacheTTL  time.Duration
+	healthCheckedAt time.Time
+	healthErr       error
 }
 
 // DataPoint represents a single energy measurement
@@ -43,9 +178,77 @@ func NewClient(url, token, org, bucket, measurement string) (*Client, error) {
 	return NewClientWithErrorHandler(url, token, org, bucket, measurement, nil)
 }
 
+// Options holds optional transport settings for the InfluxDB client
+type Options struct {
+	// ProxyURL routes all requests through an HTTP(S) or SOCKS5 proxy when set
+	ProxyURL *url.URL
+	// TLSConfig overrides certificate verification, e.g. for a private CA or self-signed certificate
+	TLSConfig *tls.Config
+
+	// Connection pool tuning. Zero values keep the influxdb2 defaults of 100 idle
+	// connections (total and per host) kept for 90 seconds.
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	// KeepAlive is the TCP keep-alive probe period; zero uses the Go default and a
+	// negative value disables probes
+	KeepAlive time.Duration
+}
+
+// customTransport reports whether any option requires building our own transport
+func (o Options) customTransport() bool {
+	return o.ProxyURL != nil || o.TLSConfig != nil ||
+		o.MaxIdleConns != 0 || o.MaxIdleConnsPerHost != 0 || o.IdleConnTimeout != 0 || o.KeepAlive != 0
+}
+
+// newTransport builds an HTTP transport with the same defaults as the influxdb2
+// client, overridden by any options set
+func newTransport(opts Options) *http.Transport {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: opts.KeepAlive,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		TLSClientConfig:     opts.TLSConfig,
+		MaxIdleConns:        defaultMaxIdleConns,
+		MaxIdleConnsPerHost: defaultMaxIdleConnsPerHost,
+		IdleConnTimeout:     defaultIdleConnTimeout,
+	}
+	if opts.ProxyURL != nil {
+		transport.Proxy = http.ProxyURL(opts.ProxyURL)
+	}
+	if opts.MaxIdleConns > 0 {
+		transport.MaxIdleConns = opts.MaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	return transport
+}
+
 // NewClientWithErrorHandler creates a new InfluxDB client with a custom error handler
 func NewClientWithErrorHandler(url, token, org, bucket, measurement string, errorHandler ErrorHandler) (*Client, error) {
-	client := influxdb2.NewClient(url, token)
+	return NewClientWithOptions(url, token, org, bucket, measurement, errorHandler, Options{})
+}
+
+// NewClientWithOptions creates a new InfluxDB client with a custom error handler and transport options
+func NewClientWithOptions(url, token, org, bucket, measurement string, errorHandler ErrorHandler, opts Options) (*Client, error) {
+	clientOptions := influxdb2.DefaultOptions()
+	var transport *http.Transport
+	if opts.customTransport() {
+		transport = newTransport(opts)
+		clientOptions.SetHTTPClient(&http.Client{
+			Timeout:   time.Duration(clientOptions.HTTPRequestTimeout()) * time.Second,
+			Transport: transport,
+		})
+	}
+
+	client := influxdb2.NewClientWithOptions(url, token, clientOptions)
 
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -79,10 +282,16 @@ func NewClientWithErrorHandler(url, token, org, bucket, measurement string, erro
 			failureRatio := float64(counts.TotalFailures) / float64(counts.Requests)
 			return counts.Requests >= 3 && failureRatio >= 0.6
 		},
+		// Backpressure is transient and a field type conflict is a schema problem; neither
+		// means InfluxDB is unavailable, so they should not trip the breaker
+		IsSuccessful: func(err error) bool {
+			return err == nil || IsBackpressure(err) || IsFieldTypeConflict(err)
+		},
 	}
 
 	c := &Client{
 		client:         client,
+		transport:      transport,
 		writeAPI:       writeAPI,
 		bucket:         bucket,
 		org:            org,
@@ -90,8 +299,13 @@ func NewClientWithErrorHandler(url, token, org, bucket, measurement string, erro
 		errorHandler:   errorHandler,
 		stopChan:       make(chan struct{}),
 		circuitBreaker: gobreaker.NewCircuitBreaker(cbSettings),
+
+		backpressureEnabled: true,
+		backpressureMaxWait: defaultBackpressureMaxWait,
 	}
 
+	writeAPI.SetWriteFailedCallback(c.handleFailedBatch)
+
 	// Start error monitoring goroutine
 	c.wg.Add(1)
 	go c.monitorErrors()
@@ -119,40 +333,264 @@ func (c *Client) monitorErrors() {
 			if !ok {
 				return
 			}
-			if err != nil && c.errorHandler != nil {
-				// Wrap error handler in panic recovery
-				func() {
-					defer func() {
-						if r := recover(); r != nil {
-							log.Printf("Error handler panicked: %v", r)
-						}
-					}()
-					c.errorHandler(err)
-				}()
+			if err == nil {
+				continue
 			}
+			// The WriteAPI only reports errors once its own retries are exhausted,
+			// so each one means a batch was dropped
+			err = fieldTypeConflict(err)
+			c.callHandler(c.errorHandler, err)
+			c.callHandler(c.getAsyncFailureHandler(), err)
 		case <-c.stopChan:
 			return
 		}
 	}
 }
 
+// callHandler invokes an error handler with panic recovery
+func (c *Client) callHandler(handler ErrorHandler, err error) {
+	if handler == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Error handler panicked: %v", r)
+		}
+	}()
+	handler(err)
+}
+
+// SetAsyncFailureHandler registers a callback invoked whenever the async WriteAPI
+// gives up on a batch, so the caller can stop trusting the connection and fall back
+// to caching as it does for failed blocking writes
+func (c *Client) SetAsyncFailureHandler(handler ErrorHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.asyncFailureHandler = handler
+}
+
+func (c *Client) getAsyncFailureHandler() ErrorHandler {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.asyncFailureHandler
+}
+
+// SetFailedBatchHandler registers a callback given the points of each batch the async
+// WriteAPI fails to write. The WriteAPI then leaves the batch to the caller rather than
+// retrying it, so the points are not written twice.
+func (c *Client) SetFailedBatchHandler(handler FailedBatchHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failedBatchHandler = handler
+}
+
+// handleFailedBatch is the WriteAPI's failed write callback. It hands the batch's points
+// to the failed batch handler, or keeps the WriteAPI retrying when there is none.
+func (c *Client) handleFailedBatch(batch string, writeErr http2.Error, _ uint) bool {
+	c.mu.Lock()
+	handler := c.failedBatchHandler
+	c.mu.Unlock()
+	if handler == nil {
+		return true
+	}
+
+	points, err := c.parseBatch(batch)
+	if err != nil {
+		log.Printf("Failed to parse dropped InfluxDB batch: %v", err)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Failed batch handler panicked: %v", r)
+		}
+	}()
+	handler(points, &writeErr)
+	return false
+}
+
+// parseBatch converts a line protocol batch written by this client back to data points,
+// undoing the consumption unit scaling. Points of other measurements are skipped.
+func (c *Client) parseBatch(batch string) ([]DataPoint, error) {
+	metrics, err := protocol.NewParser(protocol.NewMetricHandler()).Parse([]byte(batch))
+	if err != nil {
+		return nil, err
+	}
+
+	_, scale := c.energyUnit()
+	points := make([]DataPoint, 0, len(metrics))
+	for _, metric := range metrics {
+		if metric.Name() != c.measurement {
+			continue
+		}
+		dp := DataPoint{Timestamp: metric.Time()}
+		for _, field := range metric.FieldList() {
+			value, ok := field.Value.(float64)
+			if !ok {
+				continue
+			}
+			switch field.Key {
+			case "consumption_delta":
+				dp.ConsumptionDelta = value / scale
+			case "demand":
+				dp.Demand = value
+			case "cost_delta":
+				dp.CostDelta = value
+			case "consumption":
+				dp.Consumption = value / scale
+			}
+		}
+		points = append(points, dp)
+	}
+	return points, nil
+}
+
+// SetExtraTags sets additional tags attached to every point written, such as
+// meter identifiers. Empty values are ignored.
+func (c *Client) SetExtraTags(tags map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.extraTags = make(map[string]string, len(tags))
+	for key, value := range tags {
+		if value != "" {
+			c.extraTags[key] = value
+		}
+	}
+}
+
+// SetExportFields enables writing import_kwh and export_kwh alongside consumption_delta.
+// The Home Mini reports net flow, so a negative consumption delta is energy exported
+// to the grid (e.g. from solar) and a positive one is energy imported.
+func (c *Client) SetExportFields(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.exportFields = enabled
+}
+
+// SetIdempotentWrites makes rewriting a reading produce byte-identical line protocol.
+// InfluxDB stores one value per measurement, tag set, field key and timestamp, so a
+// repeated write overwrites rather than duplicates only if all four match exactly;
+// timestamps are therefore truncated to whole seconds in UTC so re-synced cached points
+// and re-polled overlapping windows land on the same series and time.
+func (c *Client) SetIdempotentWrites(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.idempotentWrites = enabled
+}
+
+// SetConsumptionUnit writes consumption and consumption_delta in unit instead of kWh,
+// multiplying them by scale (units per kWh), and tags energy points with unit so the
+// series can't be mistaken for kWh. An empty unit writes kWh untagged, as before.
+// import_kwh and export_kwh stay in kWh, as their names say.
+func (c *Client) SetConsumptionUnit(unit string, scale float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.consumptionUnit = unit
+	c.consumptionScale = scale
+}
+
+// energyUnit returns the unit tag and scale applied to consumption fields
+func (c *Client) energyUnit() (string, float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.consumptionUnit == "" || c.consumptionScale == 0 {
+		return "", 1
+	}
+	return c.consumptionUnit, c.consumptionScale
+}
+
+// energyTags returns the tags for points carrying consumption fields
+func (c *Client) energyTags(unit string) map[string]string {
+	tags := c.tags()
+	if unit != "" {
+		tags["unit"] = unit
+	}
+	return tags
+}
+
+// pointTime returns the timestamp written for t
+func (c *Client) pointTime(t time.Time) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.idempotentWrites {
+		return t.Truncate(time.Second).UTC()
+	}
+	return t
+}
+
+// newPoint builds an InfluxDB point for a data point. NaN and Inf fields are dropped
+// because InfluxDB rejects them; nil is returned if no valid fields remain.
+func (c *Client) newPoint(dp DataPoint) *write.Point {
+	unit, scale := c.energyUnit()
+	tags := c.energyTags(unit)
+
+	c.mu.Lock()
+	exportFields := c.exportFields
+	c.mu.Unlock()
+
+	values := map[string]float64{
+		"consumption_delta": dp.ConsumptionDelta * scale,
+		"demand":            dp.Demand,
+		"cost_delta":        dp.CostDelta,
+		"consumption":       dp.Consumption * scale,
+	}
+	if exportFields {
+		values["import_kwh"] = math.Max(dp.ConsumptionDelta, 0)
+		values["export_kwh"] = math.Max(-dp.ConsumptionDelta, 0)
+	}
+
+	fields := c.sanitizeFields(dp.Timestamp, values)
+	if len(fields) == 0 {
+		log.Printf("Dropping InfluxDB point at %s: no valid fields", dp.Timestamp.Format(time.RFC3339))
+		c.droppedPoints.Add(1)
+		return nil
+	}
+
+	return write.NewPoint(c.measurement, tags, fields, c.pointTime(dp.Timestamp))
+}
+
+// tags returns the tags attached to every point written
+func (c *Client) tags() map[string]string {
+	tags := map[string]string{
+		"source": "octopus_home_mini",
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, value := range c.extraTags {
+		tags[key] = value
+	}
+	return tags
+}
+
+// sanitizeFields removes NaN and Inf values, logging and counting each dropped field
+func (c *Client) sanitizeFields(timestamp time.Time, values map[string]float64) map[string]interface{} {
+	fields := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			log.Printf("Dropping invalid InfluxDB field %q (%v) at %s", name, value, timestamp.Format(time.RFC3339))
+			c.droppedFields.Add(1)
+			continue
+		}
+		fields[name] = value
+	}
+	return fields
+}
+
+// DroppedFieldCount returns the number of NaN/Inf fields dropped before writing
+func (c *Client) DroppedFieldCount() int64 {
+	return c.droppedFields.Load()
+}
+
+// DroppedPointCount returns the number of points dropped because no valid fields remained
+func (c *Client) DroppedPointCount() int64 {
+	return c.droppedPoints.Load()
+}
+
 // WriteDataPoint writes a single data point to InfluxDB
 func (c *Client) WriteDataPoint(dp DataPoint) error {
-	p := influxdb2.NewPoint(
-		c.measurement,
-		map[string]string{
-			"source": "octopus_home_mini",
-		},
-		map[string]interface{}{
-			"consumption_delta": dp.ConsumptionDelta,
-			"demand":            dp.Demand,
-			"cost_delta":        dp.CostDelta,
-			"consumption":       dp.Consumption,
-		},
-		dp.Timestamp,
-	)
-
-	c.writeAPI.WritePoint(p)
+	if p := c.newPoint(dp); p != nil {
+		c.writeAPI.WritePoint(p)
+	}
 	return nil
 }
 
@@ -166,6 +604,35 @@ func (c *Client) WriteDataPoints(dataPoints []DataPoint) error {
 	return nil
 }
 
+// WriteDataPointsBlocking writes all data points to InfluxDB in a single synchronous request
+// through the circuit breaker. Unlike WriteDataPoints it reports delivery failures: the
+// returned error joins the write error with one error per point dropped because none of
+// its fields were valid. Valid points are written even when others are dropped.
+func (c *Client) WriteDataPointsBlocking(ctx context.Context, dataPoints []DataPoint) error {
+	var errs []error
+	points := make([]*write.Point, 0, len(dataPoints))
+	for i, dp := range dataPoints {
+		p := c.newPoint(dp)
+		if p == nil {
+			errs = append(errs, fmt.Errorf("point %d at %s has no valid fields", i, dp.Timestamp.Format(time.RFC3339)))
+			continue
+		}
+		points = append(points, p)
+	}
+
+	if len(points) > 0 {
+		_, err := c.circuitBreaker.Execute(func() (interface{}, error) {
+			writeAPIBlocking := c.client.WriteAPIBlocking(c.org, c.bucket)
+			return nil, c.classifyWriteError(writeAPIBlocking.WritePoint(ctx, points...))
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to write %d points: %w", len(points), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 // Flush ensures all pending writes are sent to InfluxDB
 func (c *Client) Flush() {
 	c.writeAPI.Flush()
@@ -176,8 +643,52 @@ func (c *Client) GetErrors() <-chan error {
 	return c.writeAPI.Errors()
 }
 
-// CheckConnection tests if the connection to InfluxDB is healthy
+// CheckConnection tests if the connection to InfluxDB is healthy. It always pings the
+// server and refreshes the result reused by CheckHealth.
 func (c *Client) CheckConnection(ctx context.Context) error {
+	err := c.ping(ctx)
+
+	// A check abandoned by its caller says nothing about InfluxDB, so it is not reused
+	if ctx.Err() == nil {
+		c.mu.Lock()
+		c.healthCheckedAt = time.Now()
+		c.healthErr = err
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// CheckHealth is like CheckConnection but reuses a result from within the health cache
+// TTL, so the readiness probe and the monitor do not ping InfluxDB back to back
+func (c *Client) CheckHealth(ctx context.Context) error {
+	c.mu.Lock()
+	if c.healthCacheTTL > 0 && !c.healthCheckedAt.IsZero() && time.Since(c.healthCheckedAt) < c.healthCacheTTL {
+		err := c.healthErr
+		c.mu.Unlock()
+		return err
+	}
+	c.mu.Unlock()
+
+	return c.CheckConnection(ctx)
+}
+
+// SetHealthCacheTTL sets how long CheckHealth reuses the last result; zero disables reuse
+func (c *Client) SetHealthCacheTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.healthCacheTTL = ttl
+}
+
+// ExpireHealthCache forces the next CheckHealth to ping, e.g. after a write failed
+// while the cached result still reported InfluxDB healthy
+func (c *Client) ExpireHealthCache() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.healthCheckedAt = time.Time{}
+}
+
+// ping queries the InfluxDB health endpoint
+func (c *Client) ping(ctx context.Context) error {
 	health, err := c.client.Health(ctx)
 	if err != nil {
 		return fmt.Errorf("connection check failed: %w", err)
@@ -190,6 +701,161 @@ func (c *Client) CheckConnection(ctx context.Context) error {
 	return nil
 }
 
+// CountPoints returns the number of distinct timestamps stored for the measurement
+// between start and end inclusive. It is used to verify that writes landed.
+func (c *Client) CountPoints(ctx context.Context, start, end time.Time) (int64, error) {
+	query := fmt.Sprintf(`from(bucket: %q)
+  |> range(start: %s, stop: %s)
+  |> filter(fn: (r) => r._measurement == %q)
+  |> group()
+  |> distinct(column: "_time")
+  |> count()`,
+		c.bucket,
+		start.UTC().Format(time.RFC3339Nano),
+		end.Add(time.Nanosecond).UTC().Format(time.RFC3339Nano), // stop is exclusive
+		c.measurement)
+
+	result, err := c.client.QueryAPI(c.org).Query(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query point count: %w", err)
+	}
+	defer result.Close()
+
+	var count int64
+	for result.Next() {
+		if n, ok := result.Record().Value().(int64); ok {
+			count += n
+		}
+	}
+	if err := result.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read point count: %w", err)
+	}
+
+	return count, nil
+}
+
+// PointTimes returns the distinct timestamps stored for the measurement between start
+// and end inclusive, oldest first. It is used to find gaps after an outage.
+func (c *Client) PointTimes(ctx context.Context, start, end time.Time) ([]time.Time, error) {
+	query := fmt.Sprintf(`from(bucket: %q)
+  |> range(start: %s, stop: %s)
+  |> filter(fn: (r) => r._measurement == %q)
+  |> keep(columns: ["_time"])
+  |> group()
+  |> distinct(column: "_time")`,
+		c.bucket,
+		start.UTC().Format(time.RFC3339Nano),
+		end.Add(time.Nanosecond).UTC().Format(time.RFC3339Nano), // stop is exclusive
+		c.measurement)
+
+	result, err := c.client.QueryAPI(c.org).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query point times: %w", err)
+	}
+	defer result.Close()
+
+	var times []time.Time
+	for result.Next() {
+		if t, ok := result.Record().Value().(time.Time); ok {
+			times = append(times, t)
+		}
+	}
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read point times: %w", err)
+	}
+
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times, nil
+}
+
+// EnsureBucket creates the configured bucket if it does not exist yet, keeping data for
+// retention (0 keeps data forever). It reports whether the bucket was created. Creating a
+// bucket needs a token with org-admin rights; permission failures wrap ErrBucketPermission.
+func (c *Client) EnsureBucket(ctx context.Context, retention time.Duration) (bool, error) {
+	existing, err := c.client.APIClient().GetBuckets(ctx, &domain.GetBucketsParams{Name: &c.bucket, Org: &c.org})
+	if err != nil {
+		return false, bucketError("failed to look up bucket", err)
+	}
+	if existing.Buckets != nil && len(*existing.Buckets) > 0 {
+		return false, nil
+	}
+
+	org, err := c.client.OrganizationsAPI().FindOrganizationByName(ctx, c.org)
+	if err != nil {
+		return false, bucketError("failed to look up organization", err)
+	}
+
+	expire := domain.RetentionRuleTypeExpire
+	rule := domain.RetentionRule{EverySeconds: int64(retention / time.Second), Type: &expire}
+	if _, err := c.client.BucketsAPI().CreateBucketWithName(ctx, org, c.bucket, rule); err != nil {
+		return false, bucketError("failed to create bucket", err)
+	}
+
+	return true, nil
+}
+
+// CheckOrgAndBucket confirms the configured organization and bucket exist, returning an
+// error wrapping ErrOrgNotFound, ErrBucketNotFound or ErrConnectionFailed so a startup
+// failure says which is wrong. Only a 404 or a lookup returning a different name counts
+// as not found: an empty result is what a write-only token sees, so it returns
+// ErrNotVisible, and tokens refused outright get ErrBucketPermission.
+func (c *Client) CheckOrgAndBucket(ctx context.Context) error {
+	orgs, err := c.client.APIClient().GetOrgs(ctx, &domain.GetOrgsParams{Org: &c.org})
+	if err != nil {
+		return lookupError("failed to look up organization "+c.org, ErrOrgNotFound, err)
+	}
+
+	params := &domain.GetBucketsParams{Name: &c.bucket}
+	if orgs.Orgs == nil || len(*orgs.Orgs) == 0 {
+		// Without the org's ID the bucket can still be looked up by org name
+		params.Org = &c.org
+	} else {
+		org := (*orgs.Orgs)[0]
+		if org.Name != c.org {
+			return fmt.Errorf("organization %s: lookup returned %s: %w", c.org, org.Name, ErrOrgNotFound)
+		}
+		params.OrgID = org.Id
+	}
+
+	buckets, err := c.client.APIClient().GetBuckets(ctx, params)
+	if err != nil {
+		return lookupError("failed to look up bucket "+c.bucket, ErrBucketNotFound, err)
+	}
+	if buckets.Buckets == nil || len(*buckets.Buckets) == 0 {
+		return fmt.Errorf("bucket %s in organization %s: %w", c.bucket, c.org, ErrNotVisible)
+	}
+	if bucket := (*buckets.Buckets)[0]; bucket.Name != c.bucket {
+		return fmt.Errorf("bucket %s in organization %s: lookup returned %s: %w", c.bucket, c.org, bucket.Name, ErrBucketNotFound)
+	}
+
+	return nil
+}
+
+// lookupError classifies a failed org or bucket lookup: transport failures wrap
+// ErrConnectionFailed and "not found" responses wrap notFound
+func lookupError(action string, notFound, err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w: %v", action, ErrConnectionFailed, err)
+	}
+	if strings.Contains(strings.ToLower(err.Error()), "not found") {
+		return fmt.Errorf("%s: %w: %v", action, notFound, err)
+	}
+	return bucketError(action, err)
+}
+
+// bucketError wraps a buckets API failure, marking authorization failures with ErrBucketPermission.
+// The API reports these as "unauthorized"/"forbidden" error codes or bare 401/403 statuses.
+func bucketError(action string, err error) error {
+	msg := strings.ToLower(err.Error())
+	for _, marker := range []string{"unauthorized", "forbidden", "401", "403"} {
+		if strings.HasPrefix(msg, marker) {
+			return fmt.Errorf("%s: %w: %v", action, ErrBucketPermission, err)
+		}
+	}
+	return fmt.Errorf("%s: %w", action, err)
+}
+
 // Close closes the InfluxDB client
 func (c *Client) Close() {
 	// Signal error monitoring goroutine to stop
@@ -203,27 +869,117 @@ func (c *Client) Close() {
 
 	// Close the client connection
 	c.client.Close()
+
+	// influxdb2 only closes idle connections of a transport it created itself
+	if c.transport != nil {
+		c.transport.CloseIdleConnections()
+	}
 }
 
-// WritePointDirectly writes a point directly (synchronous, returns error immediately) with circuit breaker
+// SetBackpressure configures how 429 responses are handled. When enabled, writes
+// pause for the Retry-After duration (up to maxWait) and are then retried.
+func (c *Client) SetBackpressure(enabled bool, maxWait time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.backpressureEnabled = enabled
+	if maxWait > 0 {
+		c.backpressureMaxWait = maxWait
+	}
+}
+
+// WritePointDirectly writes a point directly (synchronous, returns error immediately) with circuit breaker.
+// If InfluxDB signals backpressure, the write is paused and retried while the pause fits within the
+// configured maximum wait; otherwise a *BackpressureError is returned.
 func (c *Client) WritePointDirectly(ctx context.Context, dp DataPoint) error {
+	for {
+		if err := c.waitForBackpressure(ctx); err != nil {
+			return err
+		}
+
+		err := c.writePoint(ctx, dp)
+		if !IsBackpressure(err) {
+			return err
+		}
+
+		c.mu.Lock()
+		enabled := c.backpressureEnabled
+		c.mu.Unlock()
+		if !enabled {
+			return err
+		}
+	}
+}
+
+// writePoint performs a single blocking write through the circuit breaker
+func (c *Client) writePoint(ctx context.Context, dp DataPoint) error {
 	_, err := c.circuitBreaker.Execute(func() (interface{}, error) {
-		p := write.NewPoint(
-			c.measurement,
-			map[string]string{
-				"source": "octopus_home_mini",
-			},
-			map[string]interface{}{
-				"consumption_delta": dp.ConsumptionDelta,
-				"demand":            dp.Demand,
-				"cost_delta":        dp.CostDelta,
-				"consumption":       dp.Consumption,
-			},
-			dp.Timestamp,
-		)
+		p := c.newPoint(dp)
+		if p == nil {
+			return nil, nil
+		}
 
 		writeAPIBlocking := c.client.WriteAPIBlocking(c.org, c.bucket)
-		return nil, writeAPIBlocking.WritePoint(ctx, p)
+		return nil, c.classifyWriteError(writeAPIBlocking.WritePoint(ctx, p))
 	})
 	return err
 }
+
+// classifyWriteError converts 429 responses into a *BackpressureError and records the
+// pause, and field type conflicts into a *FieldTypeConflictError
+func (c *Client) classifyWriteError(err error) error {
+	if err := fieldTypeConflict(err); IsFieldTypeConflict(err) {
+		return err
+	}
+
+	var httpErr *http2.Error
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusTooManyRequests {
+		return err
+	}
+
+	retryAfter := time.Duration(httpErr.RetryAfter) * time.Second
+	if retryAfter <= 0 {
+		retryAfter = defaultBackpressurePause
+	}
+
+	c.mu.Lock()
+	c.pausedUntil = time.Now().Add(retryAfter)
+	c.mu.Unlock()
+
+	return &BackpressureError{RetryAfter: retryAfter, Err: err}
+}
+
+// waitForBackpressure blocks until any active backpressure pause has elapsed.
+// It returns a *BackpressureError without waiting if the pause exceeds the maximum
+// wait or the context deadline.
+func (c *Client) waitForBackpressure(ctx context.Context) error {
+	c.mu.Lock()
+	remaining := time.Until(c.pausedUntil)
+	maxWait := c.backpressureMaxWait
+	enabled := c.backpressureEnabled
+	c.mu.Unlock()
+
+	if !enabled || remaining <= 0 {
+		return nil
+	}
+
+	pauseErr := &BackpressureError{
+		RetryAfter: remaining,
+		Err:        fmt.Errorf("writes paused by InfluxDB backpressure"),
+	}
+	if remaining > maxWait {
+		return pauseErr
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < remaining {
+		return pauseErr
+	}
+
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -2,10 +2,23 @@ package influx
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/influxdata/influxdb-client-go/v2/api"
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
 )
 
 func TestDataPoint_Structure(t *testing.T) {
@@ -596,3 +609,1048 @@ func TestDataPoint_TimeZones(t *testing.T) {
 		})
 	}
 }
+
+// newBackpressureServer returns a mock InfluxDB that rejects the first write with
+// 429 and the given Retry-After header, then accepts subsequent writes
+func newBackpressureServer(t *testing.T, retryAfter string) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var writes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"name":"influxdb","status":"pass"}`))
+		case "/api/v2/write":
+			if atomic.AddInt32(&writes, 1) == 1 {
+				w.Header().Set("Retry-After", retryAfter)
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &writes
+}
+
+func TestClient_WritePointDirectly_Backpressure(t *testing.T) {
+	server, writes := newBackpressureServer(t, "1")
+
+	client, err := NewClient(server.URL, "test_token", "test_org", "test_bucket", "energy")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err = client.WritePointDirectly(ctx, DataPoint{Timestamp: time.Now(), Consumption: 1.0})
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("WritePointDirectly() error = %v, want nil after backpressure pause", err)
+	}
+	if elapsed < 900*time.Millisecond {
+		t.Errorf("WritePointDirectly() returned after %v, want pause of at least 1s", elapsed)
+	}
+	if got := atomic.LoadInt32(writes); got != 2 {
+		t.Errorf("write attempts = %d, want 2", got)
+	}
+}
+
+func TestClient_WritePointDirectly_BackpressureExceedsMaxWait(t *testing.T) {
+	server, writes := newBackpressureServer(t, "60")
+
+	client, err := NewClient(server.URL, "test_token", "test_org", "test_bucket", "energy")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+	client.SetBackpressure(true, 5*time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = client.WritePointDirectly(ctx, DataPoint{Timestamp: time.Now(), Consumption: 1.0})
+	if !IsBackpressure(err) {
+		t.Fatalf("WritePointDirectly() error = %v, want backpressure error", err)
+	}
+
+	var bpErr *BackpressureError
+	if errors.As(err, &bpErr) && bpErr.RetryAfter < 50*time.Second {
+		t.Errorf("RetryAfter = %v, want about 60s", bpErr.RetryAfter)
+	}
+
+	// Subsequent writes should not hit the server while paused
+	if err := client.WritePointDirectly(ctx, DataPoint{Timestamp: time.Now()}); !IsBackpressure(err) {
+		t.Errorf("WritePointDirectly() while paused error = %v, want backpressure error", err)
+	}
+	if got := atomic.LoadInt32(writes); got != 1 {
+		t.Errorf("write attempts = %d, want 1", got)
+	}
+}
+
+func TestIsBackpressure(t *testing.T) {
+	if IsBackpressure(errors.New("connection refused")) {
+		t.Error("IsBackpressure() = true for plain error, want false")
+	}
+	if IsBackpressure(nil) {
+		t.Error("IsBackpressure() = true for nil, want false")
+	}
+	wrapped := fmt.Errorf("write failed: %w", &BackpressureError{RetryAfter: time.Second, Err: errors.New("429")})
+	if !IsBackpressure(wrapped) {
+		t.Error("IsBackpressure() = false for wrapped backpressure error, want true")
+	}
+}
+
+func TestClient_WritePointDirectly_FieldTypeConflict(t *testing.T) {
+	var writes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"influxdb","status":"pass"}`))
+		case "/api/v2/write":
+			writes.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"code":"unprocessable entity","message":"failure writing points to database: partial write: field type conflict: input field \"demand\" on measurement \"energy\" is type integer, already exists as type float dropped=1"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "test_token", "test_org", "test_bucket", "energy")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	// Repeated conflicts keep their detail rather than tripping the circuit breaker
+	for i := 0; i < 5; i++ {
+		err := client.WritePointDirectly(context.Background(), DataPoint{Timestamp: time.Now(), Demand: 450})
+
+		var conflictErr *FieldTypeConflictError
+		if !errors.As(err, &conflictErr) {
+			t.Fatalf("write %d error = %v, want *FieldTypeConflictError", i+1, err)
+		}
+		want := FieldTypeConflictError{Measurement: "energy", Field: "demand", Type: "integer", ExistingType: "float"}
+		conflictErr.Err = nil
+		if *conflictErr != want {
+			t.Errorf("write %d conflict = %+v, want %+v", i+1, *conflictErr, want)
+		}
+	}
+	if got := writes.Load(); got != 5 {
+		t.Errorf("write attempts = %d, want 5", got)
+	}
+
+	if IsFieldTypeConflict(errors.New("partial write: points beyond retention policy dropped=1")) {
+		t.Error("IsFieldTypeConflict() = true for another partial write, want false")
+	}
+}
+
+func TestClient_SetExtraTags(t *testing.T) {
+	client := &Client{measurement: "energy"}
+	client.SetExtraTags(map[string]string{
+		"mpan":         "1012345678901",
+		"meter_serial": "",
+	})
+
+	p := client.newPoint(DataPoint{Timestamp: time.Now(), Consumption: 1.0})
+
+	tags := make(map[string]string)
+	for _, tag := range p.TagList() {
+		tags[tag.Key] = tag.Value
+	}
+
+	if tags["source"] != "octopus_home_mini" {
+		t.Errorf("source tag = %q, want octopus_home_mini", tags["source"])
+	}
+	if tags["mpan"] != "1012345678901" {
+		t.Errorf("mpan tag = %q, want 1012345678901", tags["mpan"])
+	}
+	if _, ok := tags["meter_serial"]; ok {
+		t.Error("empty meter_serial tag should be omitted")
+	}
+}
+
+func TestClient_NewPoint_DropsInvalidFields(t *testing.T) {
+	client := &Client{measurement: "energy"}
+
+	p := client.newPoint(DataPoint{
+		Timestamp:        time.Now(),
+		ConsumptionDelta: 0.5,
+		Demand:           math.NaN(),
+		CostDelta:        math.Inf(1),
+		Consumption:      10.5,
+	})
+	if p == nil {
+		t.Fatal("newPoint() returned nil, want point with remaining fields")
+	}
+
+	fields := make(map[string]interface{})
+	for _, field := range p.FieldList() {
+		fields[field.Key] = field.Value
+	}
+
+	if _, ok := fields["demand"]; ok {
+		t.Error("NaN demand field should be dropped")
+	}
+	if _, ok := fields["cost_delta"]; ok {
+		t.Error("Inf cost_delta field should be dropped")
+	}
+	if fields["consumption_delta"] != 0.5 {
+		t.Errorf("consumption_delta = %v, want 0.5", fields["consumption_delta"])
+	}
+	if fields["consumption"] != 10.5 {
+		t.Errorf("consumption = %v, want 10.5", fields["consumption"])
+	}
+	if got := client.DroppedFieldCount(); got != 2 {
+		t.Errorf("DroppedFieldCount() = %d, want 2", got)
+	}
+}
+
+func TestClient_NewPoint_AllFieldsInvalid(t *testing.T) {
+	client := &Client{measurement: "energy"}
+
+	p := client.newPoint(DataPoint{
+		Timestamp:        time.Now(),
+		ConsumptionDelta: math.NaN(),
+		Demand:           math.NaN(),
+		CostDelta:        math.Inf(-1),
+		Consumption:      math.NaN(),
+	})
+	if p != nil {
+		t.Error("newPoint() should return nil when no valid fields remain")
+	}
+	if got := client.DroppedPointCount(); got != 1 {
+		t.Errorf("DroppedPointCount() = %d, want 1", got)
+	}
+}
+
+func TestClient_NewPoint_ExportFields(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    bool
+		delta      float64
+		wantImport interface{}
+		wantExport interface{}
+	}{
+		{name: "disabled", enabled: false, delta: -0.4, wantImport: nil, wantExport: nil},
+		{name: "importing", enabled: true, delta: 0.3, wantImport: 0.3, wantExport: 0.0},
+		{name: "exporting", enabled: true, delta: -0.4, wantImport: 0.0, wantExport: 0.4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{measurement: "energy"}
+			client.SetExportFields(tt.enabled)
+
+			p := client.newPoint(DataPoint{Timestamp: time.Now(), ConsumptionDelta: tt.delta, Consumption: 10.5})
+
+			fields := make(map[string]interface{})
+			for _, field := range p.FieldList() {
+				fields[field.Key] = field.Value
+			}
+			if fields["import_kwh"] != tt.wantImport {
+				t.Errorf("import_kwh = %v, want %v", fields["import_kwh"], tt.wantImport)
+			}
+			if fields["export_kwh"] != tt.wantExport {
+				t.Errorf("export_kwh = %v, want %v", fields["export_kwh"], tt.wantExport)
+			}
+			if fields["consumption_delta"] != tt.delta {
+				t.Errorf("consumption_delta = %v, want %v", fields["consumption_delta"], tt.delta)
+			}
+		})
+	}
+}
+
+func TestClient_NewPoint_ConsumptionUnit(t *testing.T) {
+	client := &Client{measurement: "energy"}
+	dp := DataPoint{Timestamp: time.Now(), ConsumptionDelta: 0.25, Demand: 450, CostDelta: 0.01, Consumption: 1234.5}
+
+	// kWh by default, without a unit tag
+	p := client.newPoint(dp)
+	fields := make(map[string]interface{})
+	for _, field := range p.FieldList() {
+		fields[field.Key] = field.Value
+	}
+	if fields["consumption_delta"] != 0.25 || fields["consumption"] != 1234.5 {
+		t.Errorf("default fields = %v, want kWh values", fields)
+	}
+	for _, tag := range p.TagList() {
+		if tag.Key == "unit" {
+			t.Errorf("default point has unit tag %q, want none", tag.Value)
+		}
+	}
+
+	client.SetConsumptionUnit("Wh", 1000)
+	p = client.newPoint(dp)
+	fields = make(map[string]interface{})
+	for _, field := range p.FieldList() {
+		fields[field.Key] = field.Value
+	}
+	want := map[string]float64{"consumption_delta": 250, "consumption": 1234500, "demand": 450, "cost_delta": 0.01}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("Wh field %s = %v, want %v", key, fields[key], value)
+		}
+	}
+	tags := make(map[string]string)
+	for _, tag := range p.TagList() {
+		tags[tag.Key] = tag.Value
+	}
+	if tags["unit"] != "Wh" {
+		t.Errorf("unit tag = %q, want Wh", tags["unit"])
+	}
+	if tags["source"] != "octopus_home_mini" {
+		t.Errorf("source tag = %q, want octopus_home_mini", tags["source"])
+	}
+}
+
+func TestClient_IdempotentWrites(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"influxdb","status":"pass"}`))
+		case "/api/v2/write":
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			bodies = append(bodies, string(body))
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "token", "org", "bucket", "energy")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+	client.SetExtraTags(map[string]string{"mpan": "1234567890123", "meter_serial": "21L1234567"})
+	client.SetIdempotentWrites(true)
+
+	// The re-synced copy carries the same reading in a different zone with sub-second noise
+	readAt := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	points := []DataPoint{
+		{Timestamp: readAt, ConsumptionDelta: 0.5, Demand: 1.2, CostDelta: 0.15, Consumption: 10.5},
+		{Timestamp: readAt.Add(250 * time.Millisecond).In(time.FixedZone("BST", 3600)), ConsumptionDelta: 0.5, Demand: 1.2, CostDelta: 0.15, Consumption: 10.5},
+	}
+	for _, dp := range points {
+		if err := client.WritePointDirectly(context.Background(), dp); err != nil {
+			t.Fatalf("WritePointDirectly() error = %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 2 {
+		t.Fatalf("write requests = %d, want 2", len(bodies))
+	}
+	if bodies[0] != bodies[1] {
+		t.Errorf("line protocol differs between writes:\n%s\n%s", bodies[0], bodies[1])
+	}
+	want := "energy,meter_serial=21L1234567,mpan=1234567890123,source=octopus_home_mini "
+	if !strings.HasPrefix(bodies[0], want) {
+		t.Errorf("line protocol = %q, want prefix %q", bodies[0], want)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(bodies[0]), fmt.Sprintf(" %d", readAt.UnixNano())) {
+		t.Errorf("line protocol = %q, want timestamp %d", bodies[0], readAt.UnixNano())
+	}
+}
+
+func TestClient_CheckHealth_CachesResult(t *testing.T) {
+	var pings atomic.Int32
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pings.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"name":"influxdb","status":"fail","message":"overloaded"}`))
+			return
+		}
+		w.Write([]byte(`{"name":"influxdb","status":"pass"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "token", "org", "bucket", "measurement")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+	client.SetHealthCacheTTL(time.Minute)
+	pings.Store(0)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := client.CheckHealth(ctx); err != nil {
+			t.Fatalf("CheckHealth() error = %v", err)
+		}
+	}
+	if got := pings.Load(); got != 1 {
+		t.Errorf("pings for two rapid checks = %d, want 1", got)
+	}
+
+	// CheckConnection always pings and refreshes the cached result
+	failing.Store(true)
+	if err := client.CheckConnection(ctx); err == nil {
+		t.Fatal("CheckConnection() expected error, got nil")
+	}
+	if err := client.CheckHealth(ctx); err == nil {
+		t.Error("CheckHealth() reused a stale healthy result after a failed ping")
+	}
+	if got := pings.Load(); got != 2 {
+		t.Errorf("pings = %d, want 2", got)
+	}
+
+	// Expiring the cache forces the next check to ping
+	failing.Store(false)
+	client.ExpireHealthCache()
+	if err := client.CheckHealth(ctx); err != nil {
+		t.Errorf("CheckHealth() after expiry error = %v", err)
+	}
+	if got := pings.Load(); got != 3 {
+		t.Errorf("pings = %d, want 3", got)
+	}
+}
+
+// stubWriteAPI is a minimal api.WriteAPI whose error channel is controlled by the test
+type stubWriteAPI struct {
+	errors chan error
+}
+
+func (s *stubWriteAPI) WriteRecord(line string)                           {}
+func (s *stubWriteAPI) WritePoint(point *write.Point)                     {}
+func (s *stubWriteAPI) Flush()                                            {}
+func (s *stubWriteAPI) Errors() <-chan error                              { return s.errors }
+func (s *stubWriteAPI) SetWriteFailedCallback(cb api.WriteFailedCallback) {}
+
+func TestClient_MonitorErrors_AsyncFailureHandler(t *testing.T) {
+	stub := &stubWriteAPI{errors: make(chan error)}
+	c := &Client{
+		writeAPI: stub,
+		stopChan: make(chan struct{}),
+	}
+
+	var handled, failed atomic.Int32
+	c.errorHandler = func(err error) { handled.Add(1) }
+	c.SetAsyncFailureHandler(func(err error) { failed.Add(1) })
+
+	c.wg.Add(1)
+	go c.monitorErrors()
+
+	stub.errors <- errors.New("write failed: retries exhausted")
+	stub.errors <- errors.New("write failed: retries exhausted")
+	close(stub.errors)
+	c.wg.Wait()
+
+	if handled.Load() != 2 {
+		t.Errorf("errorHandler called %d times, want 2", handled.Load())
+	}
+	if failed.Load() != 2 {
+		t.Errorf("async failure handler called %d times, want 2", failed.Load())
+	}
+}
+
+func TestClient_MonitorErrors_AsyncFailureHandlerPanic(t *testing.T) {
+	stub := &stubWriteAPI{errors: make(chan error)}
+	c := &Client{
+		writeAPI: stub,
+		stopChan: make(chan struct{}),
+	}
+
+	var failed atomic.Int32
+	c.SetAsyncFailureHandler(func(err error) {
+		failed.Add(1)
+		panic("handler panic")
+	})
+
+	c.wg.Add(1)
+	go c.monitorErrors()
+
+	stub.errors <- errors.New("first")
+	stub.errors <- errors.New("second")
+	close(stub.errors)
+	c.wg.Wait()
+
+	if failed.Load() != 2 {
+		t.Errorf("async failure handler called %d times, want 2 despite panics", failed.Load())
+	}
+}
+
+func TestClient_FailedBatchHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/health":
+			w.Write([]byte(`{"name":"influxdb","status":"pass"}`))
+		case "/api/v2/write":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"code":"unavailable","message":"overloaded"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "test_token", "test_org", "test_bucket", "energy")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+	client.SetConsumptionUnit("Wh", 1000)
+
+	failed := make(chan []DataPoint, 1)
+	client.SetFailedBatchHandler(func(points []DataPoint, err error) {
+		if err == nil {
+			t.Error("failed batch handler called without an error")
+		}
+		failed <- points
+	})
+
+	at := time.Unix(1700000000, 0)
+	client.WriteDataPoint(DataPoint{Timestamp: at, ConsumptionDelta: 0.25, Demand: 600, CostDelta: 0.05, Consumption: 1234.5})
+	client.Flush()
+
+	select {
+	case points := <-failed:
+		// Points come back as they were given, in kWh
+		want := DataPoint{Timestamp: at, ConsumptionDelta: 0.25, Demand: 600, CostDelta: 0.05, Consumption: 1234.5}
+		if len(points) != 1 || !points[0].Timestamp.Equal(want.Timestamp) || points[0].ConsumptionDelta != want.ConsumptionDelta ||
+			points[0].Demand != want.Demand || points[0].CostDelta != want.CostDelta || points[0].Consumption != want.Consumption {
+			t.Errorf("failed batch points = %+v, want [%+v]", points, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed batch handler not called")
+	}
+}
+
+func TestNewClientWithOptions_SelfSignedTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"name":"influxdb","status":"pass"}`))
+	}))
+	defer server.Close()
+
+	// The test server's certificate is self-signed, so default verification fails
+	if client, err := NewClient(server.URL, "token", "org", "bucket", "measurement"); err == nil {
+		client.Close()
+		t.Fatal("NewClient() against self-signed server succeeded, want TLS verification error")
+	}
+
+	client, err := NewClientWithOptions(server.URL, "token", "org", "bucket", "measurement", nil, Options{
+		TLSConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec // Test server uses a self-signed certificate
+	})
+	if err != nil {
+		t.Fatalf("NewClientWithOptions() with InsecureSkipVerify error = %v", err)
+	}
+	defer client.Close()
+
+	if err := client.CheckConnection(context.Background()); err != nil {
+		t.Errorf("CheckConnection() error = %v", err)
+	}
+}
+
+func TestNewTransport_ConnectionPool(t *testing.T) {
+	defaults := newTransport(Options{})
+	if defaults.MaxIdleConns != defaultMaxIdleConns || defaults.MaxIdleConnsPerHost != defaultMaxIdleConnsPerHost {
+		t.Errorf("default idle conns = %d/%d, want %d/%d", defaults.MaxIdleConns, defaults.MaxIdleConnsPerHost,
+			defaultMaxIdleConns, defaultMaxIdleConnsPerHost)
+	}
+	if defaults.IdleConnTimeout != defaultIdleConnTimeout {
+		t.Errorf("default IdleConnTimeout = %v, want %v", defaults.IdleConnTimeout, defaultIdleConnTimeout)
+	}
+	if (Options{}).customTransport() {
+		t.Error("customTransport() = true for zero Options, want false so influxdb2 builds its own")
+	}
+
+	tuned := newTransport(Options{MaxIdleConns: 10, MaxIdleConnsPerHost: 5, IdleConnTimeout: time.Minute})
+	if tuned.MaxIdleConns != 10 || tuned.MaxIdleConnsPerHost != 5 || tuned.IdleConnTimeout != time.Minute {
+		t.Errorf("tuned transport = %d/%d/%v, want 10/5/1m0s", tuned.MaxIdleConns, tuned.MaxIdleConnsPerHost, tuned.IdleConnTimeout)
+	}
+}
+
+// benchmarkWriteBurst writes bursts of concurrent blocking points through a client
+// built with opts, reporting how many TCP connections the server accepted
+func benchmarkWriteBurst(b *testing.B, opts Options) {
+	const burst = 20
+
+	var conns atomic.Int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"influxdb","status":"pass"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client, err := NewClientWithOptions(server.URL, "token", "org", "bucket", "measurement", nil, opts)
+	if err != nil {
+		b.Fatalf("NewClientWithOptions() error = %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var wg sync.WaitGroup
+		for j := 0; j < burst; j++ {
+			wg.Add(1)
+			go func(j int) {
+				defer wg.Done()
+				dp := DataPoint{Timestamp: time.Unix(int64(i*burst+j), 0), Demand: 500}
+				if err := client.WritePointDirectly(ctx, dp); err != nil {
+					b.Error(err)
+				}
+			}(j)
+		}
+		wg.Wait()
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/burst")
+}
+
+func BenchmarkWriteBurst_DefaultTransport(b *testing.B) {
+	benchmarkWriteBurst(b, Options{})
+}
+
+func BenchmarkWriteBurst_SmallPool(b *testing.B) {
+	// Fewer idle connections than concurrent writers forces connections to be re-dialled
+	benchmarkWriteBurst(b, Options{MaxIdleConnsPerHost: 2})
+}
+
+func BenchmarkWriteBurst_TunedTransport(b *testing.B) {
+	benchmarkWriteBurst(b, Options{MaxIdleConns: 200, MaxIdleConnsPerHost: 50, IdleConnTimeout: 5 * time.Minute, KeepAlive: 30 * time.Second})
+}
+
+// newBucketsServer mocks the buckets API. exists controls whether the bucket is listed and
+// createStatus is the response to a create request. It returns the create request bodies.
+func newBucketsServer(t *testing.T, exists bool, createStatus int) (*httptest.Server, *[]map[string]interface{}) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var creates []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/health":
+			w.Write([]byte(`{"name":"influxdb","status":"pass"}`))
+		case r.URL.Path == "/api/v2/buckets" && r.Method == http.MethodGet:
+			if exists && r.URL.Query().Get("name") == "test_bucket" {
+				w.Write([]byte(`{"buckets":[{"id":"b1","orgID":"o1","name":"test_bucket","retentionRules":[]}]}`))
+				return
+			}
+			w.Write([]byte(`{"buckets":[]}`))
+		case r.URL.Path == "/api/v2/orgs":
+			w.Write([]byte(`{"orgs":[{"id":"o1","name":"test_org"}]}`))
+		case r.URL.Path == "/api/v2/buckets" && r.Method == http.MethodPost:
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			creates = append(creates, body)
+			mu.Unlock()
+			if createStatus != http.StatusCreated {
+				w.WriteHeader(createStatus)
+				w.Write([]byte(`{"code":"forbidden","message":"insufficient permissions for write:orgs/o1/buckets"}`))
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"b2","orgID":"o1","name":"test_bucket","retentionRules":[]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &creates
+}
+
+func TestClient_EnsureBucket(t *testing.T) {
+	tests := []struct {
+		name        string
+		exists      bool
+		wantCreated bool
+	}{
+		{name: "missing bucket is created", exists: false, wantCreated: true},
+		{name: "existing bucket is left alone", exists: true, wantCreated: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, creates := newBucketsServer(t, tt.exists, http.StatusCreated)
+
+			client, err := NewClient(server.URL, "test_token", "test_org", "test_bucket", "energy")
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			defer client.Close()
+
+			created, err := client.EnsureBucket(context.Background(), 30*24*time.Hour)
+			if err != nil {
+				t.Fatalf("EnsureBucket() error = %v", err)
+			}
+			if created != tt.wantCreated {
+				t.Errorf("EnsureBucket() created = %v, want %v", created, tt.wantCreated)
+			}
+
+			if !tt.wantCreated {
+				if len(*creates) != 0 {
+					t.Errorf("create requests = %d, want 0 for an existing bucket", len(*creates))
+				}
+				return
+			}
+			if len(*creates) != 1 {
+				t.Fatalf("create requests = %d, want 1", len(*creates))
+			}
+			body := (*creates)[0]
+			if body["name"] != "test_bucket" || body["orgID"] != "o1" {
+				t.Errorf("create request = %v, want test_bucket in org o1", body)
+			}
+			rules, _ := body["retentionRules"].([]interface{})
+			if len(rules) != 1 || rules[0].(map[string]interface{})["everySeconds"] != float64(30*24*3600) {
+				t.Errorf("retentionRules = %v, want 30 days", body["retentionRules"])
+			}
+		})
+	}
+}
+
+func TestClient_EnsureBucket_PermissionDenied(t *testing.T) {
+	server, _ := newBucketsServer(t, false, http.StatusForbidden)
+
+	client, err := NewClient(server.URL, "test_token", "test_org", "test_bucket", "energy")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	created, err := client.EnsureBucket(context.Background(), 0)
+	if created {
+		t.Error("EnsureBucket() created = true, want false")
+	}
+	if !errors.Is(err, ErrBucketPermission) {
+		t.Errorf("EnsureBucket() error = %v, want ErrBucketPermission", err)
+	}
+}
+
+func TestClient_CheckOrgAndBucket(t *testing.T) {
+	tests := []struct {
+		name    string
+		orgs    func(w http.ResponseWriter)
+		buckets func(w http.ResponseWriter)
+		down    bool // Stop the server before checking
+		wantErr error
+	}{
+		{
+			name: "org and bucket exist",
+			orgs: func(w http.ResponseWriter) { w.Write([]byte(`{"orgs":[{"id":"o1","name":"test_org"}]}`)) },
+			buckets: func(w http.ResponseWriter) {
+				w.Write([]byte(`{"buckets":[{"id":"b1","orgID":"o1","name":"test_bucket","retentionRules":[]}]}`))
+			},
+		},
+		{
+			name: "org not found",
+			orgs: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"code":"not found","message":"organization name \"test_org\" not found"}`))
+			},
+			wantErr: ErrOrgNotFound,
+		},
+		{
+			name: "bucket not found",
+			orgs: func(w http.ResponseWriter) { w.Write([]byte(`{"orgs":[{"id":"o1","name":"test_org"}]}`)) },
+			buckets: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"code":"not found","message":"bucket \"test_bucket\" not found"}`))
+			},
+			wantErr: ErrBucketNotFound,
+		},
+		{
+			name: "bucket name mismatch",
+			orgs: func(w http.ResponseWriter) { w.Write([]byte(`{"orgs":[{"id":"o1","name":"test_org"}]}`)) },
+			buckets: func(w http.ResponseWriter) {
+				w.Write([]byte(`{"buckets":[{"id":"b2","orgID":"o1","name":"other_bucket","retentionRules":[]}]}`))
+			},
+			wantErr: ErrBucketNotFound,
+		},
+		{
+			name:    "bucket not visible",
+			orgs:    func(w http.ResponseWriter) { w.Write([]byte(`{"orgs":[{"id":"o1","name":"test_org"}]}`)) },
+			buckets: func(w http.ResponseWriter) { w.Write([]byte(`{"buckets":[]}`)) },
+			wantErr: ErrNotVisible,
+		},
+		{
+			name:    "org and bucket not visible",
+			orgs:    func(w http.ResponseWriter) { w.Write([]byte(`{"orgs":[]}`)) },
+			buckets: func(w http.ResponseWriter) { w.Write([]byte(`{"buckets":[]}`)) },
+			wantErr: ErrNotVisible,
+		},
+		{
+			name: "org not visible but bucket is",
+			orgs: func(w http.ResponseWriter) { w.Write([]byte(`{"orgs":[]}`)) },
+			buckets: func(w http.ResponseWriter) {
+				w.Write([]byte(`{"buckets":[{"id":"b1","orgID":"o1","name":"test_bucket","retentionRules":[]}]}`))
+			},
+		},
+		{
+			name: "org lookup forbidden",
+			orgs: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"code":"forbidden","message":"insufficient permissions for read:orgs"}`))
+			},
+			wantErr: ErrBucketPermission,
+		},
+		{
+			name:    "connection failed",
+			down:    true,
+			wantErr: ErrConnectionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/health":
+					w.Write([]byte(`{"name":"influxdb","status":"pass"}`))
+				case "/api/v2/orgs":
+					tt.orgs(w)
+				case "/api/v2/buckets":
+					if q := r.URL.Query(); q.Get("orgID") != "o1" && q.Get("org") != "test_org" {
+						t.Errorf("bucket lookup query = %q, want orgID o1 or org test_org", r.URL.RawQuery)
+					}
+					tt.buckets(w)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			client, err := NewClient(server.URL, "test_token", "test_org", "test_bucket", "energy")
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			defer client.Close()
+			if tt.down {
+				server.Close()
+			}
+
+			err = client.CheckOrgAndBucket(context.Background())
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("CheckOrgAndBucket() error = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckOrgAndBucket() error = %v, want %v", err, tt.wantErr)
+			}
+			for _, other := range []error{ErrOrgNotFound, ErrBucketNotFound, ErrNotVisible, ErrConnectionFailed} {
+				if other != tt.wantErr && errors.Is(err, other) {
+					t.Errorf("CheckOrgAndBucket() error = %v, also matches %v", err, other)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_WriteDataPointsBlocking(t *testing.T) {
+	var requests, lines atomic.Int32
+	var status atomic.Int32
+	status.Store(http.StatusNoContent)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"influxdb","status":"pass"}`))
+		case "/api/v2/write":
+			body, _ := io.ReadAll(r.Body)
+			requests.Add(1)
+			lines.Add(int32(len(strings.Split(strings.TrimSpace(string(body)), "\n"))))
+			if code := int(status.Load()); code != http.StatusNoContent {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(code)
+				w.Write([]byte(`{"code":"invalid","message":"partial write: field type conflict"}`))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "test_token", "test_org", "test_bucket", "energy")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	now := time.Now()
+	points := []DataPoint{
+		{Timestamp: now, Demand: 500},
+		{Timestamp: now.Add(10 * time.Second), ConsumptionDelta: math.NaN(), Demand: math.NaN(), CostDelta: math.NaN(), Consumption: math.NaN()},
+		{Timestamp: now.Add(20 * time.Second), Demand: 600},
+	}
+
+	err = client.WriteDataPointsBlocking(context.Background(), points)
+	if err == nil || !strings.Contains(err.Error(), "point 1") {
+		t.Errorf("WriteDataPointsBlocking() error = %v, want error for the invalid point", err)
+	}
+	if requests.Load() != 1 || lines.Load() != 2 {
+		t.Errorf("write requests = %d with %d lines, want 1 request with the 2 valid points", requests.Load(), lines.Load())
+	}
+
+	status.Store(http.StatusBadRequest)
+	err = client.WriteDataPointsBlocking(context.Background(), points[:1])
+	if err == nil || !strings.Contains(err.Error(), "failed to write 1 points") {
+		t.Errorf("WriteDataPointsBlocking() error = %v, want rejected write error", err)
+	}
+}
+
+func TestClient_QueryRecent(t *testing.T) {
+	const response = `#datatype,string,long,string,double
+#group,false,false,true,false
+#default,sum,,,
+,result,table,_field,_value
+,,0,consumption_delta,1.25
+,,1,cost_delta,0.3
+
+#datatype,string,long,long
+#group,false,false,false
+#default,count,,
+,result,table,_value
+,,0,42
+
+#datatype,string,long,string,double
+#group,false,false,false,false
+#default,max,,,
+,result,table,_field,_value
+,,0,demand,2500
+
+`
+	var query atomic.Value
+	var fail atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"influxdb","status":"pass"}`))
+			return
+		case "/api/v2/query":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if fail.Load() {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"invalid","message":"bad query"}`))
+			return
+		}
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		query.Store(body.Query)
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "test_token", "test_org", "test_bucket", "energy")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	summary, err := client.QueryRecent(context.Background(), "", time.Hour)
+	if err != nil {
+		t.Fatalf("QueryRecent() error = %v", err)
+	}
+	if summary.Points != 42 || summary.ConsumptionDelta != 1.25 || summary.CostDelta != 0.3 || summary.DemandMax != 2500 {
+		t.Errorf("QueryRecent() = %+v, want 42 points, 1.25 kWh, 0.3 cost, 2500 max demand", summary)
+	}
+	if since := time.Since(summary.Start); since < time.Hour || since > time.Hour+time.Minute {
+		t.Errorf("QueryRecent() start = %v, want an hour ago", summary.Start)
+	}
+	if q, _ := query.Load().(string); !strings.Contains(q, `r._measurement == "energy"`) {
+		t.Errorf("query = %s, want the client's measurement", q)
+	}
+
+	fail.Store(true)
+	if _, err := client.QueryRecent(context.Background(), "energy", time.Hour); err == nil {
+		t.Error("QueryRecent() error = nil, want query error")
+	}
+}
+
+func TestClient_PointTimes(t *testing.T) {
+	const response = `#datatype,string,long,dateTime:RFC3339
+#group,false,false,false
+#default,_result,,
+,result,table,_value
+,,0,2026-03-01T12:00:10Z
+,,0,2026-03-01T12:00:00Z
+
+`
+	var query atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"influxdb","status":"pass"}`))
+		case "/api/v2/query":
+			var body struct {
+				Query string `json:"query"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			query.Store(body.Query)
+			w.Header().Set("Content-Type", "text/csv")
+			w.Write([]byte(response))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "test_token", "test_org", "test_bucket", "energy")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	times, err := client.PointTimes(context.Background(), start, start.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("PointTimes() error = %v", err)
+	}
+	if len(times) != 2 || !times[0].Equal(start) || !times[1].Equal(start.Add(10*time.Second)) {
+		t.Errorf("PointTimes() = %v, want %v and %v in order", times, start, start.Add(10*time.Second))
+	}
+	if q, _ := query.Load().(string); !strings.Contains(q, `distinct(column: "_time")`) || !strings.Contains(q, `r._measurement == "energy"`) {
+		t.Errorf("query = %s, want distinct times for the client's measurement", q)
+	}
+}
@@ -0,0 +1,85 @@
+package influx
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RecentSummary aggregates the points stored for a measurement since Start
+type RecentSummary struct {
+	Start            time.Time `json:"start"`
+	Points           int64     `json:"points"`
+	ConsumptionDelta float64   `json:"consumption_delta"` // Sum of the points' consumption deltas
+	CostDelta        float64   `json:"cost_delta"`        // Sum of the points' cost deltas
+	DemandMax        float64   `json:"demand_max"`        // 0 when no points are stored
+}
+
+// QueryRecent reads back the points stored for measurement over the last window and
+// returns their summed consumption (in kWh, whatever unit it is written in) and cost,
+// point count and peak demand. An empty measurement uses the client's.
+func (c *Client) QueryRecent(ctx context.Context, measurement string, window time.Duration) (RecentSummary, error) {
+	if measurement == "" {
+		measurement = c.measurement
+	}
+	summary := RecentSummary{Start: time.Now().Add(-window).UTC()}
+
+	query := fmt.Sprintf(`data = from(bucket: %q)
+  |> range(start: %s)
+  |> filter(fn: (r) => r._measurement == %q)
+data
+  |> filter(fn: (r) => r._field == "consumption_delta" or r._field == "cost_delta")
+  |> group(columns: ["_field"])
+  |> sum()
+  |> yield(name: "sum")
+data
+  |> filter(fn: (r) => r._field == "consumption_delta")
+  |> group()
+  |> count()
+  |> yield(name: "count")
+data
+  |> filter(fn: (r) => r._field == "demand")
+  |> group()
+  |> max()
+  |> yield(name: "max")`,
+		c.bucket,
+		summary.Start.Format(time.RFC3339Nano),
+		measurement)
+
+	result, err := c.client.QueryAPI(c.org).Query(ctx, query)
+	if err != nil {
+		return RecentSummary{}, fmt.Errorf("failed to query recent data: %w", err)
+	}
+	defer result.Close()
+
+	for result.Next() {
+		record := result.Record()
+		switch record.Result() {
+		case "sum":
+			value, ok := record.Value().(float64)
+			if !ok || !isFinite(value) {
+				continue
+			}
+			switch record.Field() {
+			case "consumption_delta":
+				_, scale := c.energyUnit()
+				summary.ConsumptionDelta = value / scale
+			case "cost_delta":
+				summary.CostDelta = value
+			}
+		case "count":
+			if n, ok := record.Value().(int64); ok {
+				summary.Points = n
+			}
+		case "max":
+			if value, ok := record.Value().(float64); ok && isFinite(value) {
+				summary.DemandMax = value
+			}
+		}
+	}
+	if err := result.Err(); err != nil {
+		return RecentSummary{}, fmt.Errorf("failed to read recent data: %w", err)
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,144 @@
+package influx
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+)
+
+// Summary aggregates the data points fetched in one poll into a single point
+type Summary struct {
+	Start            time.Time // Earliest reading in the batch
+	End              time.Time // Latest reading in the batch; the summary point's timestamp
+	Count            int
+	DemandMin        float64
+	DemandMax        float64
+	DemandAvg        float64
+	ConsumptionDelta float64 // Sum of the readings' consumption deltas
+	CostDelta        float64 // Sum of the readings' cost deltas
+}
+
+// Summarize aggregates dataPoints into min/max/avg demand and summed consumption and cost
+// deltas. NaN and Inf values are left out of each aggregate. It returns false for an empty batch.
+func Summarize(dataPoints []DataPoint) (Summary, bool) {
+	if len(dataPoints) == 0 {
+		return Summary{}, false
+	}
+
+	s := Summary{
+		Start:     dataPoints[0].Timestamp,
+		End:       dataPoints[0].Timestamp,
+		Count:     len(dataPoints),
+		DemandMin: math.NaN(),
+		DemandMax: math.NaN(),
+		DemandAvg: math.NaN(),
+	}
+
+	var demandSum float64
+	var demandCount int
+	for _, dp := range dataPoints {
+		if dp.Timestamp.Before(s.Start) {
+			s.Start = dp.Timestamp
+		}
+		if dp.Timestamp.After(s.End) {
+			s.End = dp.Timestamp
+		}
+		if isFinite(dp.Demand) {
+			if demandCount == 0 || dp.Demand < s.DemandMin {
+				s.DemandMin = dp.Demand
+			}
+			if demandCount == 0 || dp.Demand > s.DemandMax {
+				s.DemandMax = dp.Demand
+			}
+			demandSum += dp.Demand
+			demandCount++
+		}
+		if isFinite(dp.ConsumptionDelta) {
+			s.ConsumptionDelta += dp.ConsumptionDelta
+		}
+		if isFinite(dp.CostDelta) {
+			s.CostDelta += dp.CostDelta
+		}
+	}
+	if demandCount > 0 {
+		s.DemandAvg = demandSum / float64(demandCount)
+	}
+
+	return s, true
+}
+
+func isFinite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+// WriteSummary writes a summary point to the given measurement synchronously through
+// the circuit breaker. It carries the same tags and consumption unit as raw points and,
+// like WritePointDirectly, waits out any active backpressure pause first.
+func (c *Client) WriteSummary(ctx context.Context, measurement string, s Summary) error {
+	if err := c.waitForBackpressure(ctx); err != nil {
+		return err
+	}
+
+	unit, scale := c.energyUnit()
+	fields := c.sanitizeFields(s.End, map[string]float64{
+		"demand_min":        s.DemandMin,
+		"demand_max":        s.DemandMax,
+		"demand_avg":        s.DemandAvg,
+		"consumption_delta": s.ConsumptionDelta * scale,
+		"cost_delta":        s.CostDelta,
+	})
+	fields["count"] = int64(s.Count)
+	fields["window_seconds"] = s.End.Sub(s.Start).Seconds()
+
+	p := write.NewPoint(measurement, c.energyTags(unit), fields, c.pointTime(s.End))
+	_, err := c.circuitBreaker.Execute(func() (interface{}, error) {
+		writeAPIBlocking := c.client.WriteAPIBlocking(c.org, c.bucket)
+		return nil, c.classifyWriteError(writeAPIBlocking.WritePoint(ctx, p))
+	})
+	return err
+}
+
+// WriteTemperature writes an outdoor temperature reading to the given measurement, with
+// the same tags as raw points so it can be overlaid on consumption. Like WriteSummary it
+// writes synchronously through the circuit breaker after any backpressure pause.
+func (c *Client) WriteTemperature(ctx context.Context, measurement string, t time.Time, celsius float64) error {
+	if err := c.waitForBackpressure(ctx); err != nil {
+		return err
+	}
+
+	fields := c.sanitizeFields(t, map[string]float64{"temperature_c": celsius})
+	if len(fields) == 0 {
+		return fmt.Errorf("invalid temperature reading: %v", celsius)
+	}
+
+	p := write.NewPoint(measurement, c.tags(), fields, c.pointTime(t))
+	_, err := c.circuitBreaker.Execute(func() (interface{}, error) {
+		writeAPIBlocking := c.client.WriteAPIBlocking(c.org, c.bucket)
+		return nil, c.classifyWriteError(writeAPIBlocking.WritePoint(ctx, p))
+	})
+	return err
+}
+
+// WriteSavingSession marks a Saving Session window in the given measurement with an
+// active=1 point at its start and an active=0 point at its end, tagged with the session
+// code, so dashboards can shade the window
+func (c *Client) WriteSavingSession(ctx context.Context, measurement, code string, start, end time.Time) error {
+	if err := c.waitForBackpressure(ctx); err != nil {
+		return err
+	}
+
+	tags := c.tags()
+	tags["session"] = code
+	points := []*write.Point{
+		write.NewPoint(measurement, tags, map[string]interface{}{"active": 1}, c.pointTime(start)),
+		write.NewPoint(measurement, tags, map[string]interface{}{"active": 0}, c.pointTime(end)),
+	}
+	_, err := c.circuitBreaker.Execute(func() (interface{}, error) {
+		writeAPIBlocking := c.client.WriteAPIBlocking(c.org, c.bucket)
+		return nil, c.classifyWriteError(writeAPIBlocking.WritePoint(ctx, points...))
+	})
+	return err
+}
@@ -0,0 +1,105 @@
+package influx
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	points := []DataPoint{
+		{Timestamp: base.Add(10 * time.Second), ConsumptionDelta: 0.002, Demand: 600, CostDelta: 0.0005},
+		{Timestamp: base, ConsumptionDelta: 0.001, Demand: 300, CostDelta: 0.00025},
+		{Timestamp: base.Add(20 * time.Second), ConsumptionDelta: 0.003, Demand: 1200, CostDelta: 0.00075},
+		{Timestamp: base.Add(30 * time.Second), ConsumptionDelta: math.NaN(), Demand: math.Inf(1), CostDelta: math.NaN()},
+	}
+
+	s, ok := Summarize(points)
+	if !ok {
+		t.Fatal("Summarize() ok = false, want true")
+	}
+
+	if !s.Start.Equal(base) || !s.End.Equal(base.Add(30*time.Second)) {
+		t.Errorf("window = %v..%v, want %v..%v", s.Start, s.End, base, base.Add(30*time.Second))
+	}
+	if s.Count != 4 {
+		t.Errorf("Count = %d, want 4", s.Count)
+	}
+	if s.DemandMin != 300 || s.DemandMax != 1200 || s.DemandAvg != 700 {
+		t.Errorf("demand min/max/avg = %v/%v/%v, want 300/1200/700", s.DemandMin, s.DemandMax, s.DemandAvg)
+	}
+	if math.Abs(s.ConsumptionDelta-0.006) > 1e-12 {
+		t.Errorf("ConsumptionDelta = %v, want 0.006", s.ConsumptionDelta)
+	}
+	if math.Abs(s.CostDelta-0.0015) > 1e-12 {
+		t.Errorf("CostDelta = %v, want 0.0015", s.CostDelta)
+	}
+}
+
+func TestSummarize_Empty(t *testing.T) {
+	if _, ok := Summarize(nil); ok {
+		t.Error("Summarize(nil) ok = true, want false")
+	}
+}
+
+func TestSummarize_NoValidDemand(t *testing.T) {
+	s, ok := Summarize([]DataPoint{{Timestamp: time.Now(), Demand: math.NaN(), ConsumptionDelta: 0.5}})
+	if !ok {
+		t.Fatal("Summarize() ok = false, want true")
+	}
+	if !math.IsNaN(s.DemandAvg) || !math.IsNaN(s.DemandMin) || !math.IsNaN(s.DemandMax) {
+		t.Errorf("demand aggregates = %v/%v/%v, want NaN so they are dropped on write", s.DemandMin, s.DemandMax, s.DemandAvg)
+	}
+	if s.ConsumptionDelta != 0.5 {
+		t.Errorf("ConsumptionDelta = %v, want 0.5", s.ConsumptionDelta)
+	}
+}
+
+func TestClient_WriteSummary(t *testing.T) {
+	bodies := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"influxdb","status":"pass"}`))
+		case "/api/v2/write":
+			body, _ := io.ReadAll(r.Body)
+			bodies <- string(body)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "test_token", "test_org", "test_bucket", "energy")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s, _ := Summarize([]DataPoint{
+		{Timestamp: base, Demand: 300, ConsumptionDelta: 0.001},
+		{Timestamp: base.Add(20 * time.Second), Demand: 500, ConsumptionDelta: 0.002},
+	})
+	if err := client.WriteSummary(context.Background(), "energy_summary", s); err != nil {
+		t.Fatalf("WriteSummary() error = %v", err)
+	}
+
+	line := <-bodies
+	for _, want := range []string{"energy_summary,source=octopus_home_mini ", "demand_min=300", "demand_max=500", "demand_avg=400", "count=2i", "window_seconds=20"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("line protocol %q missing %q", line, want)
+		}
+	}
+	if !strings.HasSuffix(strings.TrimSpace(line), " 1704110420000000000") {
+		t.Errorf("line protocol %q not timestamped at the end of the window", line)
+	}
+}
@@ -0,0 +1,90 @@
+package monitor
+
+import (
+	"sort"
+	"time"
+
+	"github.com/soothill/octopus-home-mini/pkg/octopus"
+)
+
+// adaptiveStep is the fraction (1/adaptiveStep) of the distance to the observed cadence
+// the interval moves per poll, so one irregular batch cannot swing it far
+const adaptiveStep = 4
+
+// intervalTuner nudges the poll interval toward the cadence at which readings arrive,
+// within [min, max]. It is only used from the polling goroutine.
+type intervalTuner struct {
+	interval time.Duration
+	min, max time.Duration
+	lastRead time.Time // Newest reading seen, so the cadence spans consecutive polls
+}
+
+func newIntervalTuner(interval, min, max time.Duration) *intervalTuner {
+	t := &intervalTuner{min: min, max: max}
+	t.interval = t.clamp(interval)
+	return t
+}
+
+// observe adjusts the interval after a successful fetch and returns it. The cadence is
+// the median gap between reading timestamps; a fetch with no new readings came too
+// early, so it lengthens the interval instead.
+func (t *intervalTuner) observe(readings []octopus.TelemetryData) time.Duration {
+	var times []time.Time
+	for _, r := range readings {
+		if r.ReadAt.After(t.lastRead) {
+			times = append(times, r.ReadAt)
+		}
+	}
+	if len(times) == 0 {
+		t.interval = t.clamp(t.interval + t.interval/adaptiveStep)
+		return t.interval
+	}
+
+	if !t.lastRead.IsZero() {
+		times = append(times, t.lastRead)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	t.lastRead = times[len(times)-1]
+
+	cadence, ok := medianGap(times)
+	if !ok {
+		return t.interval
+	}
+	t.interval = t.clamp(t.interval + (cadence-t.interval)/adaptiveStep)
+	return t.interval
+}
+
+func (t *intervalTuner) clamp(interval time.Duration) time.Duration {
+	if interval < t.min {
+		return t.min
+	}
+	if interval > t.max {
+		return t.max
+	}
+	return interval
+}
+
+// medianGap returns the median of the non-zero gaps between sorted times, reporting
+// false when there are none
+func medianGap(times []time.Time) (time.Duration, bool) {
+	var gaps []time.Duration
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap > 0 {
+			gaps = append(gaps, gap)
+		}
+	}
+	if len(gaps) == 0 {
+		return 0, false
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	return gaps[len(gaps)/2], true
+}
+
+// pollInterval returns the interval before degraded-mode backoff: the tuned interval
+// when AdaptivePollInterval is set, otherwise PollInterval
+func (m *Monitor) pollInterval() time.Duration {
+	if m.tuner != nil {
+		return m.tuner.interval
+	}
+	return m.Cfg.PollInterval
+}
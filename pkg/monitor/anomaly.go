@@ -0,0 +1,100 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/soothill/octopus-home-mini/pkg/octopus"
+)
+
+// flatlineDetector spots consumption that drops to zero for a sustained run during
+// active hours, which usually means the meter or Home Mini has lost connectivity
+type flatlineDetector struct {
+	threshold int // Consecutive zero readings that trigger an alert
+	startHour int // Active window start hour (inclusive, local time)
+	endHour   int // Active window end hour (exclusive); equal to startHour means all day
+
+	zeroRun  int
+	runStart time.Time
+	alerting bool
+	warned   bool // Warning sent for the current run, so its end is worth announcing
+}
+
+func newFlatlineDetector(threshold, startHour, endHour int) *flatlineDetector {
+	return &flatlineDetector{
+		threshold: threshold,
+		startHour: startHour,
+		endHour:   endHour,
+	}
+}
+
+// inActiveHours reports whether t falls inside the window, which may wrap past midnight
+func (d *flatlineDetector) inActiveHours(t time.Time) bool {
+	if d.startHour == d.endHour {
+		return true
+	}
+	hour := t.Local().Hour()
+	if d.startHour < d.endHour {
+		return hour >= d.startHour && hour < d.endHour
+	}
+	return hour >= d.startHour || hour < d.endHour
+}
+
+// observe processes a batch of readings and reports whether a flatline started
+// or ended within it. An end is only reported once the start has been warned of, so a
+// flatline that starts and ends within one batch reports neither.
+func (d *flatlineDetector) observe(data []octopus.TelemetryData) (started, recovered bool) {
+	for _, reading := range data {
+		if reading.ConsumptionDelta != 0 || reading.Demand != 0 {
+			d.zeroRun = 0
+			if d.alerting {
+				d.alerting = false
+				recovered = d.warned
+				started = false
+				d.warned = false
+			}
+			continue
+		}
+
+		// Zero readings outside active hours are expected (e.g. overnight) and break the run
+		if !d.inActiveHours(reading.ReadAt) {
+			d.zeroRun = 0
+			continue
+		}
+
+		if d.zeroRun == 0 {
+			d.runStart = reading.ReadAt
+		}
+		d.zeroRun++
+		if d.zeroRun >= d.threshold && !d.alerting {
+			d.alerting = true
+			started = true
+			recovered = false
+		}
+	}
+	return started, recovered
+}
+
+// checkFlatline runs the flatline detector over new telemetry and notifies on transitions
+func (m *Monitor) checkFlatline(ctx context.Context, data []octopus.TelemetryData) {
+	if m.flatline == nil {
+		return
+	}
+	logger := loggerFrom(ctx)
+
+	started, recovered := m.flatline.observe(data)
+	if started {
+		m.flatline.warned = true
+		logger.Warn().
+			Int("zero_readings", m.flatline.zeroRun).
+			Time("since", m.flatline.runStart).
+			Msg("Consumption flatlined at zero")
+		m.NotifyWarning("Consumption", fmt.Sprintf("Consumption has been zero for %d readings since %s. The meter or Home Mini may have lost connectivity.",
+			m.flatline.zeroRun, m.flatline.runStart.Format(time.RFC3339)))
+	}
+	if recovered {
+		logger.Info().Msg("Consumption readings resumed")
+		m.NotifyInfo("Consumption", "Non-zero consumption readings have resumed")
+	}
+}
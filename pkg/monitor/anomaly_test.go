@@ -0,0 +1,112 @@
+package monitor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soothill/octopus-home-mini/pkg/octopus"
+)
+
+// readings returns n telemetry readings 10 seconds apart starting at start
+func readings(start time.Time, n int, consumption float64) []octopus.TelemetryData {
+	data := make([]octopus.TelemetryData, 0, n)
+	for i := 0; i < n; i++ {
+		data = append(data, octopus.TelemetryData{
+			ReadAt:           start.Add(time.Duration(i) * 10 * time.Second),
+			ConsumptionDelta: consumption,
+			Demand:           consumption * 1000,
+		})
+	}
+	return data
+}
+
+func TestMonitor_CheckFlatline(t *testing.T) {
+	m := newTestMonitor(t)
+	notifier := &recordingNotifier{}
+	m.Notifier = notifier
+	m.flatline = newFlatlineDetector(5, 0, 0)
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+
+	// A short zero run does not alert
+	m.checkFlatline(context.Background(), readings(start, 4, 0))
+	if calls := notifier.Calls(); len(calls) != 0 {
+		t.Fatalf("notifications after short zero run = %v, want none", calls)
+	}
+
+	// Continuing the run past the threshold alerts once
+	m.checkFlatline(context.Background(), readings(start.Add(40*time.Second), 10, 0))
+	calls := notifier.Calls()
+	if len(calls) != 1 || !strings.HasPrefix(calls[0], "warning|Consumption|") {
+		t.Fatalf("notifications after zero run = %v, want one consumption warning", calls)
+	}
+
+	// Recovery sends an info notification
+	m.checkFlatline(context.Background(), readings(start.Add(3*time.Minute), 1, 0.01))
+	calls = notifier.Calls()
+	if len(calls) != 2 || !strings.HasPrefix(calls[1], "info|Consumption|") {
+		t.Fatalf("notifications after recovery = %v, want consumption info", calls)
+	}
+}
+
+func TestMonitor_CheckFlatlineWithinBatch(t *testing.T) {
+	m := newTestMonitor(t)
+	notifier := &recordingNotifier{}
+	m.Notifier = notifier
+	m.flatline = newFlatlineDetector(5, 0, 0)
+
+	// A flatline that starts and ends within one batch was never warned of, so its end
+	// is not announced either
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	batch := append(readings(start, 10, 0), readings(start.Add(100*time.Second), 1, 0.01)...)
+	m.checkFlatline(context.Background(), batch)
+	if calls := notifier.Calls(); len(calls) != 0 {
+		t.Fatalf("notifications after a flatline within one batch = %v, want none", calls)
+	}
+
+	// A later flatline spanning batches still warns and recovers
+	m.checkFlatline(context.Background(), readings(start.Add(2*time.Minute), 5, 0))
+	m.checkFlatline(context.Background(), readings(start.Add(3*time.Minute), 1, 0.01))
+	calls := notifier.Calls()
+	if len(calls) != 2 || !strings.HasPrefix(calls[0], "warning|Consumption|") || !strings.HasPrefix(calls[1], "info|Consumption|") {
+		t.Errorf("notifications after a flatline across batches = %v, want a warning then info", calls)
+	}
+}
+
+func TestFlatlineDetector_ActiveHours(t *testing.T) {
+	d := newFlatlineDetector(3, 7, 23)
+
+	// Zero consumption overnight is expected
+	night := time.Date(2024, 1, 1, 2, 0, 0, 0, time.Local)
+	if started, _ := d.observe(readings(night, 10, 0)); started {
+		t.Error("flatline alerted outside active hours")
+	}
+
+	day := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	if started, _ := d.observe(readings(day, 3, 0)); !started {
+		t.Error("flatline not detected during active hours")
+	}
+}
+
+func TestFlatlineDetector_WrappingWindow(t *testing.T) {
+	d := newFlatlineDetector(1, 22, 6)
+
+	tests := []struct {
+		hour int
+		want bool
+	}{
+		{hour: 23, want: true},
+		{hour: 3, want: true},
+		{hour: 6, want: false},
+		{hour: 12, want: false},
+	}
+
+	for _, tt := range tests {
+		at := time.Date(2024, 1, 1, tt.hour, 0, 0, 0, time.Local)
+		if got := d.inActiveHours(at); got != tt.want {
+			t.Errorf("inActiveHours(%02d:00) = %v, want %v", tt.hour, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/soothill/octopus-home-mini/pkg/cache"
+	"github.com/soothill/octopus-home-mini/pkg/notify"
+)
+
+// catchUp tracks a catch-up in progress: a cache backlog of at least CatchUpThreshold
+// points synced in paced chunks, with alerts for interrupted chunks withheld until it is
+// drained. Write failures and connection changes outside the chunks still alert.
+type catchUp struct {
+	started    time.Time
+	backlog    int // Cached points when catch-up began
+	synced     int
+	suppressed int // Routine alerts withheld
+}
+
+// startCatchUp enters catch-up mode when backlog cached points reach CatchUpThreshold,
+// sending a single alert in place of one per interrupted chunk. It reports whether
+// catch-up mode is active.
+func (m *Monitor) startCatchUp(ctx context.Context, backlog int) bool {
+	if m.catchUp != nil {
+		return true
+	}
+	if m.Cfg.CatchUpThreshold <= 0 || backlog < m.Cfg.CatchUpThreshold {
+		return false
+	}
+
+	m.catchUp = &catchUp{started: time.Now(), backlog: backlog}
+	loggerFrom(ctx).Info().
+		Int("backlog", backlog).
+		Int("chunk_size", m.Cfg.CatchUpChunkSize).
+		Dur("pause", m.Cfg.CatchUpPause).
+		Msg("Entering catch-up mode")
+	m.NotifyInfo("Catch-Up", fmt.Sprintf("Catching up %d cached data points in chunks of %d. Alerts for interrupted chunks are paused until the backlog is synced.",
+		backlog, m.Cfg.CatchUpChunkSize))
+	return true
+}
+
+// suppressRoutineAlert reports whether a catch-up sync alert should be withheld because
+// a catch-up is in progress, counting it for the finish summary and the notifier metrics.
+// Only the catch-up's own chunks use it, so an outage during a catch-up is still reported.
+func (m *Monitor) suppressRoutineAlert() bool {
+	if m.catchUp == nil {
+		return false
+	}
+	m.catchUp.suppressed++
+	if metered, ok := m.Notifier.(*notify.Metered); ok {
+		metered.RecordSuppressed()
+	}
+	return true
+}
+
+// finishCatchUp leaves catch-up mode once the cache is drained, sending the summary alert
+func (m *Monitor) finishCatchUp(ctx context.Context) {
+	if m.catchUp == nil || m.Cache.Count() > 0 {
+		return
+	}
+
+	c := m.catchUp
+	m.catchUp = nil
+	elapsed := time.Since(c.started).Round(time.Second)
+	loggerFrom(ctx).Info().
+		Int("backlog", c.backlog).
+		Int("synced", c.synced).
+		Int("suppressed_alerts", c.suppressed).
+		Dur("elapsed", elapsed).
+		Msg("Catch-up complete, leaving catch-up mode")
+	m.NotifyInfo("Catch-Up", fmt.Sprintf("Caught up: synced %d cached data points to InfluxDB in %s (%d routine alerts withheld)",
+		c.synced, elapsed, c.suppressed))
+}
+
+// syncCatchUp writes cachedData to InfluxDB in chunks of CatchUpChunkSize, pausing
+// CatchUpPause between them. Each chunk is pruned from the cache once written, so an
+// interrupted catch-up resumes where it stopped on the next sync.
+func (m *Monitor) syncCatchUp(ctx context.Context, snap cache.Snapshot, cachedData []cache.DataPoint) {
+	logger := loggerFrom(ctx)
+	ctx, finish := m.startSync(ctx, "catch_up")
+	synced := 0
+	var syncErr error
+	defer func() { finish(synced, len(cachedData)-synced, syncErr) }()
+
+	// The catch-up gets its own timeout rather than the remainder of the caller's
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.Cfg.CacheSyncTimeout)
+	defer cancel()
+
+	for synced < len(cachedData) {
+		if synced > 0 && m.Cfg.CatchUpPause > 0 {
+			select {
+			case <-ctx.Done():
+				syncErr = ctx.Err()
+			case <-time.After(m.Cfg.CatchUpPause):
+			}
+		}
+
+		remaining := cachedData[synced:]
+		chunk := remaining[:batchEnd(remaining, m.Cfg.CatchUpChunkSize)]
+		if syncErr == nil {
+			syncErr = m.writeCatchUpChunk(ctx, snap, chunk)
+		}
+		if syncErr != nil {
+			m.alertFieldTypeConflict(ctx, syncErr)
+			logger.Warn().
+				Err(syncErr).
+				Int("synced", synced).
+				Int("remaining", m.Cache.Count()).
+				Msg("Catch-up interrupted, resuming on the next sync")
+			m.suppressRoutineAlert()
+			return
+		}
+
+		synced += len(chunk)
+		m.catchUp.synced += len(chunk)
+		logger.Info().
+			Int("synced", synced).
+			Int("remaining", m.Cache.Count()).
+			Msg("Synced catch-up chunk")
+	}
+
+	m.finishCatchUp(ctx)
+}
+
+// writeCatchUpChunk writes one catch-up chunk to InfluxDB, verifying it when
+// VerifyCacheSync is set, and prunes it from the cache
+func (m *Monitor) writeCatchUpChunk(ctx context.Context, snap cache.Snapshot, chunk []cache.DataPoint) error {
+	droppedBefore := m.InfluxClient.DroppedPointCount()
+	if _, err := m.writePoints(ctx, cachedInfluxPoints(chunk)); err != nil {
+		m.recordError(ComponentInfluxDB, err)
+		return err
+	}
+	m.InfluxClient.Flush()
+
+	if m.verifiesSync() {
+		dropped := int(m.InfluxClient.DroppedPointCount() - droppedBefore)
+		if err := m.verifySync(ctx, chunk, dropped); err != nil {
+			m.recordError(ComponentInfluxDB, err)
+			return err
+		}
+	}
+	m.recordWrittenCached(chunk)
+
+	from, to := timeRange(chunk)
+	if _, err := m.Cache.PruneSnapshot(snap, from, to); err != nil {
+		m.recordError(ComponentCache, err)
+		return fmt.Errorf("failed to prune synced points from cache: %w", err)
+	}
+	m.observeCacheBacklog("cached data synced to InfluxDB")
+	return nil
+}
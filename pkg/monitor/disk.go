@@ -0,0 +1,65 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/soothill/octopus-home-mini/pkg/cache"
+)
+
+const bytesPerMB = 1024 * 1024
+
+// freeDiskSpace is the default free space probe; tests replace Monitor.freeDiskSpace
+var freeDiskSpace = cache.FreeDiskSpace
+
+// hasCacheSpace reports whether the cache filesystem has at least CacheMinFreeDiskMB free,
+// removing the oldest cache files first when CacheDropOldestOnLowDisk is set. It notifies
+// when caching halts and when it resumes. If free space cannot be measured, caching goes ahead.
+func (m *Monitor) hasCacheSpace(ctx context.Context) bool {
+	logger := loggerFrom(ctx)
+	if m.Cfg.CacheMinFreeDiskMB <= 0 {
+		return true
+	}
+	minFree := uint64(m.Cfg.CacheMinFreeDiskMB) * bytesPerMB
+
+	free, err := m.freeDiskSpace(m.Cache.Dir())
+	if err != nil {
+		logger.Warn().Err(err).Msg("Could not measure free disk space, caching anyway")
+		return true
+	}
+
+	for free < minFree && m.Cfg.CacheDropOldestOnLowDisk {
+		dropped, err := m.Cache.DropOldestFile()
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to drop oldest cache file")
+			m.recordError(ComponentCache, err)
+			break
+		}
+		if dropped == "" {
+			break
+		}
+		logger.Warn().Str("file", dropped).Msg("Dropped oldest cache file to free disk space")
+
+		if free, err = m.freeDiskSpace(m.Cache.Dir()); err != nil {
+			return true
+		}
+	}
+
+	if free < minFree {
+		if !m.diskLow {
+			m.diskLow = true
+			err := fmt.Errorf("only %d MB free, below the %d MB minimum", free/bytesPerMB, m.Cfg.CacheMinFreeDiskMB)
+			logger.Error().Err(err).Msg("Halting caching: disk nearly full")
+			m.recordError(ComponentCache, err)
+			m.NotifyError("Cache", fmt.Sprintf("Caching halted: %v. New data is being discarded until space is freed.", err))
+		}
+		return false
+	}
+
+	if m.diskLow {
+		m.diskLow = false
+		logger.Info().Uint64("free_mb", free/bytesPerMB).Msg("Disk space recovered, resuming caching")
+		m.NotifyInfo("Cache", "Disk space recovered, resuming caching")
+	}
+	return true
+}
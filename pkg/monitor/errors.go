@@ -0,0 +1,45 @@
+package monitor
+
+import "time"
+
+// Components reported by LastErrors
+const (
+	ComponentOctopus  = "octopus"
+	ComponentInfluxDB = "influxdb"
+	ComponentCache    = "cache"
+	ComponentParquet  = "parquet"
+	ComponentSlack    = "slack"
+	ComponentWeather  = "weather"
+)
+
+// ComponentError is the most recent error recorded for a component
+type ComponentError struct {
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// recordError stores err as the latest error for component, redacting credentials
+func (m *Monitor) recordError(component string, err error) {
+	if err == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastErrors[component] = ComponentError{
+		Message: sanitizeError(err),
+		Time:    time.Now().UTC(),
+	}
+}
+
+// LastErrors returns a copy of the most recent error recorded per component
+func (m *Monitor) LastErrors() map[string]ComponentError {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[string]ComponentError, len(m.lastErrors))
+	for component, lastErr := range m.lastErrors {
+		result[component] = lastErr
+	}
+	return result
+}
@@ -0,0 +1,37 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/soothill/octopus-home-mini/pkg/influx"
+)
+
+// alertFieldTypeConflict reports whether err is an InfluxDB field type conflict and, if
+// so, alerts once per conflicting field. The rejected points stay cached, but they will
+// keep failing until an operator resolves the conflict, so the alert is never withheld.
+func (m *Monitor) alertFieldTypeConflict(ctx context.Context, err error) bool {
+	var conflict *influx.FieldTypeConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+
+	loggerFrom(ctx).Error().
+		Err(err).
+		Str("measurement", conflict.Measurement).
+		Str("field", conflict.Field).
+		Str("type", conflict.Type).
+		Str("existing_type", conflict.ExistingType).
+		Msg("InfluxDB rejected a write with a field type conflict")
+
+	key := conflict.Measurement + "." + conflict.Field
+	if m.fieldConflict == key {
+		return true
+	}
+	m.fieldConflict = key
+	m.NotifyError("InfluxDB Schema", fmt.Sprintf("Field %q on measurement %q is written as %s but InfluxDB already stores it as %s, so writes are rejected. "+
+		"Data is cached locally until the conflict is resolved, e.g. by deleting the conflicting data or writing to a new measurement or bucket.",
+		conflict.Field, conflict.Measurement, conflict.Type, conflict.ExistingType))
+	return true
+}
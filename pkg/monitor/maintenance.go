@@ -0,0 +1,79 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// inMaintenance reports whether the last poll found the Octopus API down for maintenance
+func (m *Monitor) inMaintenance() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return !m.maintenanceSince.IsZero()
+}
+
+// maintenanceInterval lengthens interval to MaintenancePollInterval while the Octopus
+// API is down for maintenance
+func (m *Monitor) maintenanceInterval(interval time.Duration) time.Duration {
+	if m.inMaintenance() && m.Cfg.MaintenancePollInterval > interval {
+		return m.Cfg.MaintenancePollInterval
+	}
+	return interval
+}
+
+// handleMaintenance records a poll that found the Octopus API down for maintenance.
+// Maintenance ends by itself, so rather than counting towards degraded mode it slows
+// polling to MaintenancePollInterval and alerts only once it has lasted
+// MaintenanceAlertAfter.
+func (m *Monitor) handleMaintenance(logger zerolog.Logger, err error, now time.Time) {
+	m.recordError(ComponentOctopus, err)
+
+	m.mu.Lock()
+	since := m.maintenanceSince
+	if since.IsZero() {
+		m.maintenanceSince = now
+	}
+	m.mu.Unlock()
+
+	if since.IsZero() {
+		since = now
+		m.logTransition(transitionMaintenance, "normal", "maintenance", sanitizeError(err))
+		logger.Warn().
+			Err(err).
+			Dur("new_interval", m.maintenanceInterval(m.pollInterval())).
+			Msg("Octopus API down for maintenance, polling less often")
+	} else {
+		logger.Info().
+			Dur("maintenance_for", now.Sub(since).Round(time.Second)).
+			Msg("Octopus API still down for maintenance")
+	}
+
+	if !m.maintenanceAlerted && now.Sub(since) >= m.Cfg.MaintenanceAlertAfter {
+		m.maintenanceAlerted = true
+		m.NotifyWarning("Octopus API", fmt.Sprintf("Octopus API down for maintenance for %s; polling every %s until it returns",
+			now.Sub(since).Round(time.Second), m.maintenanceInterval(m.pollInterval())))
+	}
+}
+
+// endMaintenance leaves maintenance mode once the Octopus API answers normally again
+func (m *Monitor) endMaintenance(logger zerolog.Logger, now time.Time) {
+	m.mu.Lock()
+	since := m.maintenanceSince
+	m.maintenanceSince = time.Time{}
+	m.mu.Unlock()
+	if since.IsZero() {
+		return
+	}
+
+	lasted := now.Sub(since).Round(time.Second)
+	m.logTransition(transitionMaintenance, "maintenance", "normal", fmt.Sprintf("telemetry fetched after %s of maintenance", lasted))
+	if m.maintenanceAlerted {
+		m.maintenanceAlerted = false
+		m.NotifyInfo("Octopus API", fmt.Sprintf("Maintenance over after %s - resuming normal polling", lasted))
+	}
+	logger.Info().
+		Dur("maintenance_for", lasted).
+		Msg("Octopus API back from maintenance - resuming normal polling interval")
+}
@@ -0,0 +1,82 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/soothill/octopus-home-mini/pkg/cache"
+)
+
+// memoryBuffer holds points in memory while the cache directory is unwritable,
+// dropping the oldest once it reaches its limit
+type memoryBuffer struct {
+	points  []cache.DataPoint
+	limit   int
+	dropped int // Points discarded to stay within limit
+}
+
+func (b *memoryBuffer) add(points []cache.DataPoint) {
+	b.points = append(b.points, points...)
+	if over := len(b.points) - b.limit; over > 0 {
+		b.points = append([]cache.DataPoint(nil), b.points[over:]...)
+		b.dropped += over
+	}
+}
+
+// bufferInMemory keeps dataPoints in the memory buffer while the cache directory stays
+// unwritable, reporting whether it did. Once the directory is writable again the buffer
+// is handed back for writing ahead of dataPoints and the fallback ends.
+func (m *Monitor) bufferInMemory(ctx context.Context, dataPoints []cache.DataPoint) ([]cache.DataPoint, bool) {
+	if m.memBuffer == nil {
+		return dataPoints, false
+	}
+
+	logger := loggerFrom(ctx)
+	if err := m.Cache.Writable(); err != nil {
+		dropped := m.memBuffer.dropped
+		m.memBuffer.add(dataPoints)
+		if m.memBuffer.dropped > dropped {
+			logger.Warn().
+				Int("dropped", m.memBuffer.dropped-dropped).
+				Int("limit", m.memBuffer.limit).
+				Msg("In-memory cache fallback full, dropping oldest points")
+		}
+		logger.Info().
+			Int("count", len(dataPoints)).
+			Int("buffered", len(m.memBuffer.points)).
+			Msg("Cache directory still unwritable, buffered data points in memory")
+		return nil, true
+	}
+
+	buffered := m.memBuffer
+	m.memBuffer = nil
+	m.cacheWriteFailures = 0
+	logger.Info().
+		Int("buffered", len(buffered.points)).
+		Int("dropped", buffered.dropped).
+		Msg("Cache directory writable again, flushing in-memory buffer")
+	m.NotifyInfo("Cache", fmt.Sprintf("Cache directory writable again, flushing %d points buffered in memory (%d dropped)",
+		len(buffered.points), buffered.dropped))
+
+	return append(buffered.points, dataPoints...), false
+}
+
+// countCacheFailure records a failed cache write and, once CacheFailureThreshold writes
+// in a row have failed, switches to the in-memory fallback. It reports whether it did,
+// so the caller can skip its per-failure alert.
+func (m *Monitor) countCacheFailure(ctx context.Context, err error) bool {
+	m.cacheWriteFailures++
+	if m.Cfg.CacheFailureThreshold <= 0 || m.cacheWriteFailures < m.Cfg.CacheFailureThreshold {
+		return false
+	}
+
+	m.memBuffer = &memoryBuffer{limit: m.Cfg.CacheMemoryBufferPoints}
+	loggerFrom(ctx).Error().
+		Err(err).
+		Int("failures", m.cacheWriteFailures).
+		Int("limit", m.Cfg.CacheMemoryBufferPoints).
+		Msg("Cache writes keep failing, buffering data points in memory")
+	m.NotifyError("Cache", fmt.Sprintf("%d consecutive cache writes failed: %v. Buffering up to %d points in memory until the cache directory is writable again.",
+		m.cacheWriteFailures, err, m.Cfg.CacheMemoryBufferPoints))
+	return true
+}
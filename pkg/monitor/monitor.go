@@ -2,19 +2,27 @@ package monitor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/soothill/octopus-home-mini/pkg/cache"
 	"github.com/soothill/octopus-home-mini/pkg/config"
 	"github.com/soothill/octopus-home-mini/pkg/influx"
+	"github.com/soothill/octopus-home-mini/pkg/notify"
 	"github.com/soothill/octopus-home-mini/pkg/octopus"
-	"github.com/soothill/octopus-home-mini/pkg/slack"
+	"github.com/soothill/octopus-home-mini/pkg/parquetsink"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Monitor handles the main monitoring loop
@@ -22,71 +30,218 @@ type Monitor struct {
 	Cfg           *config.Config
 	OctopusClient *octopus.Client
 	InfluxClient  *influx.Client
+	ParquetSink   *parquetsink.Sink // Set instead of InfluxClient when SINK=parquet
 	Cache         *cache.Cache
-	SlackNotifier *slack.Notifier // May be nil if Slack is disabled
-	LastPollTime  time.Time
+	Notifier      notify.Notifier // notify.Nop when notifications are disabled
+
+	startedAt time.Time // Start of the DegradedGracePeriod window
 
 	// Fields accessed from multiple goroutines - protected by mu
-	mu             sync.RWMutex
-	influxHealthy  bool
-	consecutiveErr int
-	degradedMode   bool // True when system is operating in degraded mode
-	backoffFactor  int  // Multiplier for poll interval when in degraded mode
+	mu               sync.RWMutex
+	lastPollTime     time.Time
+	lastWriteTime    time.Time // Last time data was written to InfluxDB or the cache
+	lastEmptyPoll    time.Time // Last successful poll that had no new data to write
+	pollCount        int
+	influxHealthy    bool
+	consecutiveErr   int
+	asyncWriteErr    int  // Consecutive async InfluxDB write failures
+	degradedMode     bool // True when system is operating in degraded mode
+	backoffFactor    int  // Multiplier for poll interval when in degraded mode
+	lastErrors       map[string]ComponentError
+	stopping         bool                 // Set by Drain; no new polls or cleanups start afterwards
+	watermarkStore   cache.WatermarkStore // nil unless SetWatermarkStore was called
+	watermark        time.Time            // Newest point stored, persisted to watermarkStore
+	cacheBacklog     bool                 // True while the cache holds points, for the transition log
+	maintenanceSince time.Time            // Zero unless the Octopus API is down for maintenance
+	transitions      zerolog.Logger       // Transition log, a no-op unless SetTransitionLog was called
+
+	// In-progress polls and cache cleanups, waited on by Drain
+	inFlight sync.WaitGroup
+	drained  chan struct{} // Closed by Drain to cut short write retries
+
+	syncStats cacheSyncRecorder // Guarded by its own lock
+	written   *writeTotals      // Guarded by its own lock
+
+	// Only used from the polling goroutine
+	flatline      *flatlineDetector // nil when flatline detection is disabled
+	tuner         *intervalTuner    // nil unless AdaptivePollInterval is set
+	sessions      *savingSessions   // nil unless SavingSessionsEnabled is set
+	catchUp       *catchUp          // nil unless a catch-up is in progress
+	degraded      *degradedPeriod   // nil unless in degraded mode
+	onboarding    *onboarding       // nil unless SetOnboardingStore was called
+	freeDiskSpace func(dir string) (uint64, error)
+	diskLow       bool   // True while caching is halted for lack of disk space
+	fieldConflict string // Measurement and field of the last alerted field type conflict
+	// True once the current Octopus API maintenance has been alerted
+	maintenanceAlerted bool
+	// Consecutive failed cache writes, and the in-memory fallback they engage (nil when off)
+	cacheWriteFailures int
+	memBuffer          *memoryBuffer
+
+	tracer trace.Tracer // From the global provider, a no-op unless tracing is enabled
 }
 
-func New(cfg *config.Config, octopusClient *octopus.Client, influxClient *influx.Client, cache *cache.Cache, slackNotifier *slack.Notifier) *Monitor {
-	return &Monitor{
+func New(cfg *config.Config, octopusClient *octopus.Client, influxClient *influx.Client, cache *cache.Cache, notifier notify.Notifier) *Monitor {
+	if notifier == nil {
+		notifier = notify.Nop{}
+	}
+
+	m := &Monitor{
 		Cfg:           cfg,
 		OctopusClient: octopusClient,
 		InfluxClient:  influxClient,
 		Cache:         cache,
-		SlackNotifier: slackNotifier,
-		LastPollTime:  time.Now().Add(-cfg.PollInterval),
+		Notifier:      notifier,
+		lastPollTime:  time.Now().Add(-cfg.PollInterval),
+		lastWriteTime: time.Now(),
 		influxHealthy: influxClient != nil,
 		degradedMode:  false,
 		backoffFactor: 1,
+		lastErrors:    make(map[string]ComponentError),
+		freeDiskSpace: freeDiskSpace,
+		written:       newWriteTotals(time.Now()),
+		startedAt:     time.Now(),
+		drained:       make(chan struct{}),
+		transitions:   zerolog.Nop(),
+		tracer:        otel.Tracer(tracerName),
+	}
+	if cache != nil {
+		m.cacheBacklog = cache.Count() > 0
+	}
+
+	if cfg.FlatlineThreshold > 0 {
+		m.flatline = newFlatlineDetector(cfg.FlatlineThreshold, cfg.FlatlineActiveStartHour, cfg.FlatlineActiveEndHour)
+	}
+	if cfg.AdaptivePollInterval {
+		m.tuner = newIntervalTuner(cfg.PollInterval, cfg.AdaptivePollMin, cfg.AdaptivePollMax)
+	}
+	if cfg.SavingSessionsEnabled {
+		m.sessions = newSavingSessions()
+	}
+
+	if influxClient != nil {
+		influxClient.SetAsyncFailureHandler(m.handleAsyncWriteFailure)
+		influxClient.SetFailedBatchHandler(m.cacheFailedBatch)
+	}
+	if octopusClient != nil {
+		octopusClient.SetBreakerStateHandler(m.handleBreakerStateChange)
 	}
+
+	return m
 }
 
-// SendSlackError sends an error notification to Slack if enabled
-func (m *Monitor) SendSlackError(component, message string) {
-	if m.SlackNotifier != nil {
-		if err := m.SlackNotifier.SendError(component, message); err != nil {
-			log.Error().Err(err).Msg("Error sending Slack error notification")
-		}
+// NotifyError sends an error notification, logging delivery failures
+func (m *Monitor) NotifyError(component, message string) {
+	if err := m.Notifier.SendError(component, message); err != nil {
+		log.Error().Err(err).Msg("Error sending error notification")
+		m.recordError(ComponentSlack, err)
 	}
 }
 
-// SendSlackWarning sends a warning notification to Slack if enabled
-func (m *Monitor) SendSlackWarning(component, message string) {
-	if m.SlackNotifier != nil {
-		if err := m.SlackNotifier.SendWarning(component, message); err != nil {
-			log.Error().Err(err).Msg("Error sending Slack warning notification")
-		}
+// NotifyWarning sends a warning notification, logging delivery failures
+func (m *Monitor) NotifyWarning(component, message string) {
+	if err := m.Notifier.SendWarning(component, message); err != nil {
+		log.Error().Err(err).Msg("Error sending warning notification")
+		m.recordError(ComponentSlack, err)
 	}
 }
 
-// SendSlackInfo sends an info notification to Slack if enabled
-func (m *Monitor) SendSlackInfo(title, message string) {
-	if m.SlackNotifier != nil {
-		if err := m.SlackNotifier.SendInfo(title, message); err != nil {
-			log.Error().Err(err).Msg("Error sending Slack info notification")
-		}
+// NotifyInfo sends an info notification, logging delivery failures
+func (m *Monitor) NotifyInfo(title, message string) {
+	if err := m.Notifier.SendInfo(title, message); err != nil {
+		log.Error().Err(err).Msg("Error sending info notification")
+		m.recordError(ComponentSlack, err)
 	}
 }
 
+// SendTestAlert sends a test notification so alert delivery can be checked end to end,
+// returning the delivery error with secrets redacted
+func (m *Monitor) SendTestAlert() error {
+	if !m.Cfg.SlackEnabled {
+		return errors.New("notifications are disabled; set SLACK_WEBHOOK_URL to enable them")
+	}
+	if err := m.Notifier.SendInfo("Test Alert", "Test notification from Octopus Home Mini Monitor. Alerts are being delivered."); err != nil {
+		log.Error().Err(err).Msg("Error sending test notification")
+		m.recordError(ComponentSlack, err)
+		return errors.New(sanitizeError(err))
+	}
+	return nil
+}
+
 // Thread-safe accessors for concurrent fields
 
-func (m *Monitor) getInfluxHealthy() bool {
+// LastPollTime returns the end of the most recent successful poll window
+func (m *Monitor) LastPollTime() time.Time {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	return m.influxHealthy
+	return m.lastPollTime
 }
 
-func (m *Monitor) setInfluxHealthy(healthy bool) {
+func (m *Monitor) setLastPollTime(t time.Time) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.influxHealthy = healthy
+	m.lastPollTime = t
+}
+
+// LastWriteTime returns the last time data was successfully written to InfluxDB or the cache
+func (m *Monitor) LastWriteTime() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastWriteTime
+}
+
+func (m *Monitor) setLastWriteTime(t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastWriteTime = t
+}
+
+func (m *Monitor) setLastEmptyPoll(t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastEmptyPoll = t
+}
+
+// DataStaleness reports how long it has been since data was last written and whether
+// that exceeds maxStaleness. A successful poll with nothing new to write counts as fresh,
+// so a quiet period with no readings is not mistaken for a wedged monitor. The limit is
+// widened to two effective poll intervals so that degraded-mode backoff and maintenance
+// polling do not trip it on their own.
+func (m *Monitor) DataStaleness(now time.Time, maxStaleness time.Duration) (time.Duration, bool) {
+	m.mu.RLock()
+	fresh := m.lastWriteTime
+	if m.lastEmptyPoll.After(fresh) {
+		fresh = m.lastEmptyPoll
+	}
+	m.mu.RUnlock()
+	staleness := now.Sub(fresh)
+
+	limit := maxStaleness
+	if effectiveInterval := m.maintenanceInterval(m.Cfg.PollInterval * time.Duration(m.getBackoffFactor())); 2*effectiveInterval > limit {
+		limit = 2 * effectiveInterval
+	}
+
+	return staleness, staleness > limit
+}
+
+// routineLogger returns the logger for routine per-poll messages. Only every
+// LogSampleEveryN-th poll logs them to base; errors and state changes always use base.
+func (m *Monitor) routineLogger(base zerolog.Logger) zerolog.Logger {
+	m.mu.Lock()
+	m.pollCount++
+	count := m.pollCount
+	m.mu.Unlock()
+
+	if n := m.Cfg.LogSampleEveryN; n > 1 && (count-1)%n != 0 {
+		return zerolog.Nop()
+	}
+	return base
+}
+
+func (m *Monitor) getInfluxHealthy() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.influxHealthy
 }
 
 func (m *Monitor) getConsecutiveErr() int {
@@ -107,6 +262,12 @@ func (m *Monitor) resetConsecutiveErr() {
 	m.consecutiveErr = 0
 }
 
+// degradedPeriod tracks a spell in degraded mode for the recovery summary
+type degradedPeriod struct {
+	since  time.Time
+	errors int // Octopus API errors, including those that triggered degraded mode
+}
+
 func (m *Monitor) getDegradedMode() bool {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -174,21 +335,23 @@ func sanitizeError(err error) string {
 
 // Run executes the main monitoring loop with adaptive polling
 func (m *Monitor) Run(stopChan chan struct{}) {
-	ticker := time.NewTicker(m.Cfg.PollInterval)
+	ticker := time.NewTicker(m.nextPollDelay(m.pollInterval()))
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			m.poll()
+			if !m.runTracked(m.poll) {
+				return
+			}
 
 			// Adjust poll interval based on degraded mode
-			backoff := m.getBackoffFactor()
-			if backoff > 1 {
-				ticker.Reset(m.Cfg.PollInterval * time.Duration(backoff))
-			} else {
-				ticker.Reset(m.Cfg.PollInterval)
+			interval := m.pollInterval()
+			if backoff := m.getBackoffFactor(); backoff > 1 {
+				interval *= time.Duration(backoff)
 			}
+			interval = m.maintenanceInterval(interval)
+			ticker.Reset(m.nextPollDelay(interval))
 
 		case <-stopChan:
 			return
@@ -196,35 +359,163 @@ func (m *Monitor) Run(stopChan chan struct{}) {
 	}
 }
 
+// nextPollDelay returns how long to wait before the next poll. With AlignPolls the wait
+// runs to the next wall-clock multiple of interval, so polls stay on boundaries such as
+// :00 and :30 even as the interval changes; otherwise it is the interval itself.
+func (m *Monitor) nextPollDelay(interval time.Duration) time.Duration {
+	if !m.Cfg.AlignPolls {
+		return interval
+	}
+	return alignDelay(time.Now(), interval)
+}
+
+// alignDelay returns the time from now until the next multiple of interval since the Unix epoch
+func alignDelay(now time.Time, interval time.Duration) time.Duration {
+	elapsed := time.Duration(now.UnixNano() % int64(interval))
+	return interval - elapsed
+}
+
+// runTracked runs fn as in-flight work that Drain waits for. It returns false without
+// running fn once Drain has been called.
+func (m *Monitor) runTracked(fn func()) bool {
+	m.mu.Lock()
+	if m.stopping {
+		m.mu.Unlock()
+		return false
+	}
+	m.inFlight.Add(1)
+	m.mu.Unlock()
+
+	defer m.inFlight.Done()
+	fn()
+	return true
+}
+
+// Drain stops new polls and cache cleanups from starting and waits up to timeout
+// for any in progress to finish. It returns false if the timeout elapsed first.
+func (m *Monitor) Drain(timeout time.Duration) bool {
+	m.mu.Lock()
+	if !m.stopping && m.drained != nil {
+		close(m.drained)
+	}
+	m.stopping = true
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// pollRange returns the window to query, ending at now unless MaxPollWindow caps it.
+// If the host clock has jumped backward (e.g. an NTP correction) the last poll time
+// lies in the future, which would make the window negative; the window and the last
+// poll time are clamped to one poll interval instead, so the jump is alerted once
+// even if the fetch then fails.
+func (m *Monitor) pollRange(ctx context.Context, now time.Time) (time.Time, time.Time) {
+	start := m.LastPollTime()
+
+	// Compare wall clock readings: the API is queried by wall time, and monotonic
+	// readings would hide the jump
+	if skew := start.Round(0).Sub(now.Round(0)); skew > 0 {
+		clamped := now.Add(-m.Cfg.PollInterval)
+		loggerFrom(ctx).Warn().
+			Dur("skew", skew).
+			Time("last_poll", start).
+			Time("start", clamped).
+			Msg("System clock moved backward, clamping poll range")
+		m.NotifyWarning("Clock", fmt.Sprintf("System clock moved backward by %s; polling the last %s instead", skew.Round(time.Second), m.Cfg.PollInterval))
+		m.setLastPollTime(clamped)
+		start = clamped
+	}
+
+	// Cap the range so a long gap is fetched a window at a time
+	if window := m.Cfg.MaxPollWindow; window > 0 && now.Sub(start) > window {
+		return start, start.Add(window)
+	}
+
+	return start, now
+}
+
 // poll fetches and processes new energy data
 func (m *Monitor) poll() {
 	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.PollTimeout)
 	defer cancel()
 
+	// Tag every log line and audit record of this poll with the same ID
+	pollID := newCorrelationID()
+	logger := log.With().Str("poll_id", pollID).Logger()
+	ctx = octopus.WithCorrelationID(logger.WithContext(ctx), pollID)
+
+	ctx, span := m.tracer.Start(ctx, "poll", trace.WithAttributes(attribute.String("poll.id", pollID)))
+	var pollErr error
+	defer func() { endSpan(span, pollErr) }()
+
 	// Calculate time range for query
 	now := time.Now()
-	start := m.LastPollTime
-	end := now
+	start, end := m.pollRange(ctx, now)
 
-	log.Info().
+	routineLog := m.routineLogger(logger)
+	routineLog.Info().
 		Time("start", start).
 		Time("end", end).
 		Msg("Polling for telemetry data")
 
+	var timings pollTimings
+	defer timings.log(&logger, now)
+
 	// Fetch telemetry data
-	telemetryData, err := m.OctopusClient.GetTelemetry(ctx, start, end)
+	telemetryData, err := m.OctopusClient.GetTelemetry(octopus.WithTimings(ctx, &timings.octopus), start, end)
+	// The breaker may open on maintenance responses; its rejections are part of the same outage
+	if err != nil && (octopus.IsMaintenance(err) || m.inMaintenance() && octopus.IsCircuitOpen(err)) {
+		pollErr = err
+		m.handleMaintenance(logger, err, now)
+		return
+	}
+	if err != nil && m.Cfg.CircuitOpenSkipPoll && octopus.IsCircuitOpen(err) {
+		// The breaker already reflects the failures; counting its rejections would
+		// compound the backoff. The skipped window is picked up by the next poll.
+		logger.Warn().Err(err).Msg("Octopus API circuit breaker open, skipping poll")
+		m.recordError(ComponentOctopus, err)
+		if m.degraded != nil {
+			m.degraded.errors++
+		}
+		return
+	}
 	if err != nil {
+		pollErr = err
 		m.incrementConsecutiveErr()
-		log.Error().Err(err).Msg("Error fetching telemetry")
+		logger.Error().Err(err).Msg("Error fetching telemetry")
+		m.recordError(ComponentOctopus, err)
+		if m.degraded != nil {
+			m.degraded.errors++
+		}
 
 		// Enter degraded mode after consecutive error threshold
 		consecutiveErrs := m.getConsecutiveErr()
-		if consecutiveErrs >= m.Cfg.ConsecutiveErrorThreshold {
+		if consecutiveErrs >= m.Cfg.ConsecutiveErrorThreshold && time.Since(m.startedAt) < m.Cfg.DegradedGracePeriod {
+			// Startup flakiness is retried at the normal interval rather than degrading polling
+			logger.Warn().
+				Int("consecutive_errors", consecutiveErrs).
+				Dur("grace_period", m.Cfg.DegradedGracePeriod).
+				Msg("Not entering degraded mode during startup grace period")
+		} else if consecutiveErrs >= m.Cfg.ConsecutiveErrorThreshold {
 			if !m.getDegradedMode() {
 				m.setDegradedMode(true)
 				m.setBackoffFactor(2) // Double the poll interval
-				m.SendSlackError("Octopus API", fmt.Sprintf("Entering degraded mode after %d consecutive errors: %v", consecutiveErrs, sanitizeError(err)))
-				log.Warn().
+				m.degraded = &degradedPeriod{since: time.Now(), errors: consecutiveErrs}
+				m.logTransition(transitionDegradedMode, "normal", "degraded",
+					fmt.Sprintf("%d consecutive Octopus API errors: %v", consecutiveErrs, sanitizeError(err)))
+				m.NotifyError("Octopus API", fmt.Sprintf("Entering degraded mode after %d consecutive errors: %v", consecutiveErrs, sanitizeError(err)))
+				logger.Warn().
 					Int("consecutive_errors", consecutiveErrs).
 					Dur("new_interval", m.Cfg.PollInterval*2).
 					Msg("Entering degraded mode")
@@ -234,7 +525,7 @@ func (m *Monitor) poll() {
 				if currentBackoff < m.Cfg.MaxBackoffFactor {
 					m.incrementBackoffFactor()
 					newBackoff := m.getBackoffFactor()
-					log.Warn().
+					logger.Warn().
 						Int("backoff_factor", newBackoff).
 						Dur("new_interval", m.Cfg.PollInterval*time.Duration(newBackoff)).
 						Msg("Increasing backoff factor")
@@ -244,74 +535,417 @@ func (m *Monitor) poll() {
 		return
 	}
 
-	// Exit degraded mode on successful fetch
-	if m.getDegradedMode() {
+	// Exit degraded mode on successful fetch, once any gap left by the outage is caught up
+	if m.getDegradedMode() && end.Before(now) {
+		logger.Info().
+			Dur("remaining", now.Sub(end)).
+			Int("backoff_factor", m.getBackoffFactor()).
+			Msg("Catching up before leaving degraded mode")
+	} else if m.getDegradedMode() {
 		m.setDegradedMode(false)
 		m.setBackoffFactor(1)
-		m.SendSlackInfo("Octopus API", "Recovered from degraded mode - resuming normal polling")
-		log.Info().Msg("Exiting degraded mode - resuming normal polling interval")
+		period := m.degraded
+		m.degraded = nil
+		if period == nil {
+			period = &degradedPeriod{since: time.Now()}
+		}
+		lasted := time.Since(period.since).Round(time.Second)
+		m.logTransition(transitionDegradedMode, "degraded", "normal",
+			fmt.Sprintf("telemetry fetched after %s in degraded mode (%d errors)", lasted, period.errors))
+		m.NotifyInfo("Octopus API", fmt.Sprintf("Recovered from degraded mode after %s (%d errors) - resuming normal polling",
+			lasted, period.errors))
+		logger.Info().
+			Dur("degraded_for", lasted).
+			Int("errors", period.errors).
+			Msg("Exiting degraded mode - resuming normal polling interval")
 	}
 
+	m.endMaintenance(logger, now)
 	m.resetConsecutiveErr()
-	m.LastPollTime = end
+	m.setLastPollTime(end)
+	// After the readings are stored, so a slow sessions query cannot delay them
+	defer m.checkSavingSessions(ctx, now)
+
+	if m.tuner != nil {
+		previous := m.tuner.interval
+		if interval := m.tuner.observe(telemetryData); interval != previous {
+			logger.Debug().
+				Dur("previous", previous).
+				Dur("interval", interval).
+				Msg("Adjusted poll interval toward data cadence")
+		}
+	}
 
+	telemetryData = m.dropOldPoints(ctx, telemetryData, now)
+	telemetryData = m.dropSeenPoints(ctx, telemetryData)
+	span.SetAttributes(attribute.Int("points", len(telemetryData)))
 	if len(telemetryData) == 0 {
-		log.Info().Msg("No new telemetry data available")
+		m.setLastEmptyPoll(now)
+		if m.awaitingFirstData() {
+			m.checkFirstDataTimeout(routineLog, now)
+			return
+		}
+		routineLog.Info().Msg("No new telemetry data available")
 		return
 	}
+	if m.awaitingFirstData() {
+		m.recordFirstData(ctx, now)
+	}
+
+	routineLog.Info().Int("count", len(telemetryData)).Msg("Retrieved telemetry data")
 
-	log.Info().Int("count", len(telemetryData)).Msg("Retrieved telemetry data")
+	pollErr = m.storeTelemetry(ctx, telemetryData, routineLog, &timings)
+}
+
+// storeTelemetry filters and rounds telemetryData and writes it to the configured sink,
+// caching it when InfluxDB is down or rejects the write. It returns the write error, if any.
+func (m *Monitor) storeTelemetry(ctx context.Context, telemetryData []octopus.TelemetryData, routineLog zerolog.Logger, timings *pollTimings) error {
+	logger := loggerFrom(ctx)
+
+	// Filter and round once here so InfluxDB, Parquet and the cache all store identical values
+	m.zeroNoiseDeltas(telemetryData)
+	m.roundTelemetry(telemetryData)
+
+	m.checkFlatline(ctx, telemetryData)
+
+	if m.ParquetSink != nil {
+		began := time.Now()
+		_, writeSpan := m.tracer.Start(ctx, "write", trace.WithAttributes(
+			attribute.String("sink", config.SinkParquet),
+			attribute.Int("points", len(telemetryData)),
+		))
+		m.writeToParquet(ctx, telemetryData, routineLog)
+		writeSpan.End()
+		timings.write = time.Since(began)
+		return nil
+	}
 
 	// Check InfluxDB health
+	began := time.Now()
 	m.checkInfluxHealth(ctx)
+	if m.InfluxClient != nil {
+		timings.influxHealth = time.Since(began)
+	}
 
 	// Process data
+	var err error
 	if m.getInfluxHealthy() {
 		// Try to write to InfluxDB
-		if err := m.writeToInflux(telemetryData); err != nil {
-			log.Error().Err(err).Msg("Failed to write to InfluxDB")
-			m.setInfluxHealthy(false)
-			m.SendSlackError("InfluxDB", fmt.Sprintf("Failed to write data: %v. Switching to cache mode.", sanitizeError(err)))
+		inline, deferred := m.splitBatch(telemetryData)
+		began = time.Now()
+		_, writeSpan := m.tracer.Start(ctx, "write", trace.WithAttributes(
+			attribute.String("sink", config.SinkInfluxDB),
+			attribute.Int("points", len(inline)),
+		))
+		err = m.writeToInflux(inline)
+		if err != nil {
+			err = m.retryWrite(ctx, inline, err)
+		}
+		endSpan(writeSpan, err)
+		timings.write = time.Since(began)
+		if err != nil {
+			if m.alertFieldTypeConflict(ctx, err) {
+				// InfluxDB is reachable but rejects the schema - cache without switching to cache mode
+				m.recordError(ComponentInfluxDB, err)
+				m.cacheData(ctx, telemetryData)
+				return err
+			}
+			if influx.IsBackpressure(err) {
+				// InfluxDB is overloaded but reachable - cache without switching to cache mode
+				logger.Warn().Err(err).Msg("InfluxDB backpressure, caching data until writes resume")
+				m.recordError(ComponentInfluxDB, err)
+				m.cacheData(ctx, telemetryData)
+				return err
+			}
+
+			logger.Error().Err(err).Msg("Failed to write to InfluxDB")
+			m.recordError(ComponentInfluxDB, err)
+			m.updateInfluxHealth(false, fmt.Sprintf("write failed: %v", sanitizeError(err)))
+			m.InfluxClient.ExpireHealthCache()
+			m.NotifyError("InfluxDB", fmt.Sprintf("Failed to write data: %v. Switching to cache mode.", sanitizeError(err)))
 
 			// Cache the data instead
-			m.cacheData(telemetryData)
+			m.cacheData(ctx, telemetryData)
 		} else {
-			log.Info().Int("count", len(telemetryData)).Msg("Successfully wrote data points to InfluxDB")
+			m.fieldConflict = ""
+			m.resetAsyncWriteErr()
+			m.setLastWriteTime(time.Now())
+			m.recordWrittenTelemetry(inline)
+			m.advanceWatermark(ctx, inline)
+			routineLog.Info().Int("count", len(inline)).Msg("Successfully wrote data points to InfluxDB")
+
+			if len(deferred) > 0 {
+				// Keep the poll bounded; the remainder is synced from the cache on later polls
+				logger.Info().
+					Int("deferred", len(deferred)).
+					Int("max_points_per_poll", m.Cfg.MaxPointsPerPoll).
+					Msg("Batch exceeds per-poll limit, caching remainder")
+				m.cacheData(ctx, deferred)
+			} else if m.catchUp != nil {
+				// Carry on with an interrupted catch-up rather than draining it batch by batch
+				m.syncCache(ctx)
+			} else {
+				m.syncCacheBatch(ctx, m.cacheSyncLimit(len(inline)))
+			}
 		}
 	} else {
 		// InfluxDB is down, cache the data
-		m.cacheData(telemetryData)
+		m.cacheData(ctx, telemetryData)
 
 		// Periodically try to reconnect
 		m.tryReconnectInflux(ctx)
 	}
+	return err
 }
 
-// writeToInflux writes telemetry data to InfluxDB
+// dropOldPoints removes points read more than MaxPointAge before now, such as
+// backfilled corrections, so they are neither written nor cached
+func (m *Monitor) dropOldPoints(ctx context.Context, telemetryData []octopus.TelemetryData, now time.Time) []octopus.TelemetryData {
+	if m.Cfg.MaxPointAge <= 0 {
+		return telemetryData
+	}
+
+	cutoff := now.Add(-m.Cfg.MaxPointAge)
+	kept := telemetryData[:0]
+	for _, data := range telemetryData {
+		if !data.ReadAt.Before(cutoff) {
+			kept = append(kept, data)
+		}
+	}
+
+	if dropped := len(telemetryData) - len(kept); dropped > 0 {
+		loggerFrom(ctx).Info().
+			Int("dropped", dropped).
+			Dur("max_age", m.Cfg.MaxPointAge).
+			Msg("Dropped telemetry points older than the maximum point age")
+	}
+	return kept
+}
+
+// zeroNoiseDeltas zeroes consumption deltas smaller than MinConsumptionDelta in place
+func (m *Monitor) zeroNoiseDeltas(telemetryData []octopus.TelemetryData) {
+	if m.Cfg.MinConsumptionDelta <= 0 {
+		return
+	}
+	for i := range telemetryData {
+		if math.Abs(telemetryData[i].ConsumptionDelta) < m.Cfg.MinConsumptionDelta {
+			telemetryData[i].ConsumptionDelta = 0
+		}
+	}
+}
+
+// roundTelemetry rounds telemetry fields in place to the configured decimal places
+func (m *Monitor) roundTelemetry(telemetryData []octopus.TelemetryData) {
+	for i := range telemetryData {
+		data := &telemetryData[i]
+		data.ConsumptionDelta = roundTo(data.ConsumptionDelta, m.Cfg.RoundConsumptionDelta)
+		data.Demand = roundTo(data.Demand, m.Cfg.RoundDemand)
+		data.CostDelta = roundTo(data.CostDelta, m.Cfg.RoundCostDelta)
+		data.Consumption = roundTo(data.Consumption, m.Cfg.RoundConsumption)
+	}
+}
+
+// roundTo rounds v to the given number of decimal places; nil leaves v unchanged
+func roundTo(v float64, places *int) float64 {
+	if places == nil {
+		return v
+	}
+	scale := math.Pow10(*places)
+	return math.Round(v*scale) / scale
+}
+
+// splitBatch divides telemetry into the points written inline this poll and the
+// points deferred to the cache, according to MaxPointsPerPoll
+func (m *Monitor) splitBatch(telemetryData []octopus.TelemetryData) (inline, deferred []octopus.TelemetryData) {
+	limit := m.Cfg.MaxPointsPerPoll
+	if limit <= 0 || len(telemetryData) <= limit {
+		return telemetryData, nil
+	}
+	return telemetryData[:limit], telemetryData[limit:]
+}
+
+// sortForSync orders cached points for syncing according to CacheSyncOrder
+func (m *Monitor) sortForSync(points []cache.DataPoint) {
+	newestFirst := m.Cfg.CacheSyncOrder == config.CacheSyncNewest
+	sort.SliceStable(points, func(i, j int) bool {
+		if newestFirst {
+			return points[i].Timestamp.After(points[j].Timestamp)
+		}
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+}
+
+// cacheSyncLimit returns how many cached points a poll that wrote written points inline
+// may sync: CacheSyncBatchSize, capped by the remaining MaxPointsPerPoll budget
+func (m *Monitor) cacheSyncLimit(written int) int {
+	limit := m.Cfg.CacheSyncBatchSize
+	if m.Cfg.MaxPointsPerPoll > 0 {
+		if budget := m.Cfg.MaxPointsPerPoll - written; limit == 0 || budget < limit {
+			limit = budget
+		}
+	}
+	return limit
+}
+
+// syncCacheBatch writes up to limit cached points to InfluxDB, taken from the oldest or
+// newest end per CacheSyncOrder, and prunes them from the cache so a backlog drains
+// incrementally between polls
+func (m *Monitor) syncCacheBatch(ctx context.Context, limit int) {
+	if limit <= 0 || m.Cache.Count() == 0 {
+		return
+	}
+
+	logger := loggerFrom(ctx)
+	snap := m.Cache.Snapshot()
+	cachedData := snap.Points()
+	m.sortForSync(cachedData)
+
+	batch := cachedData[:batchEnd(cachedData, limit)]
+
+	ctx, finish := m.startSync(ctx, "incremental")
+	synced := 0
+	var syncErr error
+	defer func() { finish(synced, len(batch)-synced, syncErr) }()
+
+	// The batch gets its own write timeout rather than the remainder of the caller's
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.Cfg.InfluxWriteTimeout)
+	defer cancel()
+
+	// Summary mode summarizes the batch, as a poll's points are
+	var err error
+	if synced, err = m.writePoints(ctx, cachedInfluxPoints(batch)); err != nil {
+		syncErr = err
+		logger.Warn().Err(err).Msg("Incremental cache sync interrupted")
+		m.recordError(ComponentInfluxDB, err)
+		return
+	}
+	m.InfluxClient.Flush()
+	m.recordWrittenCached(batch)
+
+	// Only points in the snapshot are pruned; any cached meanwhile wait for the next batch
+	from, to := timeRange(batch)
+	if _, err := m.Cache.PruneSnapshot(snap, from, to); err != nil {
+		logger.Error().Err(err).Msg("Error pruning synced points from cache")
+		m.recordError(ComponentCache, err)
+		return
+	}
+	m.observeCacheBacklog("cached data synced to InfluxDB")
+
+	logger.Info().
+		Int("synced", len(batch)).
+		Int("remaining", m.Cache.Count()).
+		Msg("Incrementally synced cached data points")
+}
+
+// batchEnd returns how many of the sorted points to sync in a batch of up to limit.
+// Pruning is by timestamp range, so the batch takes in any points sharing its last timestamp.
+func batchEnd(points []cache.DataPoint, limit int) int {
+	n := min(limit, len(points))
+	for n > 0 && n < len(points) && points[n].Timestamp.Equal(points[n-1].Timestamp) {
+		n++
+	}
+	return n
+}
+
+// writeToInflux writes telemetry data to InfluxDB as raw points, a summary point, or both
+// depending on the configured write mode
 func (m *Monitor) writeToInflux(telemetryData []octopus.TelemetryData) error {
 	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.InfluxWriteTimeout)
 	defer cancel()
 
+	dataPoints := make([]influx.DataPoint, 0, len(telemetryData))
 	for _, data := range telemetryData {
-		dp := influx.DataPoint{
+		dataPoints = append(dataPoints, influx.DataPoint{
 			Timestamp:        data.ReadAt,
 			ConsumptionDelta: data.ConsumptionDelta,
 			Demand:           data.Demand,
 			CostDelta:        data.CostDelta,
 			Consumption:      data.Consumption,
+		})
+	}
+
+	if _, err := m.writePoints(ctx, dataPoints); err != nil {
+		return err
+	}
+	m.InfluxClient.Flush()
+	return nil
+}
+
+// writePoints writes dataPoints as raw points, one summary point, or both depending on
+// the configured write mode. It returns how many of dataPoints were stored before any
+// error; in summary mode that is all of them once the summary is written, else none.
+func (m *Monitor) writePoints(ctx context.Context, dataPoints []influx.DataPoint) (int, error) {
+	mode := m.Cfg.InfluxDBWriteMode
+	written := 0
+	if mode != config.WriteModeSummary {
+		for _, dp := range dataPoints {
+			if err := m.InfluxClient.WritePointDirectly(ctx, dp); err != nil {
+				return written, err
+			}
+			written++
 		}
+	}
 
-		if err := m.InfluxClient.WritePointDirectly(ctx, dp); err != nil {
-			return err
+	if mode == config.WriteModeSummary || mode == config.WriteModeBoth {
+		if summary, ok := influx.Summarize(dataPoints); ok {
+			if err := m.InfluxClient.WriteSummary(ctx, m.Cfg.SummaryMeasurement(), summary); err != nil {
+				return written, err
+			}
 		}
 	}
 
-	m.InfluxClient.Flush()
-	return nil
+	return len(dataPoints), nil
+}
+
+// cachedInfluxPoints converts cached points to InfluxDB data points for syncing
+func cachedInfluxPoints(cachedData []cache.DataPoint) []influx.DataPoint {
+	dataPoints := make([]influx.DataPoint, 0, len(cachedData))
+	for _, data := range cachedData {
+		dataPoints = append(dataPoints, influx.DataPoint{
+			Timestamp:        data.Timestamp,
+			ConsumptionDelta: data.ConsumptionDelta,
+			Demand:           data.Demand,
+			CostDelta:        data.CostDelta,
+			Consumption:      data.Consumption,
+		})
+	}
+	return dataPoints
+}
+
+// writeToParquet buffers telemetry data in the Parquet sink. Points that fail to
+// flush stay buffered in the sink and are retried on the next flush.
+func (m *Monitor) writeToParquet(ctx context.Context, telemetryData []octopus.TelemetryData, routineLog zerolog.Logger) {
+	logger := loggerFrom(ctx)
+	dataPoints := make([]parquetsink.DataPoint, 0, len(telemetryData))
+	for _, data := range telemetryData {
+		dataPoints = append(dataPoints, parquetsink.DataPoint{
+			Timestamp:        data.ReadAt,
+			ConsumptionDelta: data.ConsumptionDelta,
+			Demand:           data.Demand,
+			CostDelta:        data.CostDelta,
+			Consumption:      data.Consumption,
+		})
+	}
+
+	if err := m.ParquetSink.Write(dataPoints); err != nil {
+		logger.Error().Err(err).Int("buffered", m.ParquetSink.Buffered()).Msg("Failed to flush Parquet data")
+		m.recordError(ComponentParquet, err)
+		m.NotifyError("Parquet", fmt.Sprintf("Failed to flush data: %v", err))
+		return
+	}
+
+	m.setLastWriteTime(time.Now())
+	routineLog.Info().Int("count", len(dataPoints)).Msg("Buffered data points for Parquet")
 }
 
 // cacheData stores telemetry data in local cache
-func (m *Monitor) cacheData(telemetryData []octopus.TelemetryData) {
+func (m *Monitor) cacheData(ctx context.Context, telemetryData []octopus.TelemetryData) {
+	logger := loggerFrom(ctx)
+	if !m.hasCacheSpace(ctx) {
+		logger.Error().Int("count", len(telemetryData)).Msg("Not caching data points: free disk space below CACHE_MIN_FREE_DISK_MB")
+		return
+	}
+
 	dataPoints := make([]cache.DataPoint, 0, len(telemetryData))
 
 	for _, data := range telemetryData {
@@ -324,41 +958,119 @@ func (m *Monitor) cacheData(telemetryData []octopus.TelemetryData) {
 		})
 	}
 
+	dataPoints, buffered := m.bufferInMemory(ctx, dataPoints)
+	if buffered {
+		return
+	}
+
 	if err := m.Cache.Add(dataPoints); err != nil {
-		log.Error().Err(err).Msg("Error caching data")
-		m.SendSlackError("Cache", fmt.Sprintf("Failed to cache data: %v", err))
+		logger.Error().Err(err).Msg("Error caching data")
+		m.recordError(ComponentCache, err)
+		if !m.countCacheFailure(ctx, err) {
+			m.NotifyError("Cache", fmt.Sprintf("Failed to cache data: %v", err))
+		}
 	} else {
-		log.Info().
+		m.cacheWriteFailures = 0
+		m.setLastWriteTime(time.Now())
+		m.advanceWatermark(ctx, telemetryData)
+		m.observeCacheBacklog("data cached instead of written to InfluxDB")
+		logger.Info().
 			Int("count", len(dataPoints)).
 			Int("total_in_cache", m.Cache.Count()).
 			Msg("Cached data points")
 	}
 }
 
+// handleAsyncWriteFailure switches to cache mode once ConsecutiveErrorThreshold async
+// writes in a row have failed, as Octopus API errors enter degraded mode, so a single
+// dropped batch does not abandon a working connection
+func (m *Monitor) handleAsyncWriteFailure(err error) {
+	if !m.getInfluxHealthy() {
+		return
+	}
+
+	m.recordError(ComponentInfluxDB, err)
+	m.mu.Lock()
+	m.asyncWriteErr++
+	failures := m.asyncWriteErr
+	m.mu.Unlock()
+	if failures < m.Cfg.ConsecutiveErrorThreshold {
+		log.Warn().Err(err).Int("consecutive_failures", failures).Msg("Async InfluxDB write failed")
+		return
+	}
+
+	m.resetAsyncWriteErr()
+	m.updateInfluxHealth(false, fmt.Sprintf("async write failed: %v", sanitizeError(err)))
+	if m.InfluxClient != nil {
+		m.InfluxClient.ExpireHealthCache()
+	}
+	log.Warn().Err(err).Int("consecutive_failures", failures).Msg("Async InfluxDB write failed, switching to cache mode")
+	m.NotifyError("InfluxDB", fmt.Sprintf("Async write failed: %v. Switching to cache mode.", sanitizeError(err)))
+}
+
+func (m *Monitor) resetAsyncWriteErr() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.asyncWriteErr = 0
+}
+
+// cacheFailedBatch caches the points of a batch the async WriteAPI failed to write, so
+// they are synced with the rest of the cache rather than lost
+func (m *Monitor) cacheFailedBatch(points []influx.DataPoint, err error) {
+	if len(points) == 0 {
+		return
+	}
+
+	cached := make([]cache.DataPoint, 0, len(points))
+	for _, dp := range points {
+		cached = append(cached, cache.DataPoint{
+			Timestamp:        dp.Timestamp,
+			ConsumptionDelta: dp.ConsumptionDelta,
+			Demand:           dp.Demand,
+			CostDelta:        dp.CostDelta,
+			Consumption:      dp.Consumption,
+		})
+	}
+	if cacheErr := m.Cache.Add(cached); cacheErr != nil {
+		log.Error().Err(cacheErr).Int("count", len(cached)).Msg("Error caching failed async InfluxDB batch")
+		m.recordError(ComponentCache, cacheErr)
+		return
+	}
+	log.Info().Err(err).Int("count", len(cached)).Msg("Cached points from failed async InfluxDB batch")
+}
+
 // checkInfluxHealth checks if InfluxDB is healthy
 func (m *Monitor) checkInfluxHealth(ctx context.Context) {
+	logger := loggerFrom(ctx)
 	if m.InfluxClient == nil {
 		return
 	}
 
-	err := m.InfluxClient.CheckConnection(ctx)
+	err := m.InfluxClient.CheckHealth(ctx)
 	wasHealthy := m.getInfluxHealthy()
 	isHealthy := err == nil
-	m.setInfluxHealthy(isHealthy)
+	reason := "health check passed"
+	if err != nil {
+		reason = fmt.Sprintf("health check failed: %v", sanitizeError(err))
+	}
+	m.updateInfluxHealth(isHealthy, reason)
+
+	m.recordError(ComponentInfluxDB, err)
 
 	// Alert on state change
 	if wasHealthy && !isHealthy {
-		log.Warn().Msg("InfluxDB connection lost")
-		m.SendSlackError("InfluxDB", "Connection to InfluxDB lost. Switching to cache mode.")
+		logger.Warn().Msg("InfluxDB connection lost")
+		m.NotifyError("InfluxDB", "Connection to InfluxDB lost. Switching to cache mode.")
 	} else if !wasHealthy && isHealthy {
-		log.Info().Msg("InfluxDB connection restored")
-		m.SendSlackInfo("InfluxDB", "Connection to InfluxDB restored. Syncing cached data...")
-		m.SyncCache()
+		logger.Info().Msg("InfluxDB connection restored")
+		m.NotifyInfo("InfluxDB", "Connection to InfluxDB restored. Syncing cached data...")
+		m.syncCache(ctx)
 	}
 }
 
 // tryReconnectInflux attempts to reconnect to InfluxDB with exponential backoff
 func (m *Monitor) tryReconnectInflux(ctx context.Context) {
+	logger := loggerFrom(ctx)
 	if m.InfluxClient == nil {
 		return
 	}
@@ -375,64 +1087,153 @@ func (m *Monitor) tryReconnectInflux(ctx context.Context) {
 	}
 
 	if err := backoff.Retry(operation, backoff.WithContext(expBackoff, ctx)); err == nil {
-		log.Info().Msg("InfluxDB connection restored!")
-		m.setInfluxHealthy(true)
-		m.SendSlackInfo("InfluxDB", "Connection restored. Syncing cached data...")
-		m.SyncCache()
+		logger.Info().Msg("InfluxDB connection restored!")
+		m.updateInfluxHealth(true, "reconnected")
+		m.NotifyInfo("InfluxDB", "Connection restored. Syncing cached data...")
+		m.syncCache(ctx)
 	}
 }
 
 // SyncCache writes all cached data to InfluxDB
 func (m *Monitor) SyncCache() {
+	m.syncCache(context.Background())
+}
+
+// syncCache writes all cached data to InfluxDB, logging and tracing under ctx
+func (m *Monitor) syncCache(ctx context.Context) {
+	logger := loggerFrom(ctx)
 	if !m.getInfluxHealthy() {
-		log.Warn().Msg("InfluxDB not healthy, skipping cache sync")
+		logger.Warn().Msg("InfluxDB not healthy, skipping cache sync")
 		return
 	}
-	cachedData := m.Cache.GetAll()
+	snap := m.Cache.Snapshot()
+	cachedData := snap.Points()
 	if len(cachedData) == 0 {
-		log.Info().Msg("No cached data to sync")
+		logger.Info().Msg("No cached data to sync")
 		return
 	}
 
-	log.Info().Int("count", len(cachedData)).Msg("Syncing cached data points to InfluxDB...")
+	m.sortForSync(cachedData)
+
+	logger.Info().Int("count", len(cachedData)).Msg("Syncing cached data points to InfluxDB...")
+
+	if m.startCatchUp(ctx, len(cachedData)) {
+		m.syncCatchUp(ctx, snap, cachedData)
+		return
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.CacheSyncTimeout)
+	// Points still cached when the sync ends count as failed, so partial syncs are accounted
+	ctx, finish := m.startSync(ctx, "full")
+	successCount, failedCount := 0, 0
+	var syncErr error
+	defer func() { finish(successCount, failedCount, syncErr) }()
+
+	// The sync gets its own timeout rather than the remainder of the caller's
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.Cfg.CacheSyncTimeout)
 	defer cancel()
 
-	successCount := 0
-	for _, data := range cachedData {
-		dp := influx.DataPoint{
-			Timestamp:        data.Timestamp,
-			ConsumptionDelta: data.ConsumptionDelta,
-			Demand:           data.Demand,
-			CostDelta:        data.CostDelta,
-			Consumption:      data.Consumption,
+	droppedBefore := m.InfluxClient.DroppedPointCount()
+	// Summary mode summarizes the cached points, as a poll's points are
+	var err error
+	if successCount, err = m.writePoints(ctx, cachedInfluxPoints(cachedData)); err != nil {
+		failedCount = len(cachedData) - successCount
+		syncErr = err
+		if influx.IsBackpressure(err) {
+			logger.Warn().Err(err).Int("synced", successCount).Msg("InfluxDB backpressure, postponing cache sync")
+			m.recordError(ComponentInfluxDB, err)
+			return
 		}
 
-		if err := m.InfluxClient.WritePointDirectly(ctx, dp); err != nil {
-			log.Error().Err(err).Msg("Error writing cached point")
-			m.SendSlackError("Cache Sync", fmt.Sprintf("Failed to sync cached data: %v", sanitizeError(err)))
+		m.recordError(ComponentInfluxDB, err)
+		if m.alertFieldTypeConflict(ctx, err) {
 			return
 		}
-		successCount++
+		logger.Error().Err(err).Msg("Error writing cached point")
+		m.NotifyError("Cache Sync", fmt.Sprintf("Failed to sync cached data: %v", sanitizeError(err)))
+		return
 	}
 
 	m.InfluxClient.Flush()
 
-	// Clear cache after successful sync
-	if err := m.Cache.Clear(); err != nil {
-		log.Error().Err(err).Msg("Error clearing cache")
-		m.SendSlackError("Cache", fmt.Sprintf("Failed to clear cache: %v", err))
+	if m.verifiesSync() {
+		dropped := int(m.InfluxClient.DroppedPointCount() - droppedBefore)
+		if err := m.verifySync(ctx, cachedData, dropped); err != nil {
+			successCount, failedCount = 0, len(cachedData)
+			syncErr = err
+			logger.Error().Err(err).Msg("Cache sync verification failed, keeping cached data")
+			m.recordError(ComponentInfluxDB, err)
+			m.NotifyError("Cache Sync", fmt.Sprintf("Sync verification failed: %v. Cached data kept for the next sync.", sanitizeError(err)))
+			return
+		}
+	}
+
+	m.recordWrittenCached(cachedData)
+
+	// Remove the synced points, keeping any cached while the sync was running
+	from, to := timeRange(cachedData)
+	if _, err := m.Cache.PruneSnapshot(snap, from, to); err != nil {
+		syncErr = err
+		logger.Error().Err(err).Msg("Error clearing cache")
+		m.recordError(ComponentCache, err)
+		m.NotifyError("Cache", fmt.Sprintf("Failed to clear cache: %v", err))
 	} else {
-		log.Info().Int("count", successCount).Msg("Successfully synced cached data points")
-		m.SendSlackInfo("Cache Sync", fmt.Sprintf("Successfully synced %d cached data points to InfluxDB", successCount))
+		m.observeCacheBacklog("cached data synced to InfluxDB")
+		logger.Info().Int("count", successCount).Msg("Successfully synced cached data points")
+		m.NotifyInfo("Cache Sync", fmt.Sprintf("Successfully synced %d cached data points to InfluxDB", successCount))
+	}
+}
+
+// verifiesSync reports whether synced points are read back when VerifyCacheSync is set.
+// Summary mode writes no raw points to count, so there is nothing to verify.
+func (m *Monitor) verifiesSync() bool {
+	return m.Cfg.VerifyCacheSync && m.Cfg.InfluxDBWriteMode != config.WriteModeSummary
+}
+
+// verifySync checks that InfluxDB holds a point for every distinct timestamp that was
+// synced, less any points dropped by sanitization. Extra points (e.g. from live polls in
+// the same range) are fine; fewer means writes were lost.
+func (m *Monitor) verifySync(ctx context.Context, synced []cache.DataPoint, dropped int) error {
+	timestamps := make(map[int64]struct{}, len(synced))
+	for _, data := range synced {
+		timestamps[data.Timestamp.UnixNano()] = struct{}{}
+	}
+	start, end := timeRange(synced)
+
+	stored, err := m.InfluxClient.CountPoints(ctx, start, end)
+	if err != nil {
+		return err
+	}
+
+	expected := int64(len(timestamps) - dropped)
+	if stored < expected {
+		return fmt.Errorf("InfluxDB has %d of %d synced points between %s and %s",
+			stored, expected, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+
+	log.Info().Int64("stored", stored).Int64("expected", expected).Msg("Cache sync verified")
+	return nil
+}
+
+// timeRange returns the earliest and latest timestamps of a non-empty set of points
+func timeRange(points []cache.DataPoint) (from, to time.Time) {
+	from, to = points[0].Timestamp, points[0].Timestamp
+	for _, data := range points[1:] {
+		if data.Timestamp.Before(from) {
+			from = data.Timestamp
+		}
+		if data.Timestamp.After(to) {
+			to = data.Timestamp
+		}
 	}
+	return from, to
 }
 
 // RunCacheCleanup periodically cleans up old cache files
 func (m *Monitor) RunCacheCleanup(stopChan chan struct{}) {
 	// Run cleanup immediately on startup
-	m.cleanupCache()
+	if !m.runTracked(m.cleanupCache) {
+		return
+	}
 
 	// Setup periodic cleanup
 	ticker := time.NewTicker(m.Cfg.CacheCleanupInterval)
@@ -441,14 +1242,17 @@ func (m *Monitor) RunCacheCleanup(stopChan chan struct{}) {
 	for {
 		select {
 		case <-ticker.C:
-			m.cleanupCache()
+			if !m.runTracked(m.cleanupCache) {
+				return
+			}
 		case <-stopChan:
 			return
 		}
 	}
 }
 
-// cleanupCache removes cache files older than the retention period
+// cleanupCache removes cache files older than the retention period, then the oldest
+// files beyond the configured total size
 func (m *Monitor) cleanupCache() {
 	log.Info().Int("retention_days", m.Cfg.CacheRetentionDays).Msg("Running cache cleanup...")
 
@@ -456,8 +1260,27 @@ func (m *Monitor) cleanupCache() {
 	err := m.Cache.CleanupOldFiles(retentionDuration)
 	if err != nil {
 		log.Error().Err(err).Msg("Error during cache cleanup")
-		m.SendSlackWarning("Cache Cleanup", fmt.Sprintf("Failed to cleanup old cache files: %v", err))
-	} else {
-		log.Info().Msg("Cache cleanup completed successfully")
+		m.recordError(ComponentCache, err)
+		m.NotifyWarning("Cache Cleanup", fmt.Sprintf("Failed to cleanup old cache files: %v", err))
+		return
+	}
+
+	if m.Cfg.CacheMaxSizeMB > 0 {
+		removed, err := m.Cache.CleanupBySize(int64(m.Cfg.CacheMaxSizeMB) * bytesPerMB)
+		if err != nil {
+			log.Error().Err(err).Msg("Error during size-based cache cleanup")
+			m.recordError(ComponentCache, err)
+			m.NotifyWarning("Cache Cleanup", fmt.Sprintf("Failed to trim cache to %d MB: %v", m.Cfg.CacheMaxSizeMB, err))
+			return
+		}
+		if removed > 0 {
+			log.Warn().
+				Int("removed_files", removed).
+				Int("max_size_mb", m.Cfg.CacheMaxSizeMB).
+				Msg("Removed oldest cache files to stay within size limit")
+		}
 	}
+
+	m.observeCacheBacklog("old cache files removed by cleanup")
+	log.Info().Msg("Cache cleanup completed successfully")
 }
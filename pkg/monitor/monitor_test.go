@@ -0,0 +1,2370 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/soothill/octopus-home-mini/pkg/cache"
+	"github.com/soothill/octopus-home-mini/pkg/config"
+	"github.com/soothill/octopus-home-mini/pkg/influx"
+	"github.com/soothill/octopus-home-mini/pkg/octopus"
+	"github.com/soothill/octopus-home-mini/pkg/weather"
+)
+
+// newMockOctopusServer returns a GraphQL server whose single response satisfies
+// authentication, meter discovery, and telemetry queries
+func newMockOctopusServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"data": {
+				"obtainKrakenToken": {"token": "test_token"},
+				"account": {
+					"electricityAgreements": [{
+						"meterPoint": {
+							"meters": [{"smartDevices": [{"deviceId": "test_device"}]}]
+						}
+					}]
+				},
+				"smartMeterTelemetry": []
+			}
+		}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// newTestConfig returns the settings the monitor tests share; each test overrides only
+// the fields it exercises
+func newTestConfig() *config.Config {
+	return &config.Config{
+		PollInterval:              30 * time.Second,
+		PollTimeout:               5 * time.Second,
+		InfluxWriteTimeout:        5 * time.Second,
+		CacheSyncTimeout:          5 * time.Second,
+		ConsecutiveErrorThreshold: 3,
+		MaxBackoffFactor:          4,
+	}
+}
+
+// newTestMonitor creates a monitor backed by a mock Octopus API and a temporary cache
+func newTestMonitor(t *testing.T) *Monitor {
+	t.Helper()
+
+	return newInfluxTestMonitor(t, newTestConfig(), newMockOctopusServer(t).URL, "")
+}
+
+// newInfluxTestMonitor creates a monitor with cfg and a temporary cache, polling the
+// Octopus API at octopusURL and writing to the InfluxDB at influxURL. Either client is
+// left nil when its URL is empty.
+func newInfluxTestMonitor(t *testing.T, cfg *config.Config, octopusURL, influxURL string) *Monitor {
+	t.Helper()
+
+	cacheStore, err := cache.NewCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	var octopusClient *octopus.Client
+	if octopusURL != "" {
+		octopusClient = octopus.NewClientWithEndpoint("test_key", "A-12345678", octopusURL)
+		if err := octopusClient.Initialize(context.Background()); err != nil {
+			t.Fatalf("Initialize() error = %v", err)
+		}
+	}
+
+	var influxClient *influx.Client
+	if influxURL != "" {
+		influxClient, err = influx.NewClient(influxURL, "token", "org", "bucket", "measurement")
+		if err != nil {
+			t.Fatalf("influx.NewClient() error = %v", err)
+		}
+		t.Cleanup(influxClient.Close)
+	}
+
+	return New(cfg, octopusClient, influxClient, cacheStore, nil)
+}
+
+// addCachedPoints caches a point with the given demand at each timestamp
+func addCachedPoints(t *testing.T, cacheStore *cache.Cache, demand float64, timestamps ...time.Time) {
+	t.Helper()
+
+	for _, ts := range timestamps {
+		if err := cacheStore.AddSingle(cache.DataPoint{Timestamp: ts, Demand: demand}); err != nil {
+			t.Fatalf("AddSingle() error = %v", err)
+		}
+	}
+}
+
+func TestMonitor_LastPollTime_Concurrent(t *testing.T) {
+	m := newTestMonitor(t)
+	initial := m.LastPollTime()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			m.poll()
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = m.LastPollTime()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !m.LastPollTime().After(initial) {
+		t.Errorf("LastPollTime() = %v, want after initial %v", m.LastPollTime(), initial)
+	}
+}
+
+func TestMonitor_DataStaleness(t *testing.T) {
+	m := newTestMonitor(t)
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		lastWrite     time.Time
+		lastEmptyPoll time.Time
+		backoffFactor int
+		maxStaleness  time.Duration
+		wantStale     bool
+		wantStaleness time.Duration
+	}{
+		{
+			name:          "recent write",
+			lastWrite:     now.Add(-1 * time.Minute),
+			backoffFactor: 1,
+			maxStaleness:  5 * time.Minute,
+			wantStale:     false,
+			wantStaleness: time.Minute,
+		},
+		{
+			name:          "stale write",
+			lastWrite:     now.Add(-10 * time.Minute),
+			backoffFactor: 1,
+			maxStaleness:  5 * time.Minute,
+			wantStale:     true,
+			wantStaleness: 10 * time.Minute,
+		},
+		{
+			name:          "recent poll with no data",
+			lastWrite:     now.Add(-10 * time.Minute),
+			lastEmptyPoll: now.Add(-30 * time.Second),
+			backoffFactor: 1,
+			maxStaleness:  5 * time.Minute,
+			wantStale:     false,
+			wantStaleness: 30 * time.Second,
+		},
+		{
+			name:          "old poll with no data",
+			lastWrite:     now.Add(-20 * time.Minute),
+			lastEmptyPoll: now.Add(-10 * time.Minute),
+			backoffFactor: 1,
+			maxStaleness:  5 * time.Minute,
+			wantStale:     true,
+			wantStaleness: 10 * time.Minute,
+		},
+		{
+			name:          "degraded backoff widens limit",
+			lastWrite:     now.Add(-90 * time.Second),
+			backoffFactor: 4, // 2 x (30s x 4) = 4 minutes
+			maxStaleness:  time.Minute,
+			wantStale:     false,
+			wantStaleness: 90 * time.Second,
+		},
+		{
+			name:          "stale beyond degraded limit",
+			lastWrite:     now.Add(-5 * time.Minute),
+			backoffFactor: 4,
+			maxStaleness:  time.Minute,
+			wantStale:     true,
+			wantStaleness: 5 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m.setLastWriteTime(tt.lastWrite)
+			m.setLastEmptyPoll(tt.lastEmptyPoll)
+			m.setBackoffFactor(tt.backoffFactor)
+
+			staleness, stale := m.DataStaleness(now, tt.maxStaleness)
+
+			if stale != tt.wantStale {
+				t.Errorf("DataStaleness() stale = %v, want %v", stale, tt.wantStale)
+			}
+			if staleness != tt.wantStaleness {
+				t.Errorf("DataStaleness() staleness = %v, want %v", staleness, tt.wantStaleness)
+			}
+		})
+	}
+}
+
+func TestMonitor_LogSampling(t *testing.T) {
+	m := newTestMonitor(t)
+	m.Cfg.LogSampleEveryN = 3
+
+	var buf bytes.Buffer
+	originalLogger := log.Logger
+	log.Logger = zerolog.New(&buf)
+	defer func() { log.Logger = originalLogger }()
+
+	for i := 0; i < 7; i++ {
+		m.poll()
+	}
+
+	// Polls 1, 4 and 7 should log routine messages
+	if got := strings.Count(buf.String(), "Polling for telemetry data"); got != 3 {
+		t.Errorf("routine poll messages logged = %d, want 3\n%s", got, buf.String())
+	}
+	if got := strings.Count(buf.String(), "No new telemetry data available"); got != 3 {
+		t.Errorf("no-data messages logged = %d, want 3\n%s", got, buf.String())
+	}
+}
+
+func TestMonitor_HandleAsyncWriteFailure(t *testing.T) {
+	m := newTestMonitor(t)
+	notifier := &recordingNotifier{}
+	m.Notifier = notifier
+	m.updateInfluxHealth(true, "connected")
+
+	// Failures below ConsecutiveErrorThreshold keep the connection
+	for i := 1; i < m.Cfg.ConsecutiveErrorThreshold; i++ {
+		m.handleAsyncWriteFailure(errors.New("write failed: retries exhausted"))
+	}
+	if !m.getInfluxHealthy() {
+		t.Fatal("influxHealthy = false before the failure threshold, want true")
+	}
+	if calls := notifier.Calls(); len(calls) != 0 {
+		t.Fatalf("notifications before the failure threshold = %v, want none", calls)
+	}
+
+	m.handleAsyncWriteFailure(errors.New("write failed: retries exhausted"))
+	if m.getInfluxHealthy() {
+		t.Error("influxHealthy = true after consecutive async write failures, want false")
+	}
+	calls := notifier.Calls()
+	if len(calls) != 1 || !strings.HasPrefix(calls[0], "error|InfluxDB|Async write failed") {
+		t.Errorf("notifications = %v, want one switch to cache mode", calls)
+	}
+
+	// Further failures while already unhealthy are a no-op
+	m.handleAsyncWriteFailure(errors.New("write failed again"))
+	if m.getInfluxHealthy() {
+		t.Error("influxHealthy = true after repeated async write failure, want false")
+	}
+	if len(notifier.Calls()) != 1 {
+		t.Errorf("notifications = %v, want no more once unhealthy", notifier.Calls())
+	}
+}
+
+func TestMonitor_CacheFailedAsyncBatch(t *testing.T) {
+	influxServer := newHookedInfluxServer(t, func(w http.ResponseWriter, body string) bool {
+		http.Error(w, `{"code":"unavailable","message":"overloaded"}`, http.StatusServiceUnavailable)
+		return true
+	})
+	m := newInfluxTestMonitor(t, newTestConfig(), "", influxServer.URL)
+
+	at := time.Unix(1700000000, 0)
+	if err := m.InfluxClient.WriteDataPoint(influx.DataPoint{Timestamp: at, ConsumptionDelta: 0.01, Demand: 600}); err != nil {
+		t.Fatalf("WriteDataPoint() error = %v", err)
+	}
+	m.InfluxClient.Flush()
+
+	// The dropped batch is kept in the cache to sync later
+	deadline := time.Now().Add(5 * time.Second)
+	for m.Cache.Count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cached := m.Cache.GetAll()
+	if len(cached) != 1 || !cached[0].Timestamp.Equal(at) || cached[0].Demand != 600 {
+		t.Fatalf("cached points = %+v, want the failed point", cached)
+	}
+	if !m.getInfluxHealthy() {
+		t.Error("influxHealthy = false after one failed async batch, want true")
+	}
+}
+
+// recordingNotifier is a notify.Notifier that records every notification sent
+type recordingNotifier struct {
+	mu    sync.Mutex
+	calls []string
+	err   error // Returned by every send when set
+}
+
+func (n *recordingNotifier) record(kind, component, message string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.calls = append(n.calls, kind+"|"+component+"|"+message)
+	return n.err
+}
+
+func (n *recordingNotifier) SendError(component, errorMsg string) error {
+	return n.record("error", component, errorMsg)
+}
+
+func (n *recordingNotifier) SendWarning(component, warningMsg string) error {
+	return n.record("warning", component, warningMsg)
+}
+
+func (n *recordingNotifier) SendInfo(title, message string) error {
+	return n.record("info", title, message)
+}
+
+func (n *recordingNotifier) SendCacheAlert(count int, action string) error {
+	return n.record("cache", action, "")
+}
+
+func (n *recordingNotifier) Calls() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.calls...)
+}
+
+// newFlakyMonitor returns a monitor whose Octopus API starts failing once the
+// returned flag is set
+func newFlakyMonitor(t *testing.T, cfg *config.Config, notifier *recordingNotifier) (*Monitor, *atomic.Bool) {
+	t.Helper()
+	return newFailingMonitor(t, cfg, notifier, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	})
+}
+
+// newFailingMonitor returns a monitor whose Octopus API answers with fail once the
+// returned flag is set
+func newFailingMonitor(t *testing.T, cfg *config.Config, notifier *recordingNotifier, fail http.HandlerFunc) (*Monitor, *atomic.Bool) {
+	t.Helper()
+
+	failing := &atomic.Bool{}
+	healthy := newMockOctopusServer(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			fail(w, r)
+			return
+		}
+		healthy.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	m := newInfluxTestMonitor(t, cfg, server.URL, "")
+	m.Notifier = notifier
+	return m, failing
+}
+
+func TestMonitor_DegradedModeNotifications(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.PollTimeout = 200 * time.Millisecond
+	notifier := &recordingNotifier{}
+	m, failing := newFlakyMonitor(t, cfg, notifier)
+
+	failing.Store(true)
+	for i := 0; i < cfg.ConsecutiveErrorThreshold+1; i++ {
+		m.poll()
+	}
+
+	if !m.getDegradedMode() {
+		t.Fatal("monitor not in degraded mode after consecutive failures")
+	}
+
+	calls := notifier.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("notifications = %v, want exactly one degraded-mode error", calls)
+	}
+	if !strings.HasPrefix(calls[0], "error|Octopus API|Entering degraded mode") {
+		t.Errorf("notification = %q, want degraded-mode error for Octopus API", calls[0])
+	}
+}
+
+func TestMonitor_DegradedGracePeriod(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.PollTimeout = 200 * time.Millisecond
+	cfg.ConsecutiveErrorThreshold = 2
+	cfg.DegradedGracePeriod = time.Minute
+	notifier := &recordingNotifier{}
+	m, failing := newFlakyMonitor(t, cfg, notifier)
+
+	failing.Store(true)
+	for i := 0; i < cfg.ConsecutiveErrorThreshold+1; i++ {
+		m.poll()
+	}
+
+	if m.getDegradedMode() {
+		t.Error("monitor entered degraded mode within the startup grace period")
+	}
+	if got := m.getConsecutiveErr(); got != cfg.ConsecutiveErrorThreshold+1 {
+		t.Errorf("consecutive errors = %d, want %d counted during the grace period", got, cfg.ConsecutiveErrorThreshold+1)
+	}
+	if calls := notifier.Calls(); len(calls) != 0 {
+		t.Errorf("notifications = %v, want none during the grace period", calls)
+	}
+
+	// Once the grace period has passed, continuing errors degrade polling
+	m.startedAt = time.Now().Add(-cfg.DegradedGracePeriod)
+	m.poll()
+
+	if !m.getDegradedMode() {
+		t.Error("monitor not in degraded mode after the grace period")
+	}
+}
+
+func TestMonitor_OctopusMaintenance(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.PollTimeout = 200 * time.Millisecond
+	cfg.ConsecutiveErrorThreshold = 1
+	cfg.MaintenancePollInterval = 5 * time.Minute
+	cfg.MaintenanceAlertAfter = time.Hour
+	notifier := &recordingNotifier{}
+	m, failing := newFailingMonitor(t, cfg, notifier, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("<html><body><h1>Down for maintenance</h1></body></html>"))
+	})
+	var transitions bytes.Buffer
+	m.SetTransitionLog(&transitions)
+
+	// One maintenance response: polled less often, without degrading or alerting
+	failing.Store(true)
+	m.poll()
+
+	if !m.inMaintenance() {
+		t.Fatal("monitor not in maintenance mode after a maintenance response")
+	}
+	if got := m.LastErrors()[ComponentOctopus].Message; !strings.Contains(got, octopus.ErrMaintenance.Error()) {
+		t.Errorf("last Octopus error = %q, want the maintenance error", got)
+	}
+	if m.getDegradedMode() || m.getConsecutiveErr() != 0 || m.getBackoffFactor() != 1 {
+		t.Errorf("degraded = %v, consecutive errors = %d, backoff factor = %d; want maintenance kept out of degraded mode",
+			m.getDegradedMode(), m.getConsecutiveErr(), m.getBackoffFactor())
+	}
+	if got := m.maintenanceInterval(cfg.PollInterval); got != cfg.MaintenancePollInterval {
+		t.Errorf("poll interval during maintenance = %v, want %v", got, cfg.MaintenancePollInterval)
+	}
+	if calls := notifier.Calls(); len(calls) != 0 {
+		t.Errorf("notifications = %v, want none before MaintenanceAlertAfter", calls)
+	}
+
+	// Still down once MaintenanceAlertAfter has passed: a single warning
+	m.mu.Lock()
+	m.maintenanceSince = m.maintenanceSince.Add(-cfg.MaintenanceAlertAfter)
+	m.mu.Unlock()
+	m.poll()
+
+	calls := notifier.Calls()
+	if len(calls) != 1 || !strings.HasPrefix(calls[0], "warning|Octopus API|Octopus API down for maintenance") {
+		t.Fatalf("notifications = %v, want one maintenance warning", calls)
+	}
+
+	// A normal response ends maintenance mode
+	failing.Store(false)
+	m.poll()
+
+	if m.inMaintenance() {
+		t.Error("monitor still in maintenance mode after a normal response")
+	}
+	if got := m.maintenanceInterval(cfg.PollInterval); got != cfg.PollInterval {
+		t.Errorf("poll interval after maintenance = %v, want %v", got, cfg.PollInterval)
+	}
+	recovered := false
+	for _, call := range notifier.Calls() {
+		recovered = recovered || strings.HasPrefix(call, "info|Octopus API|Maintenance over")
+	}
+	if !recovered {
+		t.Errorf("notifications = %v, want a maintenance recovery message", notifier.Calls())
+	}
+
+	counts := transitionEntries(t, &transitions)
+	for _, want := range []string{"octopus_maintenance:normal->maintenance", "octopus_maintenance:maintenance->normal"} {
+		if counts[want] != 1 {
+			t.Errorf("transition %s logged %d times, want 1 (%v)", want, counts[want], counts)
+		}
+	}
+}
+
+func TestMonitor_MaxPollWindowRecovery(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.PollTimeout = 200 * time.Millisecond
+	cfg.MaxPollWindow = 10 * time.Minute
+	notifier := &recordingNotifier{}
+	m, failing := newFlakyMonitor(t, cfg, notifier)
+
+	failing.Store(true)
+	for i := 0; i < cfg.ConsecutiveErrorThreshold+1; i++ {
+		m.poll()
+	}
+	if !m.getDegradedMode() {
+		t.Fatal("monitor not in degraded mode after consecutive failures")
+	}
+
+	// The API recovers (a fresh client, as the old one's circuit breaker is still open)
+	// after an outage that left a 25 minute gap: two full windows, then the rest up to now
+	recovered := newMockOctopusServer(t)
+	m.OctopusClient = octopus.NewClientWithEndpoint("test_key", "A-12345678", recovered.URL)
+	if err := m.OctopusClient.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	m.setLastPollTime(time.Now().Add(-25 * time.Minute))
+	for i := 0; i < 2; i++ {
+		before := m.LastPollTime()
+		m.poll()
+
+		if got := m.LastPollTime().Sub(before); got != cfg.MaxPollWindow {
+			t.Errorf("poll %d advanced %v, want one %v window", i+1, got, cfg.MaxPollWindow)
+		}
+		if !m.getDegradedMode() {
+			t.Errorf("poll %d left degraded mode before catching up", i+1)
+		}
+		if got := m.getBackoffFactor(); got <= 1 {
+			t.Errorf("poll %d backoff factor = %d, want it kept while catching up", i+1, got)
+		}
+	}
+
+	m.poll()
+	if m.getDegradedMode() {
+		t.Error("monitor still in degraded mode after catching up")
+	}
+	if got := m.getBackoffFactor(); got != 1 {
+		t.Errorf("backoff factor = %d after catching up, want 1", got)
+	}
+	if lag := time.Since(m.LastPollTime()); lag > time.Minute {
+		t.Errorf("last poll time %v behind now, want caught up", lag)
+	}
+
+	recoveries := 0
+	for _, call := range notifier.Calls() {
+		if strings.HasPrefix(call, "info|Octopus API|Recovered from degraded mode") {
+			recoveries++
+		}
+	}
+	if recoveries != 1 {
+		t.Errorf("recovery notifications = %d, want 1 (%v)", recoveries, notifier.Calls())
+	}
+}
+
+func TestMonitor_DegradedRecoverySummary(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.PollTimeout = 200 * time.Millisecond
+	notifier := &recordingNotifier{}
+	m, failing := newFlakyMonitor(t, cfg, notifier)
+
+	// Three errors enter degraded mode and two more follow
+	failing.Store(true)
+	for i := 0; i < 5; i++ {
+		m.poll()
+	}
+	if !m.getDegradedMode() {
+		t.Fatal("monitor not in degraded mode after consecutive failures")
+	}
+	m.degraded.since = time.Now().Add(-12 * time.Minute)
+
+	recovered := newMockOctopusServer(t)
+	m.OctopusClient = octopus.NewClientWithEndpoint("test_key", "A-12345678", recovered.URL)
+	if err := m.OctopusClient.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	m.setLastPollTime(time.Now().Add(-cfg.PollInterval))
+	m.poll()
+
+	if m.getDegradedMode() {
+		t.Fatal("monitor still in degraded mode after a successful poll")
+	}
+	want := "info|Octopus API|Recovered from degraded mode after 12m0s (5 errors)"
+	found := false
+	for _, call := range notifier.Calls() {
+		if strings.HasPrefix(call, want) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("notifications = %v, want one starting %q", notifier.Calls(), want)
+	}
+}
+
+func TestNew_NilNotifierUsesNop(t *testing.T) {
+	m := newTestMonitor(t)
+
+	if m.Notifier == nil {
+		t.Fatal("Notifier is nil, want no-op notifier")
+	}
+
+	// Must not panic
+	m.NotifyError("Test", "message")
+	m.NotifyWarning("Test", "message")
+	m.NotifyInfo("Test", "message")
+}
+
+func TestMonitor_SkipsPollWhenCircuitOpen(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.PollTimeout = 200 * time.Millisecond
+	cfg.ConsecutiveErrorThreshold = 5
+	cfg.CircuitOpenSkipPoll = true
+	notifier := &recordingNotifier{}
+	m, failing := newFlakyMonitor(t, cfg, notifier)
+
+	// Three failures trip the Octopus circuit breaker
+	failing.Store(true)
+	for i := 0; i < 3; i++ {
+		m.poll()
+	}
+	if got := m.getConsecutiveErr(); got != 3 {
+		t.Fatalf("consecutive errors after failures = %d, want 3", got)
+	}
+
+	// Polls rejected by the open breaker are skipped without counting as errors
+	for i := 0; i < 5; i++ {
+		m.poll()
+	}
+
+	if got := m.getConsecutiveErr(); got != 3 {
+		t.Errorf("consecutive errors with breaker open = %d, want 3", got)
+	}
+	if m.getDegradedMode() {
+		t.Error("monitor entered degraded mode while the circuit breaker was open")
+	}
+	if calls := notifier.Calls(); len(calls) != 0 {
+		t.Errorf("notifications = %v, want none", calls)
+	}
+}
+
+// mockInflux is an InfluxDB stub that passes health checks and records the writes it accepts
+type mockInflux struct {
+	*httptest.Server
+	lines atomic.Int64 // Line protocol lines accepted
+	down  atomic.Bool  // While set, every request fails with 503
+
+	mu     sync.Mutex
+	bodies []string // Accepted write request bodies, in order
+}
+
+// newMockInfluxServer returns an InfluxDB stub that accepts every write
+func newMockInfluxServer(t *testing.T) *mockInflux {
+	t.Helper()
+	return newHookedInfluxServer(t, nil)
+}
+
+// newHookedInfluxServer is like newMockInfluxServer, but first passes each write body to
+// hook, which returns true if it has answered the request itself, e.g. with an error
+func newHookedInfluxServer(t *testing.T, hook func(w http.ResponseWriter, body string) bool) *mockInflux {
+	t.Helper()
+
+	mock := &mockInflux{}
+	mock.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if mock.down.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/health", "/ping":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"influxdb","status":"pass"}`))
+		case "/api/v2/write":
+			data, _ := io.ReadAll(r.Body)
+			body := strings.TrimSpace(string(data))
+			if hook != nil && hook(w, body) {
+				return
+			}
+			mock.mu.Lock()
+			mock.bodies = append(mock.bodies, body)
+			mock.mu.Unlock()
+			mock.lines.Add(int64(len(strings.Split(body, "\n"))))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(mock.Server.Close)
+
+	return mock
+}
+
+// written returns the accepted write bodies
+func (m *mockInflux) written() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.bodies...)
+}
+
+// timestamps returns the timestamp of every accepted line, in write order
+func (m *mockInflux) timestamps() []int64 {
+	var timestamps []int64
+	for _, body := range m.written() {
+		for _, line := range strings.Split(body, "\n") {
+			fields := strings.Fields(line)
+			ts, _ := strconv.ParseInt(fields[len(fields)-1], 10, 64)
+			timestamps = append(timestamps, ts)
+		}
+	}
+	return timestamps
+}
+
+// newTelemetryOctopusServer is like newMockOctopusServer but returns the given
+// comma-separated telemetry readings
+func newTelemetryOctopusServer(t *testing.T, readingsJSON string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{
+			"data": {
+				"obtainKrakenToken": {"token": "test_token"},
+				"account": {
+					"electricityAgreements": [{
+						"meterPoint": {
+							"meters": [{"smartDevices": [{"deviceId": "test_device"}]}]
+						}
+					}]
+				},
+				"smartMeterTelemetry": [%s]
+			}
+		}`, readingsJSON)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestMonitor_MaxPointsPerPoll(t *testing.T) {
+	const returned = 25
+	start := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	readings := make([]string, 0, returned)
+	for i := 0; i < returned; i++ {
+		readings = append(readings, fmt.Sprintf(
+			`{"readAt": %q, "consumptionDelta": 0.01, "demand": 500, "costDelta": 0.002, "consumption": %d}`,
+			start.Add(time.Duration(i)*10*time.Second).Format(time.RFC3339), i))
+	}
+
+	octopusServer := newTelemetryOctopusServer(t, strings.Join(readings, ","))
+	influxServer := newMockInfluxServer(t)
+
+	cfg := newTestConfig()
+	cfg.MaxPointsPerPoll = 10
+	m := newInfluxTestMonitor(t, cfg, octopusServer.URL, influxServer.URL)
+
+	m.poll()
+
+	if got := influxServer.lines.Load(); got != 10 {
+		t.Errorf("points written inline = %d, want 10", got)
+	}
+	if got := m.Cache.Count(); got != returned-10 {
+		t.Errorf("points cached = %d, want %d", got, returned-10)
+	}
+
+	// Remaining budget on later polls drains the cache incrementally
+	influxServer.lines.Store(0)
+	m.syncCacheBatch(context.Background(), 10)
+
+	if got := influxServer.lines.Load(); got != 10 {
+		t.Errorf("points synced from cache = %d, want 10", got)
+	}
+	if got := m.Cache.Count(); got != returned-20 {
+		t.Errorf("points remaining in cache = %d, want %d", got, returned-20)
+	}
+}
+
+func TestMonitor_MaxPointAge(t *testing.T) {
+	now := time.Now().UTC()
+	var readings []string
+	for _, age := range []time.Duration{72 * time.Hour, 2 * time.Minute, 49 * time.Hour, time.Minute, 30 * 24 * time.Hour} {
+		readings = append(readings, fmt.Sprintf(
+			`{"readAt": %q, "consumptionDelta": 0.01, "demand": 500, "costDelta": 0.002, "consumption": 10}`,
+			now.Add(-age).Format(time.RFC3339)))
+	}
+
+	octopusServer := newTelemetryOctopusServer(t, strings.Join(readings, ","))
+	influxServer := newMockInfluxServer(t)
+
+	cfg := newTestConfig()
+	cfg.MaxPointAge = 24 * time.Hour
+	m := newInfluxTestMonitor(t, cfg, octopusServer.URL, influxServer.URL)
+
+	m.poll()
+
+	if got := influxServer.lines.Load(); got != 2 {
+		t.Errorf("points written = %d, want only the 2 recent points", got)
+	}
+	if got := m.Cache.Count(); got != 0 {
+		t.Errorf("points cached = %d, want 0", got)
+	}
+}
+
+func TestMonitor_CacheSyncOrder(t *testing.T) {
+	tests := []struct {
+		order string
+		want  []int64
+	}{
+		{order: config.CacheSyncOldest, want: []int64{0, 1, 2, 3, 4}},
+		{order: config.CacheSyncNewest, want: []int64{4, 3, 2, 1, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			influxServer := newMockInfluxServer(t)
+
+			cfg := newTestConfig()
+			cfg.CacheSyncOrder = tt.order
+			m := newInfluxTestMonitor(t, cfg, "", influxServer.URL)
+
+			// Insert out of order so the sync has to sort
+			addCachedPoints(t, m.Cache, 100, time.Unix(2, 0), time.Unix(0, 0), time.Unix(4, 0), time.Unix(1, 0), time.Unix(3, 0))
+
+			// An incremental batch takes points from the configured end of the backlog
+			m.syncCacheBatch(context.Background(), 2)
+			if got := m.Cache.Count(); got != 3 {
+				t.Fatalf("points remaining after batch = %d, want 3", got)
+			}
+
+			m.SyncCache()
+			if m.Cache.Count() != 0 {
+				t.Fatalf("points remaining after full sync = %d, want 0", m.Cache.Count())
+			}
+
+			written := influxServer.timestamps()
+			if len(written) != len(tt.want) {
+				t.Fatalf("written timestamps = %v, want %v", written, tt.want)
+			}
+			for i, want := range tt.want {
+				if written[i] != want*int64(time.Second) {
+					t.Errorf("write %d timestamp = %d, want %ds", i, written[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestMonitor_DrainWaitsForInFlightPoll(t *testing.T) {
+	pollStarted := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "smartMeterTelemetry") {
+			once.Do(func() { close(pollStarted) })
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"data": {
+				"obtainKrakenToken": {"token": "test_token"},
+				"account": {
+					"electricityAgreements": [{
+						"meterPoint": {
+							"meters": [{"smartDevices": [{"deviceId": "test_device"}]}]
+						}
+					}]
+				},
+				"smartMeterTelemetry": []
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig()
+	cfg.PollInterval = 10 * time.Millisecond
+	m := newInfluxTestMonitor(t, cfg, server.URL, "")
+
+	stopChan := make(chan struct{})
+	runDone := make(chan struct{})
+	go func() {
+		m.Run(stopChan)
+		close(runDone)
+	}()
+	defer close(stopChan)
+
+	select {
+	case <-pollStarted:
+	case <-time.After(2 * time.Second):
+		t.Fatal("poll did not start")
+	}
+
+	// The poll is blocked, so a short drain must time out
+	if m.Drain(50 * time.Millisecond) {
+		t.Fatal("Drain() = true while a poll is in flight, want false")
+	}
+
+	drained := make(chan bool)
+	go func() {
+		drained <- m.Drain(2 * time.Second)
+	}()
+
+	select {
+	case <-drained:
+		t.Fatal("Drain() returned before the in-flight poll finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	select {
+	case ok := <-drained:
+		if !ok {
+			t.Error("Drain() = false after the poll finished, want true")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Drain() did not return after the poll finished")
+	}
+
+	// No new polls start once draining, so Run exits on its next tick
+	select {
+	case <-runDone:
+	case <-time.After(2 * time.Second):
+		t.Error("Run() kept polling after Drain()")
+	}
+}
+
+// newCountingInfluxServer is a mock InfluxDB that accepts writes and answers every
+// query with the given point count
+func newCountingInfluxServer(t *testing.T, count int) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"influxdb","status":"pass"}`))
+		case "/api/v2/write":
+			w.WriteHeader(http.StatusNoContent)
+		case "/api/v2/query":
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			fmt.Fprintf(w, "#datatype,string,long,long\r\n#group,false,false,false\r\n#default,_result,,\r\n,result,table,_value\r\n,,0,%d\r\n\r\n", count)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestMonitor_VerifyCacheSync(t *testing.T) {
+	tests := []struct {
+		name        string
+		stored      int
+		wantCleared bool
+	}{
+		{name: "all points stored", stored: 3, wantCleared: true},
+		{name: "points missing", stored: 2, wantCleared: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newCountingInfluxServer(t, tt.stored)
+
+			cfg := newTestConfig()
+			cfg.VerifyCacheSync = true
+			m := newInfluxTestMonitor(t, cfg, "", server.URL)
+			m.Notifier = &recordingNotifier{}
+
+			base := time.Now().Add(-time.Hour).Truncate(time.Second)
+			addCachedPoints(t, m.Cache, 100, base, base.Add(10*time.Second), base.Add(20*time.Second))
+
+			m.SyncCache()
+
+			if cleared := m.Cache.Count() == 0; cleared != tt.wantCleared {
+				t.Errorf("cache cleared = %v (count %d), want %v", cleared, m.Cache.Count(), tt.wantCleared)
+			}
+			if !tt.wantCleared {
+				if _, ok := m.LastErrors()[ComponentInfluxDB]; !ok {
+					t.Error("verification failure not recorded in LastErrors()")
+				}
+			}
+		})
+	}
+}
+
+func TestMonitor_ExportFields(t *testing.T) {
+	readAt := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	octopusServer := newTelemetryOctopusServer(t, fmt.Sprintf(
+		`{"readAt": %q, "consumptionDelta": -0.4, "demand": -2400, "costDelta": -0.06, "consumption": 1234.5}`, readAt))
+	influxServer := newMockInfluxServer(t)
+
+	m := newInfluxTestMonitor(t, newTestConfig(), octopusServer.URL, influxServer.URL)
+	m.InfluxClient.SetExportFields(true)
+
+	m.poll()
+	m.InfluxClient.Flush()
+
+	written := strings.Join(influxServer.written(), "\n")
+	for _, want := range []string{"export_kwh=0.4", "import_kwh=0", "consumption_delta=-0.4"} {
+		if !strings.Contains(written, want) {
+			t.Errorf("written line protocol %q missing %q", written, want)
+		}
+	}
+}
+
+func TestMonitor_SyncCacheKeepsPointsCachedDuringSync(t *testing.T) {
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	late := cache.DataPoint{Timestamp: base.Add(5 * time.Second), Demand: 999}
+
+	// Cache a point inside the synced range while the first write is in flight
+	var m *Monitor
+	var once sync.Once
+	server := newHookedInfluxServer(t, func(w http.ResponseWriter, body string) bool {
+		once.Do(func() {
+			if err := m.Cache.AddSingle(late); err != nil {
+				t.Errorf("AddSingle() error = %v", err)
+			}
+		})
+		return false
+	})
+
+	m = newInfluxTestMonitor(t, newTestConfig(), "", server.URL)
+	addCachedPoints(t, m.Cache, 100, base, base.Add(10*time.Second), base.Add(20*time.Second))
+
+	m.SyncCache()
+
+	remaining := m.Cache.GetAll()
+	if len(remaining) != 1 || remaining[0].Demand != late.Demand {
+		t.Errorf("cache after sync = %+v, want only the point cached during the sync", remaining)
+	}
+}
+
+func TestAlignDelay(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		now      time.Time
+		interval time.Duration
+		want     time.Duration
+	}{
+		{name: "mid interval", now: base.Add(12 * time.Second), interval: 30 * time.Second, want: 18 * time.Second},
+		{name: "just after boundary", now: base.Add(30*time.Second + time.Millisecond), interval: 30 * time.Second, want: 30*time.Second - time.Millisecond},
+		{name: "on boundary waits a full interval", now: base, interval: 30 * time.Second, want: 30 * time.Second},
+		{name: "backed-off interval", now: base.Add(100 * time.Second), interval: 2 * time.Minute, want: 20 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := alignDelay(tt.now, tt.interval); got != tt.want {
+				t.Errorf("alignDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonitor_NextPollDelay(t *testing.T) {
+	interval := 30 * time.Second
+
+	m := &Monitor{Cfg: &config.Config{PollInterval: interval}}
+	if got := m.nextPollDelay(interval); got != interval {
+		t.Errorf("nextPollDelay() without alignment = %v, want %v", got, interval)
+	}
+
+	m.Cfg.AlignPolls = true
+	delay := m.nextPollDelay(interval)
+	if delay <= 0 || delay > interval {
+		t.Fatalf("nextPollDelay() = %v, want within (0, %v]", delay, interval)
+	}
+	firstPoll := time.Now().Add(delay)
+	if offset := firstPoll.Sub(firstPoll.Truncate(interval)); offset > 50*time.Millisecond && offset < interval-50*time.Millisecond {
+		t.Errorf("first poll at %v is %v past a %v boundary, want aligned", firstPoll, offset, interval)
+	}
+}
+
+func TestMonitor_PollTimingBreakdown(t *testing.T) {
+	reading := fmt.Sprintf(`{"readAt": %q, "consumptionDelta": 0.01, "demand": 500, "costDelta": 0.002, "consumption": 1}`,
+		time.Now().Add(-time.Minute).UTC().Format(time.RFC3339))
+	octopusServer := newTelemetryOctopusServer(t, reading)
+
+	influxServer := newMockInfluxServer(t)
+
+	m := newInfluxTestMonitor(t, newTestConfig(), "", influxServer.URL)
+	// Not initialized, so the first poll authenticates and discovers the meter
+	m.OctopusClient = octopus.NewClientWithEndpoint("test_key", "A-12345678", octopusServer.URL)
+
+	var buf bytes.Buffer
+	originalLogger := log.Logger
+	defer func() { log.Logger = originalLogger }()
+
+	breakdown := func() map[string]interface{} {
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var entry map[string]interface{}
+			if json.Unmarshal([]byte(line), &entry) == nil && entry["message"] == "Poll timing breakdown" {
+				return entry
+			}
+		}
+		return nil
+	}
+
+	log.Logger = zerolog.New(&buf).Level(zerolog.DebugLevel)
+	m.poll()
+
+	first := breakdown()
+	if first == nil {
+		t.Fatalf("no timing breakdown logged at debug level:\n%s", buf.String())
+	}
+	if first["level"] != "debug" {
+		t.Errorf("breakdown level = %v, want debug", first["level"])
+	}
+	for _, field := range []string{"auth", "meter_discovery", "telemetry_fetch", "influx_health_check", "write", "total"} {
+		if _, ok := first[field]; !ok {
+			t.Errorf("breakdown missing %q: %v", field, first)
+		}
+	}
+
+	// Info level keeps the breakdown out of the logs
+	buf.Reset()
+	log.Logger = zerolog.New(&buf).Level(zerolog.InfoLevel)
+	m.poll()
+	if breakdown() != nil {
+		t.Errorf("timing breakdown logged at info level:\n%s", buf.String())
+	}
+
+	// Once authenticated and discovered, those phases no longer run and are omitted
+	buf.Reset()
+	log.Logger = zerolog.New(&buf).Level(zerolog.DebugLevel)
+	m.poll()
+	later := breakdown()
+	if later == nil {
+		t.Fatalf("no timing breakdown logged at debug level:\n%s", buf.String())
+	}
+	for _, field := range []string{"auth", "meter_discovery"} {
+		if _, ok := later[field]; ok {
+			t.Errorf("breakdown includes %q for a phase that did not run: %v", field, later)
+		}
+	}
+	if _, ok := later["telemetry_fetch"]; !ok {
+		t.Errorf("breakdown missing telemetry_fetch: %v", later)
+	}
+}
+
+func TestMonitor_CacheMinFreeDisk(t *testing.T) {
+	notifier := &recordingNotifier{}
+	m := newTestMonitor(t)
+	m.Notifier = notifier
+	m.Cfg.CacheMinFreeDiskMB = 100
+
+	free := uint64(50 * bytesPerMB)
+	m.freeDiskSpace = func(dir string) (uint64, error) { return free, nil }
+
+	reading := []octopus.TelemetryData{{ReadAt: time.Now(), Demand: 500}}
+	m.cacheData(context.Background(), reading)
+	m.cacheData(context.Background(), reading)
+
+	if got := m.Cache.Count(); got != 0 {
+		t.Errorf("points cached below threshold = %d, want 0", got)
+	}
+	if got := notifier.Calls(); len(got) != 1 || !strings.HasPrefix(got[0], "error|Cache|Caching halted") {
+		t.Errorf("notifications = %v, want a single caching halted error", got)
+	}
+
+	free = 200 * bytesPerMB
+	m.cacheData(context.Background(), reading)
+
+	if got := m.Cache.Count(); got != 1 {
+		t.Errorf("points cached after space recovered = %d, want 1", got)
+	}
+	if got := notifier.Calls(); len(got) != 2 || !strings.HasPrefix(got[1], "info|Cache|") {
+		t.Errorf("notifications = %v, want a recovery info message", got)
+	}
+}
+
+func TestMonitor_CacheMinFreeDisk_DropOldest(t *testing.T) {
+	m := newTestMonitor(t)
+	m.Cfg.CacheMinFreeDiskMB = 100
+	m.Cfg.CacheDropOldestOnLowDisk = true
+
+	oldFile := filepath.Join(m.Cache.Dir(), "cache_2024-01-01.json")
+	if err := os.WriteFile(oldFile, []byte(`[]`), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	// Removing the old file frees enough space
+	m.freeDiskSpace = func(dir string) (uint64, error) {
+		if _, err := os.Stat(oldFile); err == nil {
+			return 50 * bytesPerMB, nil
+		}
+		return 200 * bytesPerMB, nil
+	}
+
+	m.cacheData(context.Background(), []octopus.TelemetryData{{ReadAt: time.Now(), Demand: 500}})
+
+	if _, err := os.Stat(oldFile); !os.IsNotExist(err) {
+		t.Errorf("oldest cache file still present, stat error = %v", err)
+	}
+	if got := m.Cache.Count(); got != 1 {
+		t.Errorf("points cached after dropping oldest file = %d, want 1", got)
+	}
+}
+
+func TestMonitor_CleanupCacheBySize(t *testing.T) {
+	m := newTestMonitor(t)
+	m.Cfg.CacheRetentionDays = 7
+	m.Cfg.CacheMaxSizeMB = 1
+
+	dir := m.Cache.Dir()
+	older := filepath.Join(dir, "cache_2024-01-01.json")
+	newer := filepath.Join(dir, "cache_2024-01-02.json")
+	for _, file := range []string{older, newer} {
+		if err := os.WriteFile(file, bytes.Repeat([]byte(" "), 800*1024), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	m.cleanupCache()
+
+	if _, err := os.Stat(older); !os.IsNotExist(err) {
+		t.Errorf("oldest cache file still present, stat error = %v", err)
+	}
+	if _, err := os.Stat(newer); err != nil {
+		t.Errorf("newer cache file removed: %v", err)
+	}
+}
+
+func TestMonitor_InfluxWriteMode(t *testing.T) {
+	start := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	readings := make([]string, 0, 3)
+	for i := 0; i < 3; i++ {
+		readings = append(readings, fmt.Sprintf(
+			`{"readAt": %q, "consumptionDelta": 0.01, "demand": %d, "costDelta": 0.002, "consumption": %d}`,
+			start.Add(time.Duration(i)*10*time.Second).Format(time.RFC3339), 100*(i+1), i))
+	}
+	octopusServer := newTelemetryOctopusServer(t, strings.Join(readings, ","))
+
+	tests := []struct {
+		mode      string
+		wantLines int64
+	}{
+		{mode: "", wantLines: 3},
+		{mode: config.WriteModeRaw, wantLines: 3},
+		{mode: config.WriteModeSummary, wantLines: 1},
+		{mode: config.WriteModeBoth, wantLines: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run("mode="+tt.mode, func(t *testing.T) {
+			influxServer := newMockInfluxServer(t)
+
+			cfg := newTestConfig()
+			cfg.InfluxDBMeasurement = "measurement"
+			cfg.InfluxDBWriteMode = tt.mode
+			m := newInfluxTestMonitor(t, cfg, octopusServer.URL, influxServer.URL)
+
+			m.poll()
+
+			if got := influxServer.lines.Load(); got != tt.wantLines {
+				t.Errorf("lines written = %d, want %d", got, tt.wantLines)
+			}
+			if got := m.Cache.Count(); got != 0 {
+				t.Errorf("points cached = %d, want 0", got)
+			}
+		})
+	}
+}
+
+func TestMonitor_SyncCacheSummaryMode(t *testing.T) {
+	influxServer := newMockInfluxServer(t)
+
+	cfg := newTestConfig()
+	cfg.InfluxDBMeasurement = "measurement"
+	cfg.InfluxDBWriteMode = config.WriteModeSummary
+	m := newInfluxTestMonitor(t, cfg, "", influxServer.URL)
+
+	base := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	addCachedPoints(t, m.Cache, 100, base, base.Add(10*time.Second), base.Add(20*time.Second), base.Add(30*time.Second), base.Add(40*time.Second))
+
+	// An incremental batch and the full sync of the rest are each written as one summary point
+	m.syncCacheBatch(context.Background(), 2)
+	m.SyncCache()
+
+	written := influxServer.written()
+	if len(written) != 2 {
+		t.Fatalf("writes = %q, want one summary point per synced batch", written)
+	}
+	for i, wantCount := range []string{"count=2i", "count=3i"} {
+		if !strings.HasPrefix(written[i], "measurement_summary,") || !strings.Contains(written[i], wantCount) {
+			t.Errorf("write %d = %q, want a summary point with %s", i, written[i], wantCount)
+		}
+	}
+	if got := m.Cache.Count(); got != 0 {
+		t.Errorf("points remaining in cache = %d, want 0", got)
+	}
+}
+
+func TestMonitor_PollCorrelationID(t *testing.T) {
+	m := newTestMonitor(t)
+
+	var buf bytes.Buffer
+	originalLogger := log.Logger
+	log.Logger = zerolog.New(&buf)
+	defer func() { log.Logger = originalLogger }()
+
+	m.poll()
+	m.poll()
+
+	var startIDs, endIDs []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry struct {
+			Message string `json:"message"`
+			PollID  string `json:"poll_id"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		switch entry.Message {
+		case "Polling for telemetry data":
+			startIDs = append(startIDs, entry.PollID)
+		case "No new telemetry data available":
+			endIDs = append(endIDs, entry.PollID)
+		}
+	}
+
+	if len(startIDs) != 2 || len(endIDs) != 2 {
+		t.Fatalf("start/end lines = %d/%d, want 2/2\n%s", len(startIDs), len(endIDs), buf.String())
+	}
+	for i := range startIDs {
+		if startIDs[i] == "" || startIDs[i] != endIDs[i] {
+			t.Errorf("poll %d: start poll_id %q, end poll_id %q, want the same non-empty ID", i, startIDs[i], endIDs[i])
+		}
+	}
+	if startIDs[0] == startIDs[1] {
+		t.Errorf("both polls logged poll_id %q, want a new ID per poll", startIDs[0])
+	}
+}
+
+func TestMonitor_CacheSyncStats(t *testing.T) {
+	const points, accepted = 5, 3
+
+	var writes atomic.Int64
+	influxServer := newHookedInfluxServer(t, func(w http.ResponseWriter, body string) bool {
+		if writes.Add(1) > accepted {
+			http.Error(w, `{"code":"invalid","message":"rejected"}`, http.StatusBadRequest)
+			return true
+		}
+		return false
+	})
+
+	m := newInfluxTestMonitor(t, newTestConfig(), "", influxServer.URL)
+	for sec := int64(0); sec < points; sec++ {
+		addCachedPoints(t, m.Cache, 100, time.Unix(sec, 0))
+	}
+
+	m.SyncCache()
+
+	stats := m.CacheSyncStats()
+	if stats.PointsSynced != accepted || stats.PointsFailed != points-accepted {
+		t.Errorf("synced/failed = %d/%d, want %d/%d", stats.PointsSynced, stats.PointsFailed, accepted, points-accepted)
+	}
+	if stats.Duration.Count != 1 || stats.Duration.Buckets["+Inf"] != 1 {
+		t.Errorf("duration count = %d, +Inf bucket = %d, want 1", stats.Duration.Count, stats.Duration.Buckets["+Inf"])
+	}
+	if stats.Duration.Buckets["300"] != 1 {
+		t.Errorf("300s bucket = %d, want 1 (buckets are cumulative)", stats.Duration.Buckets["300"])
+	}
+
+	// The same stats rendered for /metrics
+	var metrics strings.Builder
+	stats.WriteMetrics(&metrics)
+	rendered := metrics.String()
+	for _, want := range []string{
+		"# TYPE octopus_cache_sync_duration_seconds histogram\n",
+		`octopus_cache_sync_duration_seconds_bucket{le="300"} 1` + "\n",
+		`octopus_cache_sync_duration_seconds_bucket{le="+Inf"} 1` + "\n",
+		"octopus_cache_sync_duration_seconds_sum ",
+		"octopus_cache_sync_duration_seconds_count 1\n",
+		fmt.Sprintf("octopus_cache_sync_points_synced_total %d\n", accepted),
+		fmt.Sprintf("octopus_cache_sync_points_failed_total %d\n", points-accepted),
+	} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("metrics missing %q:\n%s", want, rendered)
+		}
+	}
+	if strings.Index(rendered, `le="0.1"`) > strings.Index(rendered, `le="+Inf"`) {
+		t.Errorf("buckets out of order:\n%s", rendered)
+	}
+
+	// The empty cache skips the sync entirely and leaves the stats untouched
+	if err := m.Cache.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	m.SyncCache()
+	if got := m.CacheSyncStats().Duration.Count; got != 1 {
+		t.Errorf("duration count after empty sync = %d, want 1", got)
+	}
+}
+
+func TestMonitor_CatchUpSummary(t *testing.T) {
+	const points, failAt = 10, 5
+
+	// One intermittent failure part way through the backlog
+	var writes atomic.Int64
+	influxServer := newHookedInfluxServer(t, func(w http.ResponseWriter, body string) bool {
+		if writes.Add(1) == failAt {
+			http.Error(w, `{"code":"invalid","message":"rejected"}`, http.StatusBadRequest)
+			return true
+		}
+		return false
+	})
+
+	cfg := newTestConfig()
+	cfg.CatchUpThreshold = 5
+	cfg.CatchUpChunkSize = 3
+	m := newInfluxTestMonitor(t, cfg, "", influxServer.URL)
+	notifier := &recordingNotifier{}
+	m.Notifier = notifier
+	for sec := int64(0); sec < points; sec++ {
+		addCachedPoints(t, m.Cache, 100, time.Unix(sec, 0))
+	}
+
+	// The first sync writes one chunk before the failure; the chunk stays synced
+	m.SyncCache()
+	if got := m.Cache.Count(); got != points-3 {
+		t.Fatalf("cached points after interrupted catch-up = %d, want %d", got, points-3)
+	}
+	if m.catchUp == nil {
+		t.Fatal("catch-up mode ended before the backlog was synced")
+	}
+
+	// The next sync resumes the catch-up and drains the cache
+	m.SyncCache()
+	if got := m.Cache.Count(); got != 0 {
+		t.Fatalf("cached points after catch-up = %d, want 0", got)
+	}
+	if m.catchUp != nil {
+		t.Error("catch-up mode still active with an empty cache")
+	}
+
+	calls := notifier.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("notifications = %q, want a start and a finish summary only", calls)
+	}
+	if !strings.HasPrefix(calls[0], "info|Catch-Up|Catching up 10 cached data points") {
+		t.Errorf("first notification = %q, want the catch-up start summary", calls[0])
+	}
+	if !strings.HasPrefix(calls[1], "info|Catch-Up|Caught up: synced 10 cached data points") ||
+		!strings.Contains(calls[1], "(1 routine alerts withheld)") {
+		t.Errorf("second notification = %q, want the catch-up finish summary", calls[1])
+	}
+}
+
+func TestMonitor_CatchUpAlertsOnOutage(t *testing.T) {
+	influxServer := newMockInfluxServer(t)
+	m := newInfluxTestMonitor(t, newTestConfig(), "", influxServer.URL)
+	notifier := &recordingNotifier{}
+	m.Notifier = notifier
+	m.catchUp = &catchUp{started: time.Now(), backlog: 1000}
+	m.updateInfluxHealth(true, "test")
+
+	// Losing InfluxDB part way through a catch-up is an outage, not a routine flap
+	influxServer.down.Store(true)
+	m.InfluxClient.ExpireHealthCache()
+	m.checkInfluxHealth(context.Background())
+
+	calls := notifier.Calls()
+	if len(calls) != 1 || !strings.HasPrefix(calls[0], "error|InfluxDB|Connection to InfluxDB lost") {
+		t.Errorf("notifications = %q, want the connection lost alert during a catch-up", calls)
+	}
+	if m.catchUp.suppressed != 0 {
+		t.Errorf("suppressed alerts = %d, want 0", m.catchUp.suppressed)
+	}
+}
+
+func TestMonitor_PollClockSkew(t *testing.T) {
+	m := newTestMonitor(t)
+	notifier := &recordingNotifier{}
+	m.Notifier = notifier
+
+	var buf bytes.Buffer
+	originalLogger := log.Logger
+	log.Logger = zerolog.New(&buf)
+	defer func() { log.Logger = originalLogger }()
+
+	// A backward jump leaves the last poll time ahead of the host clock. Strip the
+	// monotonic reading, as a real wall clock jump would not show up in it.
+	m.setLastPollTime(time.Now().Add(10 * time.Minute).Round(0))
+
+	before := time.Now()
+	m.poll()
+
+	var start, end time.Time
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry struct {
+			Message string    `json:"message"`
+			Start   time.Time `json:"start"`
+			End     time.Time `json:"end"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err == nil && entry.Message == "Polling for telemetry data" {
+			start, end = entry.Start, entry.End
+		}
+	}
+
+	if !start.Before(end) {
+		t.Fatalf("poll range %s to %s is not positive", start, end)
+	}
+	if want := before.Add(-m.Cfg.PollInterval); start.Before(want.Add(-time.Second)) || start.After(end) {
+		t.Errorf("poll start = %s, want about one poll interval before %s", start, end)
+	}
+	if last := m.LastPollTime(); last.Round(0).After(time.Now().Round(0)) {
+		t.Errorf("LastPollTime() = %s, still ahead of the clock", last)
+	}
+
+	calls := notifier.Calls()
+	if len(calls) != 1 || !strings.HasPrefix(calls[0], "warning|Clock|System clock moved backward") {
+		t.Errorf("notifications = %v, want one clock warning", calls)
+	}
+
+	// A jump during an Octopus outage is still only alerted once
+	failingNotifier := &recordingNotifier{}
+	failingCfg := newTestConfig()
+	failingCfg.PollTimeout = 200 * time.Millisecond
+	failingMonitor, failing := newFlakyMonitor(t, failingCfg, failingNotifier)
+	failing.Store(true)
+	failingMonitor.setLastPollTime(time.Now().Add(10 * time.Minute).Round(0))
+	for i := 0; i < 2; i++ {
+		failingMonitor.poll()
+	}
+
+	clockWarnings := 0
+	for _, call := range failingNotifier.Calls() {
+		if strings.HasPrefix(call, "warning|Clock|") {
+			clockWarnings++
+		}
+	}
+	if clockWarnings != 1 {
+		t.Errorf("clock warnings = %d over failed polls, want 1 (%v)", clockWarnings, failingNotifier.Calls())
+	}
+}
+
+func TestMonitor_PollSyncsCacheWhenHealthy(t *testing.T) {
+	const cached, batchSize = 5, 3
+	now := time.Now().UTC().Truncate(time.Second)
+
+	octopusServer := newTelemetryOctopusServer(t, fmt.Sprintf(
+		`{"readAt": %q, "consumptionDelta": 0.01, "demand": 500, "costDelta": 0.002, "consumption": 10}`,
+		now.Add(-time.Minute).Format(time.RFC3339)))
+	influxServer := newMockInfluxServer(t)
+
+	cfg := newTestConfig()
+	cfg.CacheSyncBatchSize = batchSize
+	m := newInfluxTestMonitor(t, cfg, octopusServer.URL, influxServer.URL)
+	for i := 0; i < cached; i++ {
+		addCachedPoints(t, m.Cache, 100, now.Add(-time.Hour+time.Duration(i)*10*time.Second))
+	}
+
+	m.poll()
+
+	if got := influxServer.lines.Load(); got != 1+batchSize {
+		t.Errorf("points written = %d, want 1 polled + %d synced", got, batchSize)
+	}
+	if got := m.Cache.Count(); got != cached-batchSize {
+		t.Errorf("points remaining in cache = %d, want %d", got, cached-batchSize)
+	}
+
+	// With opportunistic sync disabled the backlog waits for a reconnect
+	cfg.CacheSyncBatchSize = 0
+	influxServer.lines.Store(0)
+	m.poll()
+
+	if got := m.Cache.Count(); got != cached-batchSize {
+		t.Errorf("points remaining in cache with sync disabled = %d, want %d", got, cached-batchSize)
+	}
+}
+
+func TestMonitor_RecentStatsFallback(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	octopusServer := newTelemetryOctopusServer(t, fmt.Sprintf(
+		`{"readAt": %q, "consumptionDelta": 0.25, "demand": 800, "costDelta": 0.05, "consumption": 10},
+		{"readAt": %q, "consumptionDelta": 0.5, "demand": 1200, "costDelta": 0.1, "consumption": 10.5}`,
+		now.Add(-2*time.Minute).Format(time.RFC3339), now.Add(-time.Minute).Format(time.RFC3339)))
+
+	// The mock answers queries with 404, so stats fall back to the in-memory totals
+	influxServer := newMockInfluxServer(t)
+	m := newInfluxTestMonitor(t, newTestConfig(), octopusServer.URL, influxServer.URL)
+
+	m.poll()
+
+	stats := m.RecentStats(context.Background(), time.Hour)
+	if stats.Source != RecentStatsMemory || stats.Error == "" {
+		t.Errorf("RecentStats() source = %q, error = %q, want memory with the query error", stats.Source, stats.Error)
+	}
+	if stats.Points != 2 || stats.ConsumptionDelta != 0.75 || math.Abs(stats.CostDelta-0.15) > 1e-9 || stats.DemandMax != 1200 {
+		t.Errorf("RecentStats() = %+v, want 2 points, 0.75 kWh, 0.15 cost, 1200 max demand", stats.RecentSummary)
+	}
+
+	if _, err := json.Marshal(stats); err != nil {
+		t.Errorf("json.Marshal(RecentStats) error = %v", err)
+	}
+}
+
+func TestMonitor_CacheMemoryFallback(t *testing.T) {
+	m := newTestMonitor(t)
+	m.Cfg.CacheFailureThreshold = 2
+	m.Cfg.CacheMemoryBufferPoints = 3
+	notifier := &recordingNotifier{}
+	m.Notifier = notifier
+
+	base := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	cachePoint := func(i int) {
+		m.cacheData(context.Background(), []octopus.TelemetryData{{ReadAt: base.Add(time.Duration(i) * 10 * time.Second), Demand: 100}})
+	}
+
+	// Replace the cache directory with a file, as if its volume had been unmounted
+	dir := m.Cache.Dir()
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatalf("RemoveAll() error = %v", err)
+	}
+	if err := os.WriteFile(dir, nil, 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	for i := 0; i < 6; i++ {
+		cachePoint(i)
+	}
+
+	if m.memBuffer == nil {
+		t.Fatal("in-memory fallback not engaged after repeated cache write failures")
+	}
+	if got := len(m.memBuffer.points); got != 3 || m.memBuffer.dropped != 1 {
+		t.Errorf("memory buffer holds %d points with %d dropped, want 3 and 1", got, m.memBuffer.dropped)
+	}
+
+	calls := notifier.Calls()
+	if len(calls) != 2 || !strings.HasPrefix(calls[0], "error|Cache|Failed to cache data") ||
+		!strings.HasPrefix(calls[1], "error|Cache|2 consecutive cache writes failed") {
+		t.Errorf("notifications = %v, want one failure alert then one fallback alert", calls)
+	}
+
+	// Once the directory is back the buffer is flushed ahead of the new point
+	if err := os.Remove(dir); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	cachePoint(6)
+
+	if m.memBuffer != nil {
+		t.Error("in-memory fallback still engaged after the cache directory recovered")
+	}
+	if calls := notifier.Calls(); len(calls) != 3 || !strings.HasPrefix(calls[2], "info|Cache|Cache directory writable again") {
+		t.Errorf("notifications = %v, want a recovery notice", calls)
+	}
+
+	// The points from the failed writes were kept by the cache and are saved too
+	reloaded, err := cache.NewCache(dir)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	if got := reloaded.Count(); got != 6 {
+		t.Errorf("points on disk = %d, want 2 failed writes + 3 buffered + 1 new", got)
+	}
+}
+
+// fakeWeather is a weather.Provider returning a fixed reading or error
+type fakeWeather struct {
+	reading weather.Reading
+	err     error
+}
+
+func (f fakeWeather) CurrentTemperature(ctx context.Context) (weather.Reading, error) {
+	return f.reading, f.err
+}
+
+func TestMonitor_RecordWeather(t *testing.T) {
+	influxServer := newMockInfluxServer(t)
+
+	cfg := newTestConfig()
+	cfg.WeatherMeasurement = "weather"
+	m := newInfluxTestMonitor(t, cfg, "", influxServer.URL)
+
+	observed := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	m.recordWeather(fakeWeather{reading: weather.Reading{Time: observed, TemperatureC: 4.5}})
+
+	lines := influxServer.written()
+	want := fmt.Sprintf("weather,source=octopus_home_mini temperature_c=4.5 %d", observed.UnixNano())
+	if len(lines) != 1 || lines[0] != want {
+		t.Errorf("written lines = %q, want [%q]", lines, want)
+	}
+
+	// A provider failure is recorded without writing anything or touching poll state
+	m.recordWeather(fakeWeather{err: errors.New("weather API returned status 503")})
+
+	if _, ok := m.LastErrors()[ComponentWeather]; !ok {
+		t.Error("weather failure not recorded in LastErrors")
+	}
+	if got := len(influxServer.written()); got != 1 {
+		t.Errorf("writes = %d after a provider failure, want 1", got)
+	}
+	if !m.getInfluxHealthy() || m.getConsecutiveErr() != 0 {
+		t.Error("weather failure affected InfluxDB health or the poll error count")
+	}
+}
+
+func TestMonitor_WatermarkAcrossRestart(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	reading := func(minutesAgo int) string {
+		return fmt.Sprintf(
+			`{"readAt": %q, "consumptionDelta": 0.01, "demand": 500, "costDelta": 0.002, "consumption": 10}`,
+			now.Add(-time.Duration(minutesAgo)*time.Minute).Format(time.RFC3339))
+	}
+	watermarkFile := filepath.Join(t.TempDir(), "watermark.json")
+
+	influxServer := newMockInfluxServer(t)
+
+	// Each run gets fresh clients, cache and store; only the watermark file survives
+	run := func(readingsJSON string) *Monitor {
+		octopusServer := newTelemetryOctopusServer(t, readingsJSON)
+		m := newInfluxTestMonitor(t, newTestConfig(), octopusServer.URL, influxServer.URL)
+		if err := m.SetWatermarkStore(cache.NewFileWatermarkStore(watermarkFile)); err != nil {
+			t.Fatalf("SetWatermarkStore() error = %v", err)
+		}
+		m.poll()
+		return m
+	}
+
+	first := run(strings.Join([]string{reading(4), reading(3), reading(2)}, ","))
+	if got := influxServer.lines.Load(); got != 3 {
+		t.Fatalf("points written before restart = %d, want 3", got)
+	}
+	if got, want := first.Watermark(), now.Add(-2*time.Minute); !got.Equal(want) {
+		t.Errorf("Watermark() = %v, want %v", got, want)
+	}
+
+	// After a restart the overlapping readings are skipped and only the new one is written
+	influxServer.lines.Store(0)
+	second := run(strings.Join([]string{reading(4), reading(3), reading(2), reading(1)}, ","))
+	if got := influxServer.lines.Load(); got != 1 {
+		t.Errorf("points written after restart = %d, want 1", got)
+	}
+	if got, want := second.Watermark(), now.Add(-time.Minute); !got.Equal(want) {
+		t.Errorf("Watermark() after restart = %v, want %v", got, want)
+	}
+}
+
+func TestIntervalTuner_ConvergesToCadence(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// feed returns observe's result after polls batches of perPoll readings every cadence
+	feed := func(tuner *intervalTuner, cadence time.Duration, polls, perPoll int) time.Duration {
+		next := start
+		var interval time.Duration
+		for p := 0; p < polls; p++ {
+			batch := make([]octopus.TelemetryData, perPoll)
+			for i := range batch {
+				batch[i] = octopus.TelemetryData{ReadAt: next}
+				next = next.Add(cadence)
+			}
+			interval = tuner.observe(batch)
+		}
+		return interval
+	}
+
+	tests := []struct {
+		name    string
+		cadence time.Duration
+		want    time.Duration
+	}{
+		{"faster data shortens the interval", 10 * time.Second, 10 * time.Second},
+		{"slower data lengthens the interval", 60 * time.Second, 60 * time.Second},
+		{"cadence below the minimum stops at the minimum", 5 * time.Second, 10 * time.Second},
+		{"cadence above the maximum stops at the maximum", 5 * time.Minute, 2 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tuner := newIntervalTuner(30*time.Second, 10*time.Second, 2*time.Minute)
+
+			// One poll moves only part of the way, however far off the cadence is
+			if first := feed(tuner, tt.cadence, 1, 3); first == tt.want {
+				t.Errorf("interval after one poll = %v, want a partial step toward %v", first, tt.want)
+			}
+
+			got := feed(tuner, tt.cadence, 30, 3)
+			if diff := got - tt.want; diff < -time.Second || diff > time.Second {
+				t.Errorf("interval after 30 polls = %v, want about %v", got, tt.want)
+			}
+		})
+	}
+
+	// Polls that return nothing new lengthen the interval
+	tuner := newIntervalTuner(30*time.Second, 10*time.Second, 2*time.Minute)
+	feed(tuner, 10*time.Second, 1, 3)
+	before := tuner.interval
+	if got := tuner.observe(nil); got <= before {
+		t.Errorf("interval after an empty poll = %v, want longer than %v", got, before)
+	}
+}
+
+func TestMonitor_SavingSessions(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	upcomingStart := now.Add(3 * time.Hour)
+
+	octopusServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{
+			"data": {
+				"obtainKrakenToken": {"token": "test_token"},
+				"account": {
+					"electricityAgreements": [{
+						"meterPoint": {
+							"meters": [{"smartDevices": [{"deviceId": "test_device"}]}]
+						}
+					}]
+				},
+				"smartMeterTelemetry": [{"readAt": %q, "consumptionDelta": 0.01, "demand": 500, "costDelta": 0.002, "consumption": 10}],
+				"savingSessions": {
+					"events": [
+						{"code": "EVENT_PAST", "startAt": %q, "endAt": %q},
+						{"code": "EVENT_NEXT", "startAt": %q, "endAt": %q}
+					]
+				}
+			}
+		}`,
+			now.Add(-time.Minute).Format(time.RFC3339),
+			now.Add(-48*time.Hour).Format(time.RFC3339), now.Add(-47*time.Hour).Format(time.RFC3339),
+			upcomingStart.Format(time.RFC3339), upcomingStart.Add(time.Hour).Format(time.RFC3339))
+	}))
+	defer octopusServer.Close()
+	influxServer := newMockInfluxServer(t)
+
+	cfg := newTestConfig()
+	cfg.SavingSessionsEnabled = true
+	cfg.SavingSessionsInterval = time.Hour
+	cfg.SavingSessionsMeasurement = "saving_sessions"
+	m := newInfluxTestMonitor(t, cfg, octopusServer.URL, influxServer.URL)
+	notifier := &recordingNotifier{}
+	m.Notifier = notifier
+
+	m.poll()
+
+	calls := notifier.Calls()
+	if len(calls) != 1 || !strings.HasPrefix(calls[0], "info|Saving Session|Upcoming Saving Session EVENT_NEXT") {
+		t.Errorf("notifications = %v, want one for the upcoming session only", calls)
+	}
+	// One reading plus the start and end marks of the upcoming session
+	if got := influxServer.lines.Load(); got != 3 {
+		t.Errorf("lines written = %d, want 3", got)
+	}
+
+	// A later check announces and marks each session only once
+	m.sessions.checkedAt = time.Time{}
+	influxServer.lines.Store(0)
+	m.poll()
+
+	if calls := notifier.Calls(); len(calls) != 1 {
+		t.Errorf("notifications after second check = %v, want no repeat", calls)
+	}
+	if got := influxServer.lines.Load(); got != 1 {
+		t.Errorf("lines written after second check = %d, want only the reading", got)
+	}
+}
+
+func TestMonitor_PollTraceSpans(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	var readings []string
+	for i := 3; i > 0; i-- {
+		readings = append(readings, fmt.Sprintf(
+			`{"readAt": %q, "consumptionDelta": 0.01, "demand": 500, "costDelta": 0.002, "consumption": 10}`,
+			now.Add(-time.Duration(i)*time.Minute).Format(time.RFC3339)))
+	}
+
+	octopusServer := newTelemetryOctopusServer(t, strings.Join(readings, ","))
+
+	influxServer := newMockInfluxServer(t)
+
+	cfg := newTestConfig()
+	cfg.MaxPointsPerPoll = 10
+	m := newInfluxTestMonitor(t, cfg, octopusServer.URL, influxServer.URL)
+
+	// A cached point left from an outage is drained by the poll's incremental sync
+	addCachedPoints(t, m.Cache, 100, now.Add(-time.Hour))
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer provider.Shutdown(context.Background())
+	m.tracer = provider.Tracer("test")
+
+	m.poll()
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	root, ok := spans["poll"]
+	if !ok {
+		t.Fatalf("no poll span recorded, got %v", spans)
+	}
+	if root.Status().Code == codes.Error {
+		t.Errorf("poll span status = %v, want not error", root.Status())
+	}
+	for _, name := range []string{"fetch", "write", "sync"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("no %s span recorded", name)
+			continue
+		}
+		if span.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("%s span is not a child of the poll span", name)
+		}
+	}
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range spans["sync"].Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if got := attrs["points.synced"].AsInt64(); got != 1 {
+		t.Errorf("sync span points.synced = %d, want 1", got)
+	}
+}
+
+func TestMonitor_RoundTelemetry(t *testing.T) {
+	readAt := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	server := newTelemetryOctopusServer(t, fmt.Sprintf(
+		`{"readAt": %q, "consumptionDelta": 0.123456789, "demand": 1234.5678, "costDelta": 0.0456789, "consumption": 98765.4321987}`, readAt))
+
+	three, zero := 3, 0
+	cfg := newTestConfig()
+	cfg.RoundConsumptionDelta = &three
+	cfg.RoundDemand = &zero
+	m := newInfluxTestMonitor(t, cfg, server.URL, "")
+
+	// With no InfluxDB the rounded values land in the cache
+	m.poll()
+
+	cached := m.Cache.GetAll()
+	if len(cached) != 1 {
+		t.Fatalf("cached points = %d, want 1", len(cached))
+	}
+	got := cached[0]
+	if got.ConsumptionDelta != 0.123 {
+		t.Errorf("ConsumptionDelta = %v, want 0.123", got.ConsumptionDelta)
+	}
+	if got.Demand != 1235 {
+		t.Errorf("Demand = %v, want 1235", got.Demand)
+	}
+	// Fields without a rounding setting keep full precision
+	if got.CostDelta != 0.0456789 || got.Consumption != 98765.4321987 {
+		t.Errorf("unrounded fields changed: CostDelta = %v, Consumption = %v", got.CostDelta, got.Consumption)
+	}
+}
+
+func TestMonitor_MinConsumptionDelta(t *testing.T) {
+	now := time.Now().UTC()
+	readAt := func(ago time.Duration) string { return now.Add(-ago).Format(time.RFC3339) }
+	server := newTelemetryOctopusServer(t, fmt.Sprintf(`
+		{"readAt": %q, "consumptionDelta": 0.0004, "demand": 12, "costDelta": 0.0001, "consumption": 100},
+		{"readAt": %q, "consumptionDelta": 0.25, "demand": 900, "costDelta": 0.06, "consumption": 100.25},
+		{"readAt": %q, "consumptionDelta": -0.0003, "demand": 10, "costDelta": 0, "consumption": 100.25},
+		{"readAt": %q, "consumptionDelta": 0.001, "demand": 40, "costDelta": 0.0003, "consumption": 100.251}`,
+		readAt(4*time.Minute), readAt(3*time.Minute), readAt(2*time.Minute), readAt(time.Minute)))
+
+	cfg := newTestConfig()
+	cfg.MinConsumptionDelta = 0.001
+	m := newInfluxTestMonitor(t, cfg, server.URL, "")
+
+	m.poll()
+
+	cached := m.Cache.GetAll()
+	if len(cached) != 4 {
+		t.Fatalf("cached points = %d, want 4", len(cached))
+	}
+	sort.Slice(cached, func(i, j int) bool { return cached[i].Timestamp.Before(cached[j].Timestamp) })
+
+	// Deltas below the floor, either side of zero, are zeroed; the floor itself passes
+	want := []float64{0, 0.25, 0, 0.001}
+	for i, point := range cached {
+		if point.ConsumptionDelta != want[i] {
+			t.Errorf("point %d ConsumptionDelta = %v, want %v", i, point.ConsumptionDelta, want[i])
+		}
+	}
+	// Other fields keep their raw values
+	if cached[0].Demand != 12 || cached[0].CostDelta != 0.0001 {
+		t.Errorf("noise point Demand = %v, CostDelta = %v, want raw values", cached[0].Demand, cached[0].CostDelta)
+	}
+}
+
+func TestMonitor_FieldTypeConflictAlert(t *testing.T) {
+	readAt := time.Now().UTC().Add(-time.Minute).Format(time.RFC3339)
+	octopusServer := newTelemetryOctopusServer(t, fmt.Sprintf(
+		`{"readAt": %q, "consumptionDelta": 0.01, "demand": 500, "costDelta": 0.002, "consumption": 100}`, readAt))
+
+	influxServer := newHookedInfluxServer(t, func(w http.ResponseWriter, body string) bool {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"code":"unprocessable entity","message":"partial write: field type conflict: input field \"demand\" on measurement \"measurement\" is type float, already exists as type integer dropped=1"}`))
+		return true
+	})
+
+	m := newInfluxTestMonitor(t, newTestConfig(), octopusServer.URL, influxServer.URL)
+	notifier := &recordingNotifier{}
+	m.Notifier = notifier
+
+	m.poll()
+	m.poll()
+
+	calls := notifier.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("notifications = %v, want one field type conflict alert", calls)
+	}
+	want := `error|InfluxDB Schema|Field "demand" on measurement "measurement" is written as float but InfluxDB already stores it as integer`
+	if !strings.HasPrefix(calls[0], want) {
+		t.Errorf("notification = %q, want prefix %q", calls[0], want)
+	}
+
+	// The server is reachable, so the monitor keeps writing rather than switching to cache mode
+	if !m.getInfluxHealthy() {
+		t.Error("InfluxDB marked unhealthy after a field type conflict")
+	}
+	if got := m.Cache.Count(); got == 0 {
+		t.Error("rejected points were not cached")
+	}
+}
+
+func TestMonitor_WriteRetry(t *testing.T) {
+	readAt := time.Now().UTC().Add(-time.Minute).Format(time.RFC3339)
+	octopusServer := newTelemetryOctopusServer(t, fmt.Sprintf(
+		`{"readAt": %q, "consumptionDelta": 0.01, "demand": 500, "costDelta": 0.002, "consumption": 100}`, readAt))
+
+	// The first write hits a transient server error; the retry goes through
+	var writes atomic.Int64
+	influxServer := newHookedInfluxServer(t, func(w http.ResponseWriter, body string) bool {
+		if writes.Add(1) == 1 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"code":"internal error","message":"transient failure"}`))
+			return true
+		}
+		return false
+	})
+
+	cfg := newTestConfig()
+	cfg.WriteRetryAttempts = 2
+	cfg.WriteRetryDelay = 10 * time.Millisecond
+	m := newInfluxTestMonitor(t, cfg, octopusServer.URL, influxServer.URL)
+	notifier := &recordingNotifier{}
+	m.Notifier = notifier
+
+	m.poll()
+
+	if got := writes.Load(); got != 2 {
+		t.Errorf("write requests = %d, want 2 (one failure, one retry)", got)
+	}
+	if got := m.Cache.Count(); got != 0 {
+		t.Errorf("cached points = %d, want 0 after a successful retry", got)
+	}
+	if !m.getInfluxHealthy() {
+		t.Error("InfluxDB marked unhealthy after a successful retry")
+	}
+	if calls := notifier.Calls(); len(calls) != 0 {
+		t.Errorf("notifications = %v, want none", calls)
+	}
+}
+
+func TestMonitor_SendTestAlert(t *testing.T) {
+	notifier := &recordingNotifier{}
+	m := newTestMonitor(t)
+	m.Notifier = notifier
+
+	// Without Slack there is nothing to test
+	if err := m.SendTestAlert(); err == nil {
+		t.Error("SendTestAlert() with notifications disabled error = nil, want error")
+	}
+	if calls := notifier.Calls(); len(calls) != 0 {
+		t.Errorf("notifications = %v with notifications disabled, want none", calls)
+	}
+
+	m.Cfg.SlackEnabled = true
+	if err := m.SendTestAlert(); err != nil {
+		t.Fatalf("SendTestAlert() error = %v", err)
+	}
+	if calls := notifier.Calls(); len(calls) != 1 || !strings.HasPrefix(calls[0], "info|Test Alert|") {
+		t.Errorf("notifications = %v, want one test alert", calls)
+	}
+
+	notifier.err = errors.New("slack returned server error status: 500, token=abcdef0123456789")
+	err := m.SendTestAlert()
+	if err == nil {
+		t.Fatal("SendTestAlert() error = nil for a failed delivery, want error")
+	}
+	if strings.Contains(err.Error(), "abcdef0123456789") {
+		t.Errorf("SendTestAlert() error = %q, want secrets redacted", err)
+	}
+	if _, ok := m.LastErrors()[ComponentSlack]; !ok {
+		t.Error("failed test alert not recorded as a Slack error")
+	}
+}
+
+func TestMonitor_FirstDataNotification(t *testing.T) {
+	newClient := func(readingsJSON string) *octopus.Client {
+		server := newTelemetryOctopusServer(t, readingsJSON)
+		client := octopus.NewClientWithEndpoint("test_key", "A-12345678", server.URL)
+		if err := client.Initialize(context.Background()); err != nil {
+			t.Fatalf("Initialize() error = %v", err)
+		}
+		return client
+	}
+	readAt := time.Now().UTC().Add(-time.Minute).Format(time.RFC3339)
+	idle := newClient("")
+	reporting := newClient(fmt.Sprintf(`{"readAt": %q, "consumptionDelta": 0.01, "demand": 500, "costDelta": 0.002, "consumption": 100}`, readAt))
+
+	store := cache.NewFileOnboardingStore(filepath.Join(t.TempDir(), "onboarding.json"))
+
+	cfg := newTestConfig()
+	cfg.FirstDataTimeout = time.Hour
+	m := newInfluxTestMonitor(t, cfg, "", "")
+	m.OctopusClient = idle
+	notifier := &recordingNotifier{}
+	m.Notifier = notifier
+	if err := m.SetOnboardingStore(store); err != nil {
+		t.Fatalf("SetOnboardingStore() error = %v", err)
+	}
+
+	// A new install with no data yet is expected; nothing to report within the timeout
+	m.poll()
+	if calls := notifier.Calls(); len(calls) != 0 {
+		t.Errorf("notifications while awaiting first data = %v, want none", calls)
+	}
+
+	m.OctopusClient = reporting
+	m.poll()
+	m.poll()
+
+	calls := notifier.Calls()
+	if len(calls) != 1 || !strings.HasPrefix(calls[0], "info|First Data Received|") {
+		t.Fatalf("notifications = %v, want exactly one first-data alert", calls)
+	}
+
+	// The first data point is remembered across restarts
+	restarted := New(cfg, reporting, nil, m.Cache, notifier)
+	if err := restarted.SetOnboardingStore(store); err != nil {
+		t.Fatalf("SetOnboardingStore() after restart error = %v", err)
+	}
+	restarted.poll()
+	if calls := notifier.Calls(); len(calls) != 1 {
+		t.Errorf("notifications after restart = %v, want no second first-data alert", calls)
+	}
+}
+
+func TestMonitor_FirstDataTimeout(t *testing.T) {
+	server := newTelemetryOctopusServer(t, "")
+	store := cache.NewFileOnboardingStore(filepath.Join(t.TempDir(), "onboarding.json"))
+	if err := store.Save(cache.Onboarding{FirstStart: time.Now().Add(-2 * time.Hour)}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	cfg := newTestConfig()
+	cfg.FirstDataTimeout = time.Hour
+	m := newInfluxTestMonitor(t, cfg, server.URL, "")
+	notifier := &recordingNotifier{}
+	m.Notifier = notifier
+	if err := m.SetOnboardingStore(store); err != nil {
+		t.Fatalf("SetOnboardingStore() error = %v", err)
+	}
+
+	m.poll()
+	m.poll()
+
+	calls := notifier.Calls()
+	if len(calls) != 1 || !strings.HasPrefix(calls[0], "warning|Octopus API|No data received from the Home Mini 2h0m0s") {
+		t.Errorf("notifications = %v, want one first-data timeout warning", calls)
+	}
+}
+
+// transitionEntries decodes the transition log in buf, counting entries by
+// "subject:from->to"
+func transitionEntries(t *testing.T, buf *bytes.Buffer) map[string]int {
+	t.Helper()
+
+	counts := make(map[string]int)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry struct {
+			Subject string `json:"subject"`
+			From    string `json:"from"`
+			To      string `json:"to"`
+			Reason  string `json:"reason"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("transition log line %q is not JSON: %v", line, err)
+		}
+		if entry.Reason == "" {
+			t.Errorf("transition log line %q has no reason", line)
+		}
+		counts[entry.Subject+":"+entry.From+"->"+entry.To]++
+	}
+	return counts
+}
+
+func TestMonitor_TransitionLog(t *testing.T) {
+	t.Run("influxdb and cache", func(t *testing.T) {
+		readAt := time.Now().UTC().Add(-time.Minute).Format(time.RFC3339)
+		octopusServer := newTelemetryOctopusServer(t, fmt.Sprintf(
+			`{"readAt": %q, "consumptionDelta": 0.01, "demand": 500, "costDelta": 0.002, "consumption": 100}`, readAt))
+
+		influxServer := newMockInfluxServer(t)
+
+		cfg := newTestConfig()
+		cfg.ReconnectMaxElapsedTime = 10 * time.Millisecond
+		m := newInfluxTestMonitor(t, cfg, octopusServer.URL, influxServer.URL)
+		var buf bytes.Buffer
+		m.SetTransitionLog(&buf)
+
+		// A healthy poll changes nothing
+		m.poll()
+		if buf.Len() != 0 {
+			t.Fatalf("transition log after a healthy poll = %q, want empty", buf.String())
+		}
+
+		// InfluxDB goes down: the failed write marks it unhealthy and fills the cache
+		influxServer.down.Store(true)
+		m.setLastPollTime(time.Now().Add(-cfg.PollInterval))
+		m.poll()
+		m.setLastPollTime(time.Now().Add(-cfg.PollInterval))
+		m.poll()
+		if m.getInfluxHealthy() || m.Cache.Count() == 0 {
+			t.Fatalf("InfluxDB healthy = %v with %d cached points, want unhealthy with a backlog", m.getInfluxHealthy(), m.Cache.Count())
+		}
+
+		// InfluxDB comes back: reconnecting marks it healthy and the sync drains the cache
+		influxServer.down.Store(false)
+		m.setLastPollTime(time.Now().Add(-cfg.PollInterval))
+		m.poll()
+		if !m.getInfluxHealthy() || m.Cache.Count() != 0 {
+			t.Fatalf("InfluxDB healthy = %v with %d cached points, want healthy with an empty cache", m.getInfluxHealthy(), m.Cache.Count())
+		}
+
+		want := map[string]int{
+			"influxdb:healthy->unhealthy": 1,
+			"influxdb:unhealthy->healthy": 1,
+			"cache:empty->backlog":        1,
+			"cache:backlog->empty":        1,
+		}
+		if got := transitionEntries(t, &buf); !reflect.DeepEqual(got, want) {
+			t.Errorf("transitions = %v, want %v\n%s", got, want, buf.String())
+		}
+	})
+
+	t.Run("degraded mode and circuit breaker", func(t *testing.T) {
+		cfg := newTestConfig()
+		cfg.PollTimeout = 200 * time.Millisecond
+		m, failing := newFlakyMonitor(t, cfg, &recordingNotifier{})
+		var buf bytes.Buffer
+		m.SetTransitionLog(&buf)
+
+		failing.Store(true)
+		for i := 0; i < 5; i++ {
+			m.poll()
+		}
+
+		// A fresh client, as the tripped breaker would otherwise reject the recovery poll
+		recovered := newMockOctopusServer(t)
+		m.OctopusClient = octopus.NewClientWithEndpoint("test_key", "A-12345678", recovered.URL)
+		if err := m.OctopusClient.Initialize(context.Background()); err != nil {
+			t.Fatalf("Initialize() error = %v", err)
+		}
+		m.setLastPollTime(time.Now().Add(-cfg.PollInterval))
+		m.poll()
+
+		want := map[string]int{
+			"degraded_mode:normal->degraded": 1,
+			"degraded_mode:degraded->normal": 1,
+			"octopus_breaker:closed->open":   1,
+		}
+		if got := transitionEntries(t, &buf); !reflect.DeepEqual(got, want) {
+			t.Errorf("transitions = %v, want %v\n%s", got, want, buf.String())
+		}
+	})
+}
+
+func TestMonitor_Replay(t *testing.T) {
+	influxServer := newMockInfluxServer(t)
+
+	// Recorded out of order, with a blank line; the first two readings share a poll interval
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	recording := `{"readAt": "2024-03-01T12:00:40Z", "consumptionDelta": 0.02, "demand": 600, "costDelta": 0.004, "consumption": 100.03}
+{"readAt": "2024-03-01T12:00:00Z", "consumptionDelta": 0.01, "demand": 500, "costDelta": 0.002, "consumption": 100.01}
+
+{"readAt": "2024-03-01T12:00:10Z", "consumptionDelta": 0.01, "demand": 550, "costDelta": 0.002, "consumption": 100.02}
+`
+	if err := os.WriteFile(path, []byte(recording), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	readings, err := LoadReplayFile(path)
+	if err != nil {
+		t.Fatalf("LoadReplayFile() error = %v", err)
+	}
+
+	m := newInfluxTestMonitor(t, newTestConfig(), "", influxServer.URL)
+
+	// 40s of readings at 1000x take 40ms
+	began := time.Now()
+	replayed, err := m.Replay(context.Background(), readings, 1000)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if replayed != 3 {
+		t.Errorf("Replay() = %d readings, want 3", replayed)
+	}
+	if elapsed := time.Since(began); elapsed < 40*time.Millisecond {
+		t.Errorf("Replay() took %v, want at least 40ms at 1000x", elapsed)
+	}
+
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	want := []int64{base.UnixNano(), base.Add(10 * time.Second).UnixNano(), base.Add(40 * time.Second).UnixNano()}
+	if written := influxServer.timestamps(); !reflect.DeepEqual(written, want) {
+		t.Errorf("written timestamps = %v, want %v", written, want)
+	}
+	if got := m.Cache.Count(); got != 0 {
+		t.Errorf("cached points = %d, want 0", got)
+	}
+}
+
+func TestLoadReplayFile_CSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.csv")
+	recording := "demand,readAt,consumptionDelta,costDelta,consumption\n" +
+		"500,2024-03-01T12:00:00Z,0.01,0.002,100.01\n" +
+		"550,2024-03-01T12:00:10Z,0.015,0.003,100.025\n"
+	if err := os.WriteFile(path, []byte(recording), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	readings, err := LoadReplayFile(path)
+	if err != nil {
+		t.Fatalf("LoadReplayFile() error = %v", err)
+	}
+	want := []octopus.TelemetryData{
+		{ReadAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), ConsumptionDelta: 0.01, Demand: 500, CostDelta: 0.002, Consumption: 100.01},
+		{ReadAt: time.Date(2024, 3, 1, 12, 0, 10, 0, time.UTC), ConsumptionDelta: 0.015, Demand: 550, CostDelta: 0.003, Consumption: 100.025},
+	}
+	if !reflect.DeepEqual(readings, want) {
+		t.Errorf("LoadReplayFile() = %+v, want %+v", readings, want)
+	}
+
+	if err := os.WriteFile(path, []byte("readAt,demand\n2024-03-01T12:00:00Z,500\n"), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := LoadReplayFile(path); err == nil || !strings.Contains(err.Error(), "consumptionDelta") {
+		t.Errorf("LoadReplayFile() error = %v, want a missing consumptionDelta column", err)
+	}
+}
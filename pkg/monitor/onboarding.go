@@ -0,0 +1,76 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/soothill/octopus-home-mini/pkg/cache"
+)
+
+// onboarding tracks an install awaiting its first data point
+type onboarding struct {
+	store  cache.OnboardingStore
+	state  cache.Onboarding
+	warned bool // FirstDataTimeout warning sent by this process
+}
+
+// SetOnboardingStore enables the awaiting-first-data state kept in store: a one-time
+// alert when the install's first data point ever arrives, and a warning if none has
+// arrived FirstDataTimeout after monitoring first started. Must be called before Run.
+func (m *Monitor) SetOnboardingStore(store cache.OnboardingStore) error {
+	state, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	if state.FirstStart.IsZero() {
+		state.FirstStart = time.Now()
+		// An install upgraded from before onboarding was tracked has already stored data
+		if watermark := m.Watermark(); !watermark.IsZero() {
+			state.FirstData = watermark
+		}
+		if err := store.Save(state); err != nil {
+			return err
+		}
+	}
+
+	m.onboarding = &onboarding{store: store, state: state}
+	return nil
+}
+
+// awaitingFirstData reports whether the install has never returned data
+func (m *Monitor) awaitingFirstData() bool {
+	return m.onboarding != nil && m.onboarding.state.FirstData.IsZero()
+}
+
+// checkFirstDataTimeout logs progress to routineLog while awaiting the first data point
+// and warns once FirstDataTimeout has passed since monitoring first started
+func (m *Monitor) checkFirstDataTimeout(routineLog zerolog.Logger, now time.Time) {
+	waited := now.Sub(m.onboarding.state.FirstStart).Round(time.Minute)
+	routineLog.Info().
+		Time("first_start", m.onboarding.state.FirstStart).
+		Dur("waited", waited).
+		Msg("Awaiting first data from the Home Mini")
+
+	if m.Cfg.FirstDataTimeout <= 0 || m.onboarding.warned || waited < m.Cfg.FirstDataTimeout {
+		return
+	}
+	m.onboarding.warned = true
+	m.NotifyWarning("Octopus API", fmt.Sprintf("No data received from the Home Mini %s after monitoring first started. "+
+		"New installs can take a while to report after pairing; check the device is paired and online in the Octopus app.", waited))
+}
+
+// recordFirstData persists the arrival of the install's first data point and announces it
+func (m *Monitor) recordFirstData(ctx context.Context, now time.Time) {
+	m.onboarding.state.FirstData = now
+	if err := m.onboarding.store.Save(m.onboarding.state); err != nil {
+		loggerFrom(ctx).Warn().Err(err).Msg("Failed to persist onboarding state")
+		m.recordError(ComponentCache, err)
+	}
+
+	waited := now.Sub(m.onboarding.state.FirstStart).Round(time.Minute)
+	loggerFrom(ctx).Info().Dur("waited", waited).Msg("First data received from the Home Mini")
+	m.NotifyInfo("First Data Received", fmt.Sprintf("The Home Mini has reported its first readings, %s after monitoring started. Energy data is now being recorded.", waited))
+}
@@ -0,0 +1,103 @@
+package monitor
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/soothill/octopus-home-mini/pkg/cache"
+	"github.com/soothill/octopus-home-mini/pkg/influx"
+	"github.com/soothill/octopus-home-mini/pkg/octopus"
+)
+
+// Sources of RecentStats
+const (
+	RecentStatsInfluxDB = "influxdb"
+	RecentStatsMemory   = "memory"
+)
+
+// RecentStats summarizes recently stored data. It is read back from InfluxDB when
+// possible; otherwise it holds the points this process wrote since startup.
+type RecentStats struct {
+	Source string `json:"source"`
+	Error  string `json:"error,omitempty"` // Why the InfluxDB query failed, for the memory source
+	influx.RecentSummary
+}
+
+// writeTotals accumulates the points written to InfluxDB since startup; safe for concurrent use
+type writeTotals struct {
+	mu      sync.Mutex
+	summary influx.RecentSummary
+}
+
+func newWriteTotals(start time.Time) *writeTotals {
+	return &writeTotals{summary: influx.RecentSummary{Start: start.UTC()}}
+}
+
+// add records one written point, leaving out NaN and Inf values as InfluxDB does
+func (w *writeTotals) add(consumptionDelta, costDelta, demand float64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.summary.Points++
+	if isFinite(consumptionDelta) {
+		w.summary.ConsumptionDelta += consumptionDelta
+	}
+	if isFinite(costDelta) {
+		w.summary.CostDelta += costDelta
+	}
+	if isFinite(demand) && demand > w.summary.DemandMax {
+		w.summary.DemandMax = demand
+	}
+}
+
+func (w *writeTotals) snapshot() influx.RecentSummary {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.summary
+}
+
+func isFinite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+// recordWrittenTelemetry adds telemetry written to InfluxDB to the in-memory totals
+func (m *Monitor) recordWrittenTelemetry(data []octopus.TelemetryData) {
+	for _, d := range data {
+		m.written.add(d.ConsumptionDelta, d.CostDelta, d.Demand)
+	}
+}
+
+// recordWrittenCached adds cached points synced to InfluxDB to the in-memory totals
+func (m *Monitor) recordWrittenCached(data []cache.DataPoint) {
+	for _, d := range data {
+		m.written.add(d.ConsumptionDelta, d.CostDelta, d.Demand)
+	}
+}
+
+// RecentStats reads back the data stored in InfluxDB over the last window, falling
+// back to the in-memory totals when InfluxDB is not the sink or the query fails.
+// The start time is given in the display timezone.
+func (m *Monitor) RecentStats(ctx context.Context, window time.Duration) RecentStats {
+	stats := m.recentStats(ctx, window)
+	stats.Start = stats.Start.In(m.Cfg.DisplayLocation())
+	return stats
+}
+
+func (m *Monitor) recentStats(ctx context.Context, window time.Duration) RecentStats {
+	if m.InfluxClient == nil {
+		return RecentStats{Source: RecentStatsMemory, RecentSummary: m.written.snapshot()}
+	}
+
+	summary, err := m.InfluxClient.QueryRecent(ctx, "", window)
+	if err != nil {
+		return RecentStats{
+			Source:        RecentStatsMemory,
+			Error:         sanitizeError(err),
+			RecentSummary: m.written.snapshot(),
+		}
+	}
+
+	return RecentStats{Source: RecentStatsInfluxDB, RecentSummary: summary}
+}
@@ -0,0 +1,162 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/soothill/octopus-home-mini/pkg/octopus"
+)
+
+// replayColumns are the CSV header names, matching the JSON field names of a reading
+var replayColumns = []string{"readAt", "consumptionDelta", "demand", "costDelta", "consumption"}
+
+// LoadReplayFile reads recorded telemetry from path: CSV when it ends in .csv, with a
+// header naming the readAt, consumptionDelta, demand, costDelta and consumption columns,
+// and otherwise JSONL with one reading per line as the Octopus API returns it. readAt
+// is an RFC 3339 timestamp in both.
+func LoadReplayFile(path string) ([]octopus.TelemetryData, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replay file: %w", err)
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return decodeReplayCSV(file)
+	}
+	return decodeReplayJSONL(file)
+}
+
+func decodeReplayJSONL(r io.Reader) ([]octopus.TelemetryData, error) {
+	var readings []octopus.TelemetryData
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var reading octopus.TelemetryData
+		if err := json.Unmarshal([]byte(text), &reading); err != nil {
+			return nil, fmt.Errorf("replay file line %d: %w", line, err)
+		}
+		readings = append(readings, reading)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read replay file: %w", err)
+	}
+	return readings, nil
+}
+
+func decodeReplayCSV(r io.Reader) ([]octopus.TelemetryData, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay file header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range replayColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("replay file header has no %s column", name)
+		}
+	}
+
+	var readings []octopus.TelemetryData
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("replay file line %d: %w", line, err)
+		}
+
+		var reading octopus.TelemetryData
+		if reading.ReadAt, err = time.Parse(time.RFC3339, strings.TrimSpace(record[columns["readAt"]])); err != nil {
+			return nil, fmt.Errorf("replay file line %d: %w", line, err)
+		}
+		for name, field := range map[string]*float64{
+			"consumptionDelta": &reading.ConsumptionDelta,
+			"demand":           &reading.Demand,
+			"costDelta":        &reading.CostDelta,
+			"consumption":      &reading.Consumption,
+		} {
+			if *field, err = strconv.ParseFloat(strings.TrimSpace(record[columns[name]]), 64); err != nil {
+				return nil, fmt.Errorf("replay file line %d: invalid %s: %w", line, name, err)
+			}
+		}
+		readings = append(readings, reading)
+	}
+	return readings, nil
+}
+
+// Replay runs recorded readings through the normal write and cache pipeline in place of
+// the Octopus API, in batches spanning one poll interval of reading time as a live poll
+// would fetch them. Batches are spaced by the time between their readings divided by
+// speed, so 60 replays an hour of readings a minute; 0 replays without pausing. Failed
+// writes are cached as in a live poll. It returns the number of readings replayed, which
+// falls short only when ctx ends or the monitor is drained.
+func (m *Monitor) Replay(ctx context.Context, readings []octopus.TelemetryData, speed float64) (int, error) {
+	readings = append([]octopus.TelemetryData(nil), readings...)
+	sort.SliceStable(readings, func(i, j int) bool { return readings[i].ReadAt.Before(readings[j].ReadAt) })
+
+	replayed := 0
+	var previous time.Time
+	for len(readings) > 0 {
+		end := 1
+		for end < len(readings) && readings[end].ReadAt.Before(readings[0].ReadAt.Add(m.Cfg.PollInterval)) {
+			end++
+		}
+		batch := readings[:end]
+		readings = readings[end:]
+
+		if replayed > 0 && speed > 0 {
+			select {
+			case <-ctx.Done():
+				return replayed, ctx.Err()
+			case <-m.drained:
+				return replayed, nil
+			case <-time.After(time.Duration(float64(batch[0].ReadAt.Sub(previous)) / speed)):
+			}
+		}
+		previous = batch[0].ReadAt
+
+		m.replayBatch(ctx, batch)
+		replayed += len(batch)
+	}
+	return replayed, nil
+}
+
+// replayBatch stores one batch of recorded readings under its own poll ID and timeout
+func (m *Monitor) replayBatch(ctx context.Context, batch []octopus.TelemetryData) {
+	ctx, cancel := context.WithTimeout(ctx, m.Cfg.PollTimeout)
+	defer cancel()
+
+	pollID := newCorrelationID()
+	logger := log.With().Str("poll_id", pollID).Bool("replay", true).Logger()
+	ctx = logger.WithContext(ctx)
+
+	logger.Info().
+		Int("count", len(batch)).
+		Time("from", batch[0].ReadAt).
+		Time("to", batch[len(batch)-1].ReadAt).
+		Msg("Replaying recorded telemetry")
+
+	var timings pollTimings
+	if err := m.storeTelemetry(ctx, batch, logger, &timings); err != nil {
+		logger.Warn().Err(err).Msg("Replayed batch not written to InfluxDB")
+	}
+}
@@ -0,0 +1,67 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// savingSessions tracks which Saving Sessions have been announced and marked in
+// InfluxDB. It is only used from the polling goroutine.
+type savingSessions struct {
+	checkedAt time.Time
+	notified  map[string]bool
+	annotated map[string]bool
+}
+
+func newSavingSessions() *savingSessions {
+	return &savingSessions{notified: make(map[string]bool), annotated: make(map[string]bool)}
+}
+
+// checkSavingSessions looks up Saving Sessions at most once per SavingSessionsInterval,
+// sending a notification for each upcoming one and marking its window in InfluxDB.
+// Failures are logged and recorded but never affect the poll.
+func (m *Monitor) checkSavingSessions(ctx context.Context, now time.Time) {
+	if m.sessions == nil || now.Sub(m.sessions.checkedAt) < m.Cfg.SavingSessionsInterval {
+		return
+	}
+	m.sessions.checkedAt = now // A failed check also waits for the next interval
+	logger := loggerFrom(ctx)
+
+	sessions, err := m.OctopusClient.GetSavingSessions(ctx)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to fetch saving sessions")
+		m.recordError(ComponentOctopus, err)
+		return
+	}
+
+	for _, session := range sessions {
+		if !session.EndAt.After(now) {
+			continue
+		}
+
+		if !m.sessions.notified[session.Code] && session.StartAt.After(now) {
+			m.sessions.notified[session.Code] = true
+			logger.Info().
+				Str("session", session.Code).
+				Time("start", session.StartAt).
+				Time("end", session.EndAt).
+				Msg("Upcoming saving session")
+			m.NotifyInfo("Saving Session", fmt.Sprintf("Upcoming Saving Session %s from %s to %s",
+				session.Code,
+				session.StartAt.In(m.Cfg.DisplayLocation()).Format("Mon 2 Jan 15:04"),
+				session.EndAt.In(m.Cfg.DisplayLocation()).Format("15:04")))
+		}
+
+		if m.sessions.annotated[session.Code] || m.InfluxClient == nil || !m.getInfluxHealthy() {
+			continue
+		}
+		if err := m.InfluxClient.WriteSavingSession(ctx, m.Cfg.SavingSessionsMeasurement, session.Code, session.StartAt, session.EndAt); err != nil {
+			// Retried on the next check
+			logger.Warn().Err(err).Str("session", session.Code).Msg("Failed to mark saving session")
+			m.recordError(ComponentInfluxDB, err)
+			continue
+		}
+		m.sessions.annotated[session.Code] = true
+	}
+}
@@ -0,0 +1,114 @@
+package monitor
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/soothill/octopus-home-mini/pkg/health"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// cacheSyncBuckets are the upper bounds, in seconds, of the cache sync duration histogram
+var cacheSyncBuckets = []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300}
+
+// CacheSyncStats summarizes cache sync attempts since startup
+type CacheSyncStats struct {
+	Duration     DurationHistogram `json:"octopus_cache_sync_duration_seconds"`
+	PointsSynced int64             `json:"octopus_cache_sync_points_synced_total"`
+	PointsFailed int64             `json:"octopus_cache_sync_points_failed_total"`
+}
+
+// WriteMetrics writes the stats in the Prometheus text exposition format, for /metrics
+func (s CacheSyncStats) WriteMetrics(w io.Writer) {
+	health.WriteHistogram(w, "octopus_cache_sync_duration_seconds", "Duration of cache syncs to InfluxDB.",
+		s.Duration.Buckets, s.Duration.Sum, s.Duration.Count)
+	health.WriteCounter(w, "octopus_cache_sync_points_synced_total", "Cached points synced to InfluxDB.", s.PointsSynced)
+	health.WriteCounter(w, "octopus_cache_sync_points_failed_total", "Cached points left in the cache by failed or partial syncs.", s.PointsFailed)
+}
+
+// DurationHistogram is a cumulative histogram of durations in seconds, keyed by
+// bucket upper bound as in the Prometheus exposition format
+type DurationHistogram struct {
+	Buckets map[string]int64 `json:"buckets"`
+	Sum     float64          `json:"sum"`
+	Count   int64            `json:"count"`
+}
+
+// cacheSyncRecorder accumulates CacheSyncStats; safe for concurrent use
+type cacheSyncRecorder struct {
+	mu      sync.Mutex
+	buckets []int64 // Non-cumulative counts per cacheSyncBuckets entry, plus +Inf
+	sum     float64
+	count   int64
+	synced  int64
+	failed  int64
+}
+
+// record adds one sync attempt that took d and wrote synced of its points,
+// leaving failed points in the cache
+func (r *cacheSyncRecorder) record(d time.Duration, synced, failed int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.buckets == nil {
+		r.buckets = make([]int64, len(cacheSyncBuckets)+1)
+	}
+
+	seconds := d.Seconds()
+	i := 0
+	for i < len(cacheSyncBuckets) && seconds > cacheSyncBuckets[i] {
+		i++
+	}
+	r.buckets[i]++
+	r.sum += seconds
+	r.count++
+	r.synced += int64(synced)
+	r.failed += int64(failed)
+}
+
+func (r *cacheSyncRecorder) snapshot() CacheSyncStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	buckets := make(map[string]int64, len(cacheSyncBuckets)+1)
+	var cumulative int64
+	for i, bound := range cacheSyncBuckets {
+		if r.buckets != nil {
+			cumulative += r.buckets[i]
+		}
+		buckets[strconv.FormatFloat(bound, 'g', -1, 64)] = cumulative
+	}
+	buckets["+Inf"] = r.count
+
+	return CacheSyncStats{
+		Duration:     DurationHistogram{Buckets: buckets, Sum: r.sum, Count: r.count},
+		PointsSynced: r.synced,
+		PointsFailed: r.failed,
+	}
+}
+
+// CacheSyncStats returns the cache sync duration histogram and point counters,
+// covering both full and incremental syncs
+func (m *Monitor) CacheSyncStats() CacheSyncStats {
+	return m.syncStats.snapshot()
+}
+
+// startSync begins a cache sync of the given kind ("full" or "incremental"). The
+// returned finish function records the outcome in CacheSyncStats and ends the sync span.
+func (m *Monitor) startSync(ctx context.Context, kind string) (context.Context, func(synced, failed int, err error)) {
+	start := time.Now()
+	ctx, span := m.tracer.Start(ctx, "sync", trace.WithAttributes(attribute.String("sync.kind", kind)))
+
+	return ctx, func(synced, failed int, err error) {
+		m.syncStats.record(time.Since(start), synced, failed)
+		span.SetAttributes(
+			attribute.Int("points.synced", synced),
+			attribute.Int("points.failed", failed),
+		)
+		endSpan(span, err)
+	}
+}
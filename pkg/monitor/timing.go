@@ -0,0 +1,43 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/soothill/octopus-home-mini/pkg/octopus"
+)
+
+// pollTimings collects how long each phase of a poll took. A zero duration means
+// the phase did not run and is left out of the log line.
+type pollTimings struct {
+	octopus      octopus.Timings
+	influxHealth time.Duration
+	write        time.Duration
+}
+
+// log emits the timing breakdown at debug level, so slow polls can be traced to
+// the Octopus API or to InfluxDB
+func (t *pollTimings) log(logger *zerolog.Logger, pollStart time.Time) {
+	event := logger.Debug()
+	if !event.Enabled() {
+		return
+	}
+
+	phases := []struct {
+		field    string
+		duration time.Duration
+	}{
+		{"auth", t.octopus.Auth},
+		{"meter_discovery", t.octopus.MeterDiscovery},
+		{"telemetry_fetch", t.octopus.Telemetry},
+		{"influx_health_check", t.influxHealth},
+		{"write", t.write},
+	}
+	for _, phase := range phases {
+		if phase.duration > 0 {
+			event = event.Dur(phase.field, phase.duration)
+		}
+	}
+
+	event.Dur("total", time.Since(pollStart)).Msg("Poll timing breakdown")
+}
@@ -0,0 +1,22 @@
+package monitor
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the monitor's spans; spans are no-ops unless tracing.Setup
+// installed an exporting provider
+const tracerName = "github.com/soothill/octopus-home-mini/pkg/monitor"
+
+// endSpan marks span as failed with the redacted err, if any, and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		msg := sanitizeError(err)
+		span.RecordError(errors.New(msg))
+		span.SetStatus(codes.Error, msg)
+	}
+	span.End()
+}
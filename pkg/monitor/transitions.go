@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/rs/zerolog"
+)
+
+// Subjects of the transition log
+const (
+	transitionInfluxDB       = "influxdb"
+	transitionDegradedMode   = "degraded_mode"
+	transitionOctopusBreaker = "octopus_breaker"
+	transitionCache          = "cache"
+	transitionMaintenance    = "octopus_maintenance"
+)
+
+// SetTransitionLog routes the transition log to w: one JSON line per significant state
+// change (InfluxDB health, degraded mode, Octopus maintenance and circuit breaker, the
+// cache backlog) with its before and after states and the reason, so an incident
+// timeline can be read without the per-poll logs. A nil w disables it.
+func (m *Monitor) SetTransitionLog(w io.Writer) {
+	logger := zerolog.Nop()
+	if w != nil {
+		logger = zerolog.New(w).With().Timestamp().Logger()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.transitions = logger
+}
+
+// logTransition writes one transition log entry for subject moving from one state to another
+func (m *Monitor) logTransition(subject, from, to, reason string) {
+	m.mu.RLock()
+	logger := m.transitions
+	m.mu.RUnlock()
+
+	logger.Log().
+		Str("subject", subject).
+		Str("from", from).
+		Str("to", to).
+		Str("reason", reason).
+		Msg("State transition")
+}
+
+// updateInfluxHealth records InfluxDB as healthy or not, logging a transition when
+// that changes
+func (m *Monitor) updateInfluxHealth(healthy bool, reason string) {
+	m.mu.Lock()
+	changed := m.influxHealthy != healthy
+	m.influxHealthy = healthy
+	m.mu.Unlock()
+
+	if changed {
+		m.logTransition(transitionInfluxDB, healthState(!healthy), healthState(healthy), reason)
+	}
+}
+
+func healthState(healthy bool) string {
+	if healthy {
+		return "healthy"
+	}
+	return "unhealthy"
+}
+
+// handleBreakerStateChange logs the Octopus API circuit breaker opening, half-opening
+// and closing. It runs while the breaker holds its lock, so it must not call the client.
+func (m *Monitor) handleBreakerStateChange(from, to string) {
+	m.logTransition(transitionOctopusBreaker, from, to, fmt.Sprintf("circuit breaker %s", to))
+}
+
+// observeCacheBacklog logs a transition when the cache goes from empty to holding a
+// backlog or is drained, checked after every change to its contents
+func (m *Monitor) observeCacheBacklog(reason string) {
+	if m.Cache == nil {
+		return
+	}
+	count := m.Cache.Count()
+
+	m.mu.Lock()
+	changed := m.cacheBacklog != (count > 0)
+	m.cacheBacklog = count > 0
+	m.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if count > 0 {
+		m.logTransition(transitionCache, "empty", "backlog", fmt.Sprintf("%s (%d points cached)", reason, count))
+	} else {
+		m.logTransition(transitionCache, "backlog", "empty", reason)
+	}
+}
@@ -0,0 +1,82 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/soothill/octopus-home-mini/pkg/cache"
+	"github.com/soothill/octopus-home-mini/pkg/octopus"
+)
+
+// SetWatermarkStore enables filtering out points at or before the newest point already
+// stored, as recorded in store, so overlapping poll ranges (including the first poll
+// after a restart) cannot write duplicates
+func (m *Monitor) SetWatermarkStore(store cache.WatermarkStore) error {
+	watermark, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.watermarkStore = store
+	m.watermark = watermark
+	return nil
+}
+
+// Watermark returns the timestamp of the newest point stored, or the zero time if
+// the watermark is not tracked or nothing has been stored yet
+func (m *Monitor) Watermark() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.watermark
+}
+
+// dropSeenPoints removes points read at or before the watermark
+func (m *Monitor) dropSeenPoints(ctx context.Context, telemetryData []octopus.TelemetryData) []octopus.TelemetryData {
+	watermark := m.Watermark()
+	if watermark.IsZero() {
+		return telemetryData
+	}
+
+	kept := telemetryData[:0]
+	for _, data := range telemetryData {
+		if data.ReadAt.After(watermark) {
+			kept = append(kept, data)
+		}
+	}
+
+	if dropped := len(telemetryData) - len(kept); dropped > 0 {
+		loggerFrom(ctx).Info().
+			Int("dropped", dropped).
+			Time("watermark", watermark).
+			Msg("Dropped points already stored")
+	}
+	return kept
+}
+
+// advanceWatermark raises the watermark to the newest of stored and persists it.
+// A failed save only means duplicates may be refetched after a restart.
+func (m *Monitor) advanceWatermark(ctx context.Context, stored []octopus.TelemetryData) {
+	m.mu.Lock()
+	store := m.watermarkStore
+	newest := m.watermark
+	for _, data := range stored {
+		if data.ReadAt.After(newest) {
+			newest = data.ReadAt
+		}
+	}
+	advanced := store != nil && newest.After(m.watermark)
+	if advanced {
+		m.watermark = newest
+	}
+	m.mu.Unlock()
+
+	if !advanced {
+		return
+	}
+	if err := store.Save(newest); err != nil {
+		loggerFrom(ctx).Warn().Err(err).Msg("Failed to persist watermark")
+		m.recordError(ComponentCache, err)
+	}
+}
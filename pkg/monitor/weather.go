@@ -0,0 +1,64 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/soothill/octopus-home-mini/pkg/weather"
+)
+
+// RunWeather fetches the outdoor temperature from provider every WeatherInterval and
+// writes it to InfluxDB until stopChan is closed. Failures are logged and recorded but
+// never affect polling.
+func (m *Monitor) RunWeather(stopChan chan struct{}, provider weather.Provider) {
+	record := func() { m.recordWeather(provider) }
+	if !m.runTracked(record) {
+		return
+	}
+
+	ticker := time.NewTicker(m.Cfg.WeatherInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !m.runTracked(record) {
+				return
+			}
+		case <-stopChan:
+			return
+		}
+	}
+}
+
+// recordWeather writes one temperature reading. Readings are skipped rather than cached
+// while InfluxDB is unavailable, as the next one follows shortly.
+func (m *Monitor) recordWeather(provider weather.Provider) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.PollTimeout)
+	defer cancel()
+
+	reading, err := provider.CurrentTemperature(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to fetch outdoor temperature")
+		m.recordError(ComponentWeather, err)
+		return
+	}
+
+	if m.InfluxClient == nil || !m.getInfluxHealthy() {
+		log.Debug().Msg("InfluxDB unavailable, skipping outdoor temperature")
+		return
+	}
+
+	if err := m.InfluxClient.WriteTemperature(ctx, m.Cfg.WeatherMeasurement, reading.Time, reading.TemperatureC); err != nil {
+		log.Warn().Err(err).Msg("Failed to write outdoor temperature")
+		m.recordError(ComponentWeather, err)
+		return
+	}
+
+	log.Debug().
+		Float64("temperature_c", reading.TemperatureC).
+		Time("observed", reading.Time).
+		Msg("Recorded outdoor temperature")
+}
@@ -0,0 +1,54 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/soothill/octopus-home-mini/pkg/influx"
+	"github.com/soothill/octopus-home-mini/pkg/octopus"
+)
+
+// retryWrite retries a failed InfluxDB write of telemetryData up to WriteRetryAttempts
+// times, WriteRetryDelay apart, so a blip that clears within seconds doesn't send the
+// batch to the cache and InfluxDB into cache mode. It returns nil once a retry succeeds,
+// or the last error. Batches over WriteRetryMaxPoints, schema conflicts and backpressure
+// are not retried, and a shutdown or the poll deadline ends the retries early.
+func (m *Monitor) retryWrite(ctx context.Context, telemetryData []octopus.TelemetryData, err error) error {
+	if m.Cfg.WriteRetryAttempts <= 0 || influx.IsFieldTypeConflict(err) || influx.IsBackpressure(err) {
+		return err
+	}
+	logger := loggerFrom(ctx)
+	if m.Cfg.WriteRetryMaxPoints > 0 && len(telemetryData) > m.Cfg.WriteRetryMaxPoints {
+		logger.Debug().
+			Int("count", len(telemetryData)).
+			Int("max_points", m.Cfg.WriteRetryMaxPoints).
+			Msg("Batch too large to hold for write retries")
+		return err
+	}
+
+	for attempt := 1; attempt <= m.Cfg.WriteRetryAttempts; attempt++ {
+		select {
+		case <-ctx.Done():
+			return err
+		case <-m.drained:
+			logger.Info().Int("count", len(telemetryData)).Msg("Shutting down, abandoning write retries")
+			return err
+		case <-time.After(m.Cfg.WriteRetryDelay):
+		}
+
+		logger.Warn().
+			Err(err).
+			Int("attempt", attempt).
+			Int("max_attempts", m.Cfg.WriteRetryAttempts).
+			Int("count", len(telemetryData)).
+			Msg("Retrying failed InfluxDB write")
+		if err = m.writeToInflux(telemetryData); err == nil {
+			logger.Info().Int("attempt", attempt).Msg("InfluxDB write succeeded on retry")
+			return nil
+		}
+		if influx.IsFieldTypeConflict(err) || influx.IsBackpressure(err) {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package notify
+
+import "sync"
+
+// Metered implements Notifier
+var _ Notifier = (*Metered)(nil)
+
+// Stats counts notification deliveries since startup, so a silently broken alert channel
+// shows up on /stats
+type Stats struct {
+	Sent         int64 `json:"octopus_notifications_sent_total"`
+	Failed       int64 `json:"octopus_notifications_failed_total"`
+	Suppressed   int64 `json:"octopus_notifications_suppressed_total"`
+	CircuitTrips int64 `json:"octopus_notifier_circuit_trips_total"`
+}
+
+// CircuitTripper is implemented by notifiers guarded by a circuit breaker, reporting
+// how many times it has opened
+type CircuitTripper interface {
+	CircuitTrips() int64
+}
+
+// Metered wraps a Notifier and counts its deliveries, so every backend reports the
+// same Stats; safe for concurrent use
+type Metered struct {
+	next Notifier
+
+	mu         sync.Mutex
+	sent       int64
+	failed     int64
+	suppressed int64
+}
+
+// NewMetered wraps next in a Metered notifier
+func NewMetered(next Notifier) *Metered {
+	return &Metered{next: next}
+}
+
+// SendError sends the notification through the wrapped notifier, counting the outcome
+func (m *Metered) SendError(component, errorMsg string) error {
+	return m.record(m.next.SendError(component, errorMsg))
+}
+
+// SendWarning sends the notification through the wrapped notifier, counting the outcome
+func (m *Metered) SendWarning(component, warningMsg string) error {
+	return m.record(m.next.SendWarning(component, warningMsg))
+}
+
+// SendInfo sends the notification through the wrapped notifier, counting the outcome
+func (m *Metered) SendInfo(title, message string) error {
+	return m.record(m.next.SendInfo(title, message))
+}
+
+// SendCacheAlert sends the notification through the wrapped notifier, counting the outcome
+func (m *Metered) SendCacheAlert(count int, action string) error {
+	return m.record(m.next.SendCacheAlert(count, action))
+}
+
+// RecordSuppressed counts a notification the caller withheld instead of sending
+func (m *Metered) RecordSuppressed() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.suppressed++
+}
+
+// Stats returns the delivery counters, with circuit breaker trips when the wrapped
+// notifier reports them
+func (m *Metered) Stats() Stats {
+	m.mu.Lock()
+	stats := Stats{Sent: m.sent, Failed: m.failed, Suppressed: m.suppressed}
+	m.mu.Unlock()
+
+	if tripper, ok := m.next.(CircuitTripper); ok {
+		stats.CircuitTrips = tripper.CircuitTrips()
+	}
+	return stats
+}
+
+func (m *Metered) record(err error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.failed++
+	} else {
+		m.sent++
+	}
+	return err
+}
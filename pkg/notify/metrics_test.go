@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"errors"
+	"testing"
+)
+
+// failingNotifier fails every send and reports a fixed number of circuit trips
+type failingNotifier struct {
+	Nop
+	trips int64
+}
+
+func (failingNotifier) SendError(component, errorMsg string) error {
+	return errors.New("webhook returned status 500")
+}
+
+func (n failingNotifier) CircuitTrips() int64 { return n.trips }
+
+func TestMetered_Stats(t *testing.T) {
+	m := NewMetered(failingNotifier{trips: 2})
+
+	if err := m.SendError("Octopus API", "poll failed"); err == nil {
+		t.Error("SendError() error = nil, want the wrapped notifier's error")
+	}
+	if err := m.SendInfo("Catch-Up", "caught up"); err != nil {
+		t.Errorf("SendInfo() error = %v", err)
+	}
+	m.RecordSuppressed()
+
+	want := Stats{Sent: 1, Failed: 1, Suppressed: 1, CircuitTrips: 2}
+	if got := m.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+
+	// Backends without a circuit breaker report no trips
+	if got := NewMetered(Nop{}).Stats().CircuitTrips; got != 0 {
+		t.Errorf("CircuitTrips = %d without a circuit breaker, want 0", got)
+	}
+}
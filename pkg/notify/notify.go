@@ -0,0 +1,24 @@
+package notify
+
+// Notifier delivers operational alerts to an external channel such as Slack
+type Notifier interface {
+	SendError(component, errorMsg string) error
+	SendWarning(component, warningMsg string) error
+	SendInfo(title, message string) error
+	SendCacheAlert(count int, action string) error
+}
+
+// Nop is a Notifier that discards all notifications, used when notifications are disabled
+type Nop struct{}
+
+// SendError discards the notification
+func (Nop) SendError(component, errorMsg string) error { return nil }
+
+// SendWarning discards the notification
+func (Nop) SendWarning(component, warningMsg string) error { return nil }
+
+// SendInfo discards the notification
+func (Nop) SendInfo(title, message string) error { return nil }
+
+// SendCacheAlert discards the notification
+func (Nop) SendCacheAlert(count int, action string) error { return nil }
@@ -0,0 +1,106 @@
+package octopus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const auditFilePattern = "telemetry_*.json"
+
+// auditRecord is the on-disk representation of a single audited telemetry response.
+// Only the response body is stored; request headers (including Authorization) never are.
+type auditRecord struct {
+	FetchedAt     time.Time       `json:"fetched_at"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	DeviceID      string          `json:"device_id"`
+	Start         string          `json:"start"`
+	End           string          `json:"end"`
+	Response      json.RawMessage `json:"smartMeterTelemetry"`
+}
+
+type correlationIDKey struct{}
+
+// WithCorrelationID returns a context whose telemetry audit records carry id, so they
+// can be matched with the caller's log lines for the same request
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// correlationID returns the ID set by WithCorrelationID, or ""
+func correlationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// SetAudit enables persisting raw telemetry responses to dir, keeping at most retention files.
+// An empty dir disables auditing.
+func (c *Client) SetAudit(dir string, retention int) error {
+	if dir == "" {
+		c.auditDir = ""
+		return nil
+	}
+	if retention < 1 {
+		return fmt.Errorf("audit retention must be at least 1")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create audit directory: %w", err)
+	}
+
+	c.auditDir = dir
+	c.auditRetention = retention
+	return nil
+}
+
+// auditTelemetry writes a raw telemetry response to the audit directory.
+// Failures are logged and never affect the caller's parse path.
+func (c *Client) auditTelemetry(ctx context.Context, start, end time.Time, raw json.RawMessage) {
+	if c.auditDir == "" {
+		return
+	}
+
+	now := time.Now().UTC()
+	record := auditRecord{
+		FetchedAt:     now,
+		CorrelationID: correlationID(ctx),
+		DeviceID:      c.meterGUID,
+		Start:         start.Format(time.RFC3339),
+		End:           end.Format(time.RFC3339),
+		Response:      raw,
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		log.Printf("Failed to encode telemetry audit record: %v", err)
+		return
+	}
+
+	filename := filepath.Join(c.auditDir, fmt.Sprintf("telemetry_%s.json", now.Format("20060102T150405.000000000Z")))
+	if err := os.WriteFile(filename, data, 0600); err != nil {
+		log.Printf("Failed to write telemetry audit file: %v", err)
+		return
+	}
+
+	c.pruneAudit()
+}
+
+// pruneAudit removes the oldest audit files beyond the retention limit
+func (c *Client) pruneAudit() {
+	files, err := filepath.Glob(filepath.Join(c.auditDir, auditFilePattern))
+	if err != nil || len(files) <= c.auditRetention {
+		return
+	}
+
+	// Timestamped names sort chronologically
+	sort.Strings(files)
+	for _, file := range files[:len(files)-c.auditRetention] {
+		if err := os.Remove(file); err != nil {
+			log.Printf("Failed to remove old telemetry audit file %s: %v", file, err)
+		}
+	}
+}
@@ -2,30 +2,124 @@ package octopus
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/machinebox/graphql"
 	"github.com/sony/gobreaker"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	graphqlEndpoint = "https://api.octopus.energy/v1/graphql/"
 	maxRetries      = 3
 	maxElapsedTime  = 30 * time.Second
+	maxInterval     = 15 * time.Second
+	defaultGrouping = "TEN_SECONDS"
 )
 
+// Meter discovery errors, each pointing at a different onboarding problem
+var (
+	ErrNoElectricityAgreement = errors.New("no electricity agreement found")
+	ErrNoMeterPoint           = errors.New("no meter point found")
+	ErrNoMeter                = errors.New("no meter found")
+	ErrNoSmartDevice          = errors.New("no smart devices found")
+)
+
+// ErrTransportTimeout is returned when a connection stalls past one of the transport
+// timeouts set by SetTransportTimeouts, before the request's own deadline
+var ErrTransportTimeout = errors.New("connection to the Octopus API timed out")
+
+// ErrNoAPIKey is returned when a token is needed but the provided one has expired and
+// there is no API key to obtain another
+var ErrNoAPIKey = errors.New("provided token expired and no API key is set to obtain another")
+
+// TransportTimeouts bound each stage of an API connection; zero leaves a stage unbounded
+type TransportTimeouts struct {
+	Dial           time.Duration
+	TLSHandshake   time.Duration
+	ResponseHeader time.Duration // From sending the request to receiving the response headers
+}
+
 // Client handles communication with the Octopus Energy GraphQL API
 type Client struct {
 	apiKey         string
 	accountNumber  string
 	token          string
+	endpoint       string
 	client         *graphql.Client
 	meterGUID      string
+	mpan           string
+	meterSerial    string
+	grouping       string
 	circuitBreaker *gobreaker.CircuitBreaker
+	// Called on every circuit breaker state change when set
+	breakerStateHandler atomic.Pointer[BreakerStateHandler]
+
+	// Layouts tried in order when parsing a reading's readAt, and the count of readings
+	// skipped because none matched
+	timestampLayouts []string
+	skippedReadings  atomic.Int64
+
+	// Retry budget applied to every API operation
+	retryMaxElapsed  time.Duration
+	retryMaxInterval time.Duration
+	// Deadline for a single API request within the caller's context (0 = none)
+	requestTimeout time.Duration
+
+	// HTTP transport settings, applied together whenever either changes
+	proxyURL          *url.URL
+	transportTimeouts *TransportTimeouts
+
+	// The API token is persisted here across restarts when set
+	tokenFile string
+	// Pre-obtained Kraken token used instead of obtainKrakenToken until it expires
+	providedToken string
+
+	// Raw telemetry responses are persisted here when auditing is enabled
+	auditDir       string
+	auditRetention int
+}
+
+// Timings records how long each phase of a GetTelemetry call took.
+// A zero duration means the phase did not run.
+type Timings struct {
+	Auth           time.Duration
+	MeterDiscovery time.Duration
+	Telemetry      time.Duration
+}
+
+type timingsKey struct{}
+
+// WithTimings returns a context in which GetTelemetry records its phase durations to
+// timings, so concurrent callers each collect their own
+func WithTimings(ctx context.Context, timings *Timings) context.Context {
+	return context.WithValue(ctx, timingsKey{}, timings)
+}
+
+// timingsFrom returns the collector set by WithTimings, or a discarded one
+func timingsFrom(ctx context.Context) *Timings {
+	if timings, ok := ctx.Value(timingsKey{}).(*Timings); ok && timings != nil {
+		return timings
+	}
+	return &Timings{}
 }
 
+// BreakerStateHandler is called when the API circuit breaker changes state, with the
+// states named as gobreaker names them: "closed", "half-open" and "open"
+type BreakerStateHandler func(from, to string)
+
 // TelemetryData represents energy consumption data
 type TelemetryData struct {
 	ReadAt           time.Time `json:"readAt"`
@@ -42,6 +136,17 @@ func NewClient(apiKey, accountNumber string) *Client {
 
 // NewClientWithEndpoint creates a new Octopus Energy API client with a specific endpoint
 func NewClientWithEndpoint(apiKey, accountNumber, endpoint string) *Client {
+	c := &Client{
+		apiKey:           apiKey,
+		accountNumber:    accountNumber,
+		endpoint:         endpoint,
+		grouping:         defaultGrouping,
+		timestampLayouts: []string{time.RFC3339},
+		client:           graphql.NewClient(endpoint, graphql.WithHTTPClient(&http.Client{Transport: maintenanceTransport{http.DefaultTransport}})),
+		retryMaxElapsed:  maxElapsedTime,
+		retryMaxInterval: maxInterval,
+	}
+
 	// Configure circuit breaker
 	cbSettings := gobreaker.Settings{
 		Name:        "OctopusAPI",
@@ -53,38 +158,178 @@ func NewClientWithEndpoint(apiKey, accountNumber, endpoint string) *Client {
 			return counts.Requests >= 3 && failureRatio >= 0.6
 		},
 		OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
-			// Log state changes (could be enhanced with structured logging)
-			// fmt.Printf("Circuit breaker %s changed from %s to %s\n", name, from, to)
+			if handler := c.breakerStateHandler.Load(); handler != nil {
+				(*handler)(from.String(), to.String())
+			}
 		},
 	}
+	c.circuitBreaker = gobreaker.NewCircuitBreaker(cbSettings)
+
+	return c
+}
 
-	return &Client{
-		apiKey:         apiKey,
-		accountNumber:  accountNumber,
-		client:         graphql.NewClient(endpoint),
-		circuitBreaker: gobreaker.NewCircuitBreaker(cbSettings),
+// SetBreakerStateHandler registers a callback invoked whenever the API circuit breaker
+// opens, half-opens or closes. It runs while the breaker holds its lock, so it must not
+// call back into the client. A nil handler removes it.
+func (c *Client) SetBreakerStateHandler(handler BreakerStateHandler) {
+	if handler == nil {
+		c.breakerStateHandler.Store(nil)
+		return
 	}
+	c.breakerStateHandler.Store(&handler)
+}
+
+// SetRetryBudget overrides the total time and maximum interval used when retrying API calls.
+// Non-positive values leave the corresponding default in place.
+func (c *Client) SetRetryBudget(maxElapsed, maxInterval time.Duration) {
+	if maxElapsed > 0 {
+		c.retryMaxElapsed = maxElapsed
+	}
+	if maxInterval > 0 {
+		c.retryMaxInterval = maxInterval
+	}
+}
+
+// SetRequestTimeout bounds each individual API request, so one slow response is
+// retried rather than consuming the caller's whole deadline. Zero disables the limit.
+func (c *Client) SetRequestTimeout(timeout time.Duration) {
+	c.requestTimeout = timeout
+}
+
+// SetGrouping sets the telemetry resolution requested from the API, such as TEN_SECONDS or ONE_MINUTE
+func (c *Client) SetGrouping(grouping string) {
+	if grouping != "" {
+		c.grouping = grouping
+	}
+}
+
+// SetTimestampLayouts sets the layouts tried in order when parsing a reading's readAt,
+// guarding against the API changing its timestamp format. Layouts without a zone are
+// read as UTC. An empty list leaves RFC 3339 in place.
+func (c *Client) SetTimestampLayouts(layouts []string) {
+	if len(layouts) > 0 {
+		c.timestampLayouts = layouts
+	}
+}
+
+// SkippedReadingCount returns the number of readings skipped because their readAt
+// matched none of the timestamp layouts
+func (c *Client) SkippedReadingCount() int64 {
+	return c.skippedReadings.Load()
+}
+
+// parseReadAt parses a reading's readAt with the first matching timestamp layout
+func (c *Client) parseReadAt(value string) (time.Time, error) {
+	var err error
+	for _, layout := range c.timestampLayouts {
+		var readAt time.Time
+		if readAt, err = time.Parse(layout, value); err == nil {
+			return readAt, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// SetProxy routes API requests through the given HTTP(S) or SOCKS5 proxy
+func (c *Client) SetProxy(proxyURL *url.URL) {
+	c.proxyURL = proxyURL
+	c.rebuildHTTPClient()
+}
+
+// SetTransportTimeouts bounds connecting, the TLS handshake and waiting for response
+// headers, so a stalled connection fails with ErrTransportTimeout well before the
+// request timeout or the caller's deadline
+func (c *Client) SetTransportTimeouts(timeouts TransportTimeouts) {
+	c.transportTimeouts = &timeouts
+	c.rebuildHTTPClient()
+}
+
+// rebuildHTTPClient replaces the GraphQL client with one using the proxy and transport timeouts
+func (c *Client) rebuildHTTPClient() {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.proxyURL != nil {
+		transport.Proxy = http.ProxyURL(c.proxyURL)
+	}
+	if t := c.transportTimeouts; t != nil {
+		transport.DialContext = (&net.Dialer{Timeout: t.Dial, KeepAlive: 30 * time.Second}).DialContext
+		transport.TLSHandshakeTimeout = t.TLSHandshake
+		transport.ResponseHeaderTimeout = t.ResponseHeader
+	}
+	c.client = graphql.NewClient(c.endpoint, graphql.WithHTTPClient(&http.Client{Transport: maintenanceTransport{transport}}))
 }
 
 // newBackoff creates a new exponential backoff configuration
-func newBackoff() *backoff.ExponentialBackOff {
+func newBackoff(maxElapsed, maxInterval time.Duration) *backoff.ExponentialBackOff {
 	b := backoff.NewExponentialBackOff()
-	b.MaxElapsedTime = maxElapsedTime
+	b.MaxElapsedTime = maxElapsed
+	b.MaxInterval = maxInterval
 	return b
 }
 
-// Authenticate obtains a JWT token from the API with exponential backoff retry
+// apiRequest describes a single GraphQL operation against the Octopus API
+type apiRequest struct {
+	query string
+	vars  map[string]interface{}
+	// authenticated requests carry the current token in the Authorization header.
+	// Only the token mutation itself is sent without one.
+	authenticated bool
+	// action describes the operation in wrapped errors, e.g. "get telemetry"
+	action string
+}
+
+// run executes an API request and decodes its data into resp
+func (c *Client) run(ctx context.Context, r apiRequest, resp interface{}) error {
+	req := graphql.NewRequest(r.query)
+	for name, value := range r.vars {
+		req.Var(name, value)
+	}
+	if r.authenticated {
+		req.Header.Set("Authorization", c.token)
+	}
+
+	if c.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.requestTimeout)
+		defer cancel()
+	}
+
+	if err := c.client.Run(ctx, req, resp); err != nil {
+		// A timeout while the context is still live came from the transport
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() && ctx.Err() == nil {
+			return fmt.Errorf("failed to %s: %w: %v", r.action, ErrTransportTimeout, err)
+		}
+		// Retrying within the call would only hammer an API that is down for maintenance
+		if IsMaintenance(err) {
+			return backoff.Permanent(fmt.Errorf("failed to %s: %w", r.action, err))
+		}
+		return fmt.Errorf("failed to %s: %w", r.action, err)
+	}
+	return nil
+}
+
+// Authenticate obtains a JWT token from the API with exponential backoff retry. A token
+// provided with SetToken is used as it is until it expires, without calling the API.
 func (c *Client) Authenticate(ctx context.Context) error {
+	if c.useProvidedToken() {
+		return nil
+	}
+	if c.apiKey == "" {
+		return fmt.Errorf("failed to authenticate: %w", ErrNoAPIKey)
+	}
+
 	operation := func() error {
-		req := graphql.NewRequest(`
+		req := apiRequest{
+			query: `
 			mutation obtainKrakenToken($apiKey: String!) {
 				obtainKrakenToken(input: {APIKey: $apiKey}) {
 					token
 				}
 			}
-		`)
-
-		req.Var("apiKey", c.apiKey)
+		`,
+			vars:   map[string]interface{}{"apiKey": c.apiKey},
+			action: "authenticate",
+		}
 
 		var resp struct {
 			ObtainKrakenToken struct {
@@ -92,27 +337,31 @@ func (c *Client) Authenticate(ctx context.Context) error {
 			} `json:"obtainKrakenToken"`
 		}
 
-		if err := c.client.Run(ctx, req, &resp); err != nil {
-			return fmt.Errorf("failed to authenticate: %w", err)
+		if err := c.run(ctx, req, &resp); err != nil {
+			return err
 		}
 
 		c.token = resp.ObtainKrakenToken.Token
+		c.saveToken()
 		return nil
 	}
 
-	b := newBackoff()
+	b := newBackoff(c.retryMaxElapsed, c.retryMaxInterval)
 	return backoff.Retry(operation, backoff.WithContext(b, ctx))
 }
 
 // GetMeterGUID retrieves the meter GUID for the account with exponential backoff retry
 func (c *Client) GetMeterGUID(ctx context.Context) error {
 	operation := func() error {
-		req := graphql.NewRequest(`
+		req := apiRequest{
+			query: `
 			query getAccount($accountNumber: String!) {
 				account(accountNumber: $accountNumber) {
 					electricityAgreements {
 						meterPoint {
+							mpan
 							meters {
+								serialNumber
 								smartDevices {
 									deviceId
 								}
@@ -121,16 +370,19 @@ func (c *Client) GetMeterGUID(ctx context.Context) error {
 					}
 				}
 			}
-		`)
-
-		req.Var("accountNumber", c.accountNumber)
-		req.Header.Set("Authorization", c.token)
+		`,
+			vars:          map[string]interface{}{"accountNumber": c.accountNumber},
+			authenticated: true,
+			action:        "get meter GUID",
+		}
 
 		var resp struct {
 			Account struct {
 				ElectricityAgreements []struct {
-					MeterPoint struct {
+					MeterPoint *struct {
+						MPAN   string `json:"mpan"`
 						Meters []struct {
+							SerialNumber string `json:"serialNumber"`
 							SmartDevices []struct {
 								DeviceID string `json:"deviceId"`
 							} `json:"smartDevices"`
@@ -140,52 +392,96 @@ func (c *Client) GetMeterGUID(ctx context.Context) error {
 			} `json:"account"`
 		}
 
-		if err := c.client.Run(ctx, req, &resp); err != nil {
-			return fmt.Errorf("failed to get meter GUID: %w", err)
+		if err := c.run(ctx, req, &resp); err != nil {
+			return err
 		}
 
-		if len(resp.Account.ElectricityAgreements) == 0 ||
-			len(resp.Account.ElectricityAgreements[0].MeterPoint.Meters) == 0 ||
-			len(resp.Account.ElectricityAgreements[0].MeterPoint.Meters[0].SmartDevices) == 0 {
-			// Don't retry if no devices found - this is a permanent error
-			return backoff.Permanent(fmt.Errorf("no smart devices found for account"))
+		// Missing account data will not appear on retry, so these errors are permanent
+		if len(resp.Account.ElectricityAgreements) == 0 {
+			return backoff.Permanent(fmt.Errorf("%w for account %s: check OCTOPUS_ACCOUNT_NUMBER matches the account with your electricity supply",
+				ErrNoElectricityAgreement, c.accountNumber))
+		}
+		meterPoint := resp.Account.ElectricityAgreements[0].MeterPoint
+		if meterPoint == nil {
+			return backoff.Permanent(fmt.Errorf("%w: the electricity agreement has no supply point yet, which is normal for a recent switch; try again once it is active",
+				ErrNoMeterPoint))
+		}
+		if len(meterPoint.Meters) == 0 {
+			return backoff.Permanent(fmt.Errorf("%w for MPAN %s: Octopus has no meter registered on the supply point; contact Octopus to have it added",
+				ErrNoMeter, meterPoint.MPAN))
+		}
+		meter := meterPoint.Meters[0]
+		if len(meter.SmartDevices) == 0 {
+			return backoff.Permanent(fmt.Errorf("%w for meter %s: pair your Home Mini in the Octopus app and wait for it to appear on your account",
+				ErrNoSmartDevice, meter.SerialNumber))
 		}
 
-		c.meterGUID = resp.Account.ElectricityAgreements[0].MeterPoint.Meters[0].SmartDevices[0].DeviceID
+		c.meterGUID = meter.SmartDevices[0].DeviceID
+		c.mpan = meterPoint.MPAN
+		c.meterSerial = meter.SerialNumber
 		return nil
 	}
 
-	b := newBackoff()
+	b := newBackoff(c.retryMaxElapsed, c.retryMaxInterval)
 	return backoff.Retry(operation, backoff.WithContext(b, ctx))
 }
 
-// GetTelemetry retrieves smart meter telemetry data with exponential backoff retry and circuit breaker
+// GetTelemetry retrieves smart meter telemetry data with exponential backoff retry and
+// circuit breaker, recording its phase durations to the context's WithTimings collector
 func (c *Client) GetTelemetry(ctx context.Context, start, end time.Time) ([]TelemetryData, error) {
-	if c.token == "" {
-		if err := c.Authenticate(ctx); err != nil {
+	timings := timingsFrom(ctx)
+	*timings = Timings{}
+
+	// Spans join the caller's trace, if any, through the provider of its current span
+	tracer := trace.SpanFromContext(ctx).TracerProvider().Tracer(tracerName)
+
+	if c.needsAuth() {
+		began := time.Now()
+		authCtx, span := tracer.Start(ctx, "auth")
+		err := c.Authenticate(authCtx)
+		endSpan(span, err)
+		timings.Auth = time.Since(began)
+		if err != nil {
 			return nil, err
 		}
 	}
 
+	// Meter discovery cannot be combined with the telemetry query into one document:
+	// smartMeterTelemetry takes the device ID as an argument, and GraphQL offers no way
+	// to feed one field's result into another field's arguments within a single request.
+	// The GUID is cached on the client, so this round trip only happens on a cold start.
 	if c.meterGUID == "" {
-		if err := c.GetMeterGUID(ctx); err != nil {
+		began := time.Now()
+		discoveryCtx, span := tracer.Start(ctx, "meter_discovery")
+		err := c.GetMeterGUID(discoveryCtx)
+		endSpan(span, err)
+		timings.MeterDiscovery = time.Since(began)
+		if err != nil {
 			return nil, err
 		}
 	}
 
 	// Wrap the operation in circuit breaker
+	began := time.Now()
+	fetchCtx, span := tracer.Start(ctx, "fetch")
 	result, err := c.circuitBreaker.Execute(func() (interface{}, error) {
-		return c.fetchTelemetryWithRetry(ctx, start, end)
+		return c.fetchTelemetryWithRetry(fetchCtx, start, end)
 	})
+	timings.Telemetry = time.Since(began)
 
 	if err != nil {
+		endSpan(span, err)
 		return nil, err
 	}
 
 	data, ok := result.([]TelemetryData)
 	if !ok {
-		return nil, fmt.Errorf("unexpected result type from circuit breaker")
+		err := fmt.Errorf("unexpected result type from circuit breaker")
+		endSpan(span, err)
+		return nil, err
 	}
+	span.SetAttributes(attribute.Int("points", len(data)))
+	endSpan(span, nil)
 	return data, nil
 }
 
@@ -194,13 +490,14 @@ func (c *Client) fetchTelemetryWithRetry(ctx context.Context, start, end time.Ti
 	var telemetry []TelemetryData
 
 	operation := func() error {
-		req := graphql.NewRequest(`
-			query getTelemetry($deviceId: String!, $start: DateTime!, $end: DateTime!) {
+		req := apiRequest{
+			query: `
+			query getTelemetry($deviceId: String!, $start: DateTime!, $end: DateTime!, $grouping: TelemetryGrouping!) {
 				smartMeterTelemetry(
 					deviceId: $deviceId
 					start: $start
 					end: $end
-					grouping: TEN_SECONDS
+					grouping: $grouping
 				) {
 					readAt
 					consumptionDelta
@@ -209,32 +506,48 @@ func (c *Client) fetchTelemetryWithRetry(ctx context.Context, start, end time.Ti
 					consumption
 				}
 			}
-		`)
-
-		req.Var("deviceId", c.meterGUID)
-		req.Var("start", start.Format(time.RFC3339))
-		req.Var("end", end.Format(time.RFC3339))
-		req.Header.Set("Authorization", c.token)
+		`,
+			vars: map[string]interface{}{
+				"deviceId": c.meterGUID,
+				"start":    start.Format(time.RFC3339),
+				"end":      end.Format(time.RFC3339),
+				"grouping": c.grouping,
+			},
+			authenticated: true,
+			action:        "get telemetry",
+		}
 
+		// Keep the raw payload so it can be audited before decoding
 		var resp struct {
-			SmartMeterTelemetry []struct {
-				ReadAt           string  `json:"readAt"`
-				ConsumptionDelta float64 `json:"consumptionDelta"`
-				Demand           float64 `json:"demand"`
-				CostDelta        float64 `json:"costDelta"`
-				Consumption      float64 `json:"consumption"`
-			} `json:"smartMeterTelemetry"`
+			SmartMeterTelemetry json.RawMessage `json:"smartMeterTelemetry"`
+		}
+
+		if err := c.run(ctx, req, &resp); err != nil {
+			return err
 		}
 
-		if err := c.client.Run(ctx, req, &resp); err != nil {
-			return fmt.Errorf("failed to get telemetry: %w", err)
+		c.auditTelemetry(ctx, start, end, resp.SmartMeterTelemetry)
+
+		var readings []struct {
+			ReadAt           string  `json:"readAt"`
+			ConsumptionDelta float64 `json:"consumptionDelta"`
+			Demand           float64 `json:"demand"`
+			CostDelta        float64 `json:"costDelta"`
+			Consumption      float64 `json:"consumption"`
+		}
+		if len(resp.SmartMeterTelemetry) > 0 {
+			if err := json.Unmarshal(resp.SmartMeterTelemetry, &readings); err != nil {
+				return fmt.Errorf("failed to decode telemetry: %w", err)
+			}
 		}
 
-		telemetry = make([]TelemetryData, 0, len(resp.SmartMeterTelemetry))
-		for _, data := range resp.SmartMeterTelemetry {
-			readAt, err := time.Parse(time.RFC3339, data.ReadAt)
+		telemetry = make([]TelemetryData, 0, len(readings))
+		for _, data := range readings {
+			readAt, err := c.parseReadAt(data.ReadAt)
 			if err != nil {
-				continue // Skip invalid timestamps
+				c.skippedReadings.Add(1)
+				log.Printf("Skipping telemetry reading with unrecognised readAt %s", quoteRaw(data.ReadAt))
+				continue
 			}
 
 			telemetry = append(telemetry, TelemetryData{
@@ -249,7 +562,7 @@ func (c *Client) fetchTelemetryWithRetry(ctx context.Context, start, end time.Ti
 		return nil
 	}
 
-	b := newBackoff()
+	b := newBackoff(c.retryMaxElapsed, c.retryMaxInterval)
 	if err := backoff.Retry(operation, backoff.WithContext(b, ctx)); err != nil {
 		return nil, err
 	}
@@ -257,8 +570,71 @@ func (c *Client) fetchTelemetryWithRetry(ctx context.Context, start, end time.Ti
 	return telemetry, nil
 }
 
-// Initialize performs authentication and retrieves the meter GUID
+// maxLoggedRawLength caps how much of an unexpected API value is logged
+const maxLoggedRawLength = 64
+
+// quoteRaw quotes an unexpected API value for logging, escaping control characters and
+// cutting it short so a malformed response cannot flood or forge log lines
+func quoteRaw(value string) string {
+	if len(value) > maxLoggedRawLength {
+		return strconv.Quote(value[:maxLoggedRawLength]) + "..."
+	}
+	return strconv.Quote(value)
+}
+
+// tracerName identifies the client's spans
+const tracerName = "github.com/soothill/octopus-home-mini/pkg/octopus"
+
+// endSpan marks span as failed with err, if any, and ends it. API errors never
+// include the token, which is only sent in a request header.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// IsCircuitOpen reports whether err was returned because the circuit breaker is
+// rejecting requests (open, or half-open with its probe quota in use)
+func IsCircuitOpen(err error) bool {
+	return errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests)
+}
+
+// MPAN returns the meter point administration number discovered by GetMeterGUID
+func (c *Client) MPAN() string {
+	return c.mpan
+}
+
+// MeterSerial returns the serial number of the meter discovered by GetMeterGUID
+func (c *Client) MeterSerial() string {
+	return c.meterSerial
+}
+
+// Initialize performs authentication and retrieves the meter GUID. A provided or persisted
+// token that is still valid is reused instead of authenticating; if the API rejects it,
+// Initialize falls back to fresh authentication with the API key. The token is kept when
+// the API is down for maintenance, since that says nothing about the token.
 func (c *Client) Initialize(ctx context.Context) error {
+	if c.useProvidedToken() {
+		err := c.GetMeterGUID(ctx)
+		if err == nil || c.apiKey == "" || IsMaintenance(err) {
+			return err
+		}
+		log.Printf("Provided Octopus API token not accepted, authenticating with the API key: %v", err)
+		c.providedToken = ""
+		c.token = ""
+	}
+
+	if c.loadToken() {
+		err := c.GetMeterGUID(ctx)
+		if err == nil || IsMaintenance(err) {
+			return err
+		}
+		log.Printf("Persisted Octopus API token not accepted, re-authenticating: %v", err)
+		c.discardToken()
+	}
+
 	if err := c.Authenticate(ctx); err != nil {
 		return err
 	}
@@ -2,8 +2,23 @@ package octopus
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/sony/gobreaker"
 )
 
 func TestNewClient(t *testing.T) {
@@ -303,7 +318,7 @@ func TestClient_MultipleClients(t *testing.T) {
 
 func TestClient_BackoffConfiguration(t *testing.T) {
 	// Test that backoff is properly configured
-	b := newBackoff()
+	b := newBackoff(maxElapsedTime, maxInterval)
 
 	if b == nil {
 		t.Fatal("newBackoff() returned nil")
@@ -312,6 +327,29 @@ func TestClient_BackoffConfiguration(t *testing.T) {
 	if b.MaxElapsedTime != maxElapsedTime {
 		t.Errorf("MaxElapsedTime = %v, want %v", b.MaxElapsedTime, maxElapsedTime)
 	}
+
+	if b.MaxInterval != maxInterval {
+		t.Errorf("MaxInterval = %v, want %v", b.MaxInterval, maxInterval)
+	}
+}
+
+func TestClient_SetRetryBudget(t *testing.T) {
+	client := NewClient("test_key", "A-12345678")
+	client.SetRetryBudget(90*time.Second, 20*time.Second)
+
+	b := newBackoff(client.retryMaxElapsed, client.retryMaxInterval)
+	if b.MaxElapsedTime != 90*time.Second {
+		t.Errorf("MaxElapsedTime = %v, want 90s", b.MaxElapsedTime)
+	}
+	if b.MaxInterval != 20*time.Second {
+		t.Errorf("MaxInterval = %v, want 20s", b.MaxInterval)
+	}
+
+	// Non-positive values keep the existing budget
+	client.SetRetryBudget(0, -1)
+	if client.retryMaxElapsed != 90*time.Second || client.retryMaxInterval != 20*time.Second {
+		t.Errorf("SetRetryBudget(0, -1) changed budget to %v/%v", client.retryMaxElapsed, client.retryMaxInterval)
+	}
 }
 
 func TestClient_TimeZoneHandling(t *testing.T) {
@@ -504,3 +542,742 @@ func TestClient_ConcurrentAccess(t *testing.T) {
 		<-done
 	}
 }
+
+func TestClient_GetMeterGUID_MeterIdentifiers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"data": {
+				"account": {
+					"electricityAgreements": [{
+						"meterPoint": {
+							"mpan": "1012345678901",
+							"meters": [{
+								"serialNumber": "21L1234567",
+								"smartDevices": [{"deviceId": "00-11-22-33-44-55-66-77"}]
+							}]
+						}
+					}]
+				}
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithEndpoint("test_key", "A-12345678", server.URL)
+	client.token = "test_token"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.GetMeterGUID(ctx); err != nil {
+		t.Fatalf("GetMeterGUID() error = %v", err)
+	}
+
+	if client.meterGUID != "00-11-22-33-44-55-66-77" {
+		t.Errorf("meterGUID = %v, want 00-11-22-33-44-55-66-77", client.meterGUID)
+	}
+	if client.MPAN() != "1012345678901" {
+		t.Errorf("MPAN() = %v, want 1012345678901", client.MPAN())
+	}
+	if client.MeterSerial() != "21L1234567" {
+		t.Errorf("MeterSerial() = %v, want 21L1234567", client.MeterSerial())
+	}
+}
+
+func TestClient_GetMeterGUID_OnboardingErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		agreements string
+		wantErr    error
+	}{
+		{name: "no agreement", agreements: `[]`, wantErr: ErrNoElectricityAgreement},
+		{name: "no meter point", agreements: `[{"meterPoint": null}]`, wantErr: ErrNoMeterPoint},
+		{name: "no meter", agreements: `[{"meterPoint": {"mpan": "1012345678901", "meters": []}}]`, wantErr: ErrNoMeter},
+		{name: "no smart device", agreements: `[{"meterPoint": {"mpan": "1012345678901", "meters": [{"serialNumber": "21L1234567", "smartDevices": []}]}}]`, wantErr: ErrNoSmartDevice},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"data": {"account": {"electricityAgreements": %s}}}`, tt.agreements)
+			}))
+			defer server.Close()
+
+			client := NewClientWithEndpoint("test_key", "A-12345678", server.URL)
+			client.token = "test_token"
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			err := client.GetMeterGUID(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetMeterGUID() error = %v, want %v", err, tt.wantErr)
+			}
+			for _, other := range []error{ErrNoElectricityAgreement, ErrNoMeterPoint, ErrNoMeter, ErrNoSmartDevice} {
+				if other != tt.wantErr && errors.Is(err, other) {
+					t.Errorf("GetMeterGUID() error = %v, also matches %v", err, other)
+				}
+			}
+			if requests != 1 {
+				t.Errorf("requests = %d, want 1 (onboarding errors are not retried)", requests)
+			}
+		})
+	}
+}
+
+func newTelemetryServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"data": {
+				"smartMeterTelemetry": [
+					{"readAt": "2024-01-01T12:00:00Z", "consumptionDelta": 1.5, "demand": 500, "costDelta": 0.3, "consumption": 100}
+				]
+			}
+		}`))
+	}))
+}
+
+func TestClient_AuditResponses(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("enabled=%v", enabled), func(t *testing.T) {
+			server := newTelemetryServer(t)
+			defer server.Close()
+
+			auditDir := filepath.Join(os.TempDir(), fmt.Sprintf("test_audit_%v", enabled))
+			defer os.RemoveAll(auditDir)
+
+			client := NewClientWithEndpoint("test_key", "A-12345678", server.URL)
+			client.token = "secret_token"
+			client.meterGUID = "test-guid"
+			if enabled {
+				if err := client.SetAudit(auditDir, 5); err != nil {
+					t.Fatalf("SetAudit() error = %v", err)
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			ctx = WithCorrelationID(ctx, "poll-1234")
+
+			data, err := client.GetTelemetry(ctx, time.Now().Add(-time.Hour), time.Now())
+			if err != nil {
+				t.Fatalf("GetTelemetry() error = %v", err)
+			}
+			if len(data) != 1 || data[0].Demand != 500 {
+				t.Errorf("GetTelemetry() = %+v, want one point with demand 500", data)
+			}
+
+			files, _ := filepath.Glob(filepath.Join(auditDir, auditFilePattern))
+			if !enabled {
+				if len(files) != 0 {
+					t.Errorf("audit files written while disabled: %v", files)
+				}
+				return
+			}
+
+			if len(files) != 1 {
+				t.Fatalf("audit files = %d, want 1", len(files))
+			}
+			content, err := os.ReadFile(files[0])
+			if err != nil {
+				t.Fatalf("failed to read audit file: %v", err)
+			}
+			if !strings.Contains(string(content), `"consumptionDelta": 1.5`) {
+				t.Errorf("audit file missing raw response: %s", content)
+			}
+			if strings.Contains(string(content), "secret_token") {
+				t.Error("audit file must not contain the auth token")
+			}
+			if !strings.Contains(string(content), `"correlation_id": "poll-1234"`) {
+				t.Errorf("audit file missing correlation ID: %s", content)
+			}
+		})
+	}
+}
+
+func TestClient_TimestampLayouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"data": {
+				"smartMeterTelemetry": [
+					{"readAt": "2024-01-01T12:00:00Z", "demand": 1},
+					{"readAt": "2024-01-01T12:00:10.5+01:00", "demand": 2},
+					{"readAt": "2024-01-01 12:00:20", "demand": 3},
+					{"readAt": "01/01/2024 12:00", "demand": 4}
+				]
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		layouts     []string
+		wantDemands []float64
+		wantSkipped int64
+	}{
+		{name: "default RFC 3339", layouts: nil, wantDemands: []float64{1, 2}, wantSkipped: 2},
+		{
+			name:        "zoneless layout added",
+			layouts:     []string{time.RFC3339, "2006-01-02 15:04:05"},
+			wantDemands: []float64{1, 2, 3},
+			wantSkipped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClientWithEndpoint("test_key", "A-12345678", server.URL)
+			client.token = "test_token"
+			client.meterGUID = "test-guid"
+			client.SetTimestampLayouts(tt.layouts)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			data, err := client.GetTelemetry(ctx, time.Now().Add(-time.Hour), time.Now())
+			if err != nil {
+				t.Fatalf("GetTelemetry() error = %v", err)
+			}
+			if len(data) != len(tt.wantDemands) {
+				t.Fatalf("GetTelemetry() = %d readings, want %d", len(data), len(tt.wantDemands))
+			}
+			for i, want := range tt.wantDemands {
+				if data[i].Demand != want {
+					t.Errorf("reading %d demand = %v, want %v", i, data[i].Demand, want)
+				}
+			}
+			if got := client.SkippedReadingCount(); got != tt.wantSkipped {
+				t.Errorf("SkippedReadingCount() = %d, want %d", got, tt.wantSkipped)
+			}
+
+			// Zoneless timestamps are read as UTC
+			if len(data) == 3 {
+				want := time.Date(2024, 1, 1, 12, 0, 20, 0, time.UTC)
+				if !data[2].ReadAt.Equal(want) {
+					t.Errorf("zoneless readAt = %v, want %v", data[2].ReadAt, want)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_AuditRetention(t *testing.T) {
+	server := newTelemetryServer(t)
+	defer server.Close()
+
+	auditDir := filepath.Join(os.TempDir(), "test_audit_retention")
+	defer os.RemoveAll(auditDir)
+
+	client := NewClientWithEndpoint("test_key", "A-12345678", server.URL)
+	client.token = "test_token"
+	client.meterGUID = "test-guid"
+	if err := client.SetAudit(auditDir, 2); err != nil {
+		t.Fatalf("SetAudit() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i := 0; i < 4; i++ {
+		if _, err := client.GetTelemetry(ctx, time.Now().Add(-time.Hour), time.Now()); err != nil {
+			t.Fatalf("GetTelemetry() error = %v", err)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(auditDir, auditFilePattern))
+	if len(files) != 2 {
+		t.Errorf("audit files = %d, want 2 after pruning", len(files))
+	}
+}
+
+func TestIsCircuitOpen(t *testing.T) {
+	if !IsCircuitOpen(gobreaker.ErrOpenState) {
+		t.Error("IsCircuitOpen(ErrOpenState) = false, want true")
+	}
+	if !IsCircuitOpen(fmt.Errorf("wrapped: %w", gobreaker.ErrTooManyRequests)) {
+		t.Error("IsCircuitOpen(wrapped ErrTooManyRequests) = false, want true")
+	}
+	if IsCircuitOpen(fmt.Errorf("failed to get telemetry")) {
+		t.Error("IsCircuitOpen(other error) = true, want false")
+	}
+}
+
+func TestClient_AuthorizationHeader(t *testing.T) {
+	var mu sync.Mutex
+	headers := make(map[string][]string)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		var operation, response string
+		switch {
+		case strings.Contains(body.Query, "obtainKrakenToken"):
+			operation = "token"
+			response = `{"data": {"obtainKrakenToken": {"token": "fresh_token"}}}`
+		case strings.Contains(body.Query, "getAccount"):
+			operation = "account"
+			response = `{"data": {"account": {"electricityAgreements": [{"meterPoint": {"mpan": "1", "meters": [{"serialNumber": "S", "smartDevices": [{"deviceId": "guid"}]}]}}]}}}`
+		case strings.Contains(body.Query, "getTelemetry"):
+			operation = "telemetry"
+			response = `{"data": {"smartMeterTelemetry": []}}`
+		default:
+			t.Errorf("unexpected query: %s", body.Query)
+		}
+
+		mu.Lock()
+		headers[operation] = r.Header.Values("Authorization")
+		mu.Unlock()
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client := NewClientWithEndpoint("test_key", "A-12345678", server.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.GetTelemetry(ctx, time.Now().Add(-time.Hour), time.Now()); err != nil {
+		t.Fatalf("GetTelemetry() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got, ok := headers["token"]; !ok || len(got) != 0 {
+		t.Errorf("token mutation Authorization = %v (sent %v), want none", got, ok)
+	}
+	for _, operation := range []string{"account", "telemetry"} {
+		got := headers[operation]
+		if len(got) != 1 || got[0] != "fresh_token" {
+			t.Errorf("%s query Authorization = %v, want [fresh_token]", operation, got)
+		}
+	}
+}
+
+func TestClient_RunWrapsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"errors": [{"message": "invalid token"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithEndpoint("test_key", "A-12345678", server.URL)
+
+	err := client.run(context.Background(), apiRequest{query: "query { viewer { id } }", authenticated: true, action: "get viewer"}, &struct{}{})
+	if err == nil || !strings.HasPrefix(err.Error(), "failed to get viewer: ") {
+		t.Errorf("run() error = %v, want prefix %q", err, "failed to get viewer: ")
+	}
+}
+
+func TestClient_RequestTimeout(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first request stalls until the client gives up on it. The body is drained
+		// so the server notices the client closing the connection.
+		io.Copy(io.Discard, r.Body)
+		if calls.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"obtainKrakenToken": {"token": "test_token"}}}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithEndpoint("test_key", "A-12345678", server.URL)
+	client.SetRetryBudget(5*time.Second, 100*time.Millisecond)
+	client.SetRequestTimeout(200 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if err := client.Authenticate(ctx); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Authenticate() took %v, want the stalled request abandoned at the request timeout", elapsed)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("requests = %d, want 2 (stalled request then retry)", got)
+	}
+	if client.token != "test_token" {
+		t.Errorf("token = %q, want test_token", client.token)
+	}
+}
+
+func TestClient_TransportTimeout(t *testing.T) {
+	// Accept connections but never respond, like a stalled TCP connection
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer listener.Close()
+	var mu sync.Mutex
+	var conns []net.Conn
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+	}()
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	client := NewClientWithEndpoint("test_key", "A-12345678", "http://"+listener.Addr().String())
+	client.SetRetryBudget(500*time.Millisecond, 100*time.Millisecond)
+	client.SetTransportTimeouts(TransportTimeouts{
+		Dial:           time.Second,
+		TLSHandshake:   time.Second,
+		ResponseHeader: 100 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err = client.Authenticate(ctx)
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Authenticate() took %v, want the stall cut short by the response header timeout", elapsed)
+	}
+	if !errors.Is(err, ErrTransportTimeout) {
+		t.Errorf("Authenticate() error = %v, want ErrTransportTimeout", err)
+	}
+
+	// Running out the request timeout instead is not reported as a transport timeout
+	client.SetTransportTimeouts(TransportTimeouts{})
+	client.SetRequestTimeout(100 * time.Millisecond)
+	err = client.Authenticate(ctx)
+	if err == nil || errors.Is(err, ErrTransportTimeout) {
+		t.Errorf("Authenticate() error = %v, want a request timeout that is not ErrTransportTimeout", err)
+	}
+}
+
+// testJWT returns an unsigned JWT whose exp claim is expiresAt
+func testJWT(expiresAt time.Time) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		encode([]byte(fmt.Sprintf(`{"exp":%d}`, expiresAt.Unix()))) + ".signature"
+}
+
+func TestClient_Maintenance(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          int
+		contentType     string
+		body            string
+		wantMaintenance bool
+	}{
+		{name: "503 maintenance page", status: http.StatusServiceUnavailable, contentType: "text/html; charset=utf-8", body: "<html><h1>Down for maintenance</h1></html>", wantMaintenance: true},
+		{name: "503 without a body", status: http.StatusServiceUnavailable, wantMaintenance: true},
+		{name: "HTML page with 200", status: http.StatusOK, contentType: "text/html", body: "<html><h1>We'll be back soon</h1></html>", wantMaintenance: true},
+		{name: "500 error", status: http.StatusInternalServerError, contentType: "text/plain", body: "internal error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewClientWithEndpoint("test_key", "A-12345678", server.URL)
+			client.SetRetryBudget(300*time.Millisecond, 50*time.Millisecond)
+			client.token = "test_token"
+
+			err := client.GetMeterGUID(context.Background())
+			if err == nil {
+				t.Fatal("GetMeterGUID() error = nil, want an error")
+			}
+			if got := IsMaintenance(err); got != tt.wantMaintenance {
+				t.Fatalf("IsMaintenance(%v) = %v, want %v", err, got, tt.wantMaintenance)
+			}
+			if tt.wantMaintenance && requests.Load() != 1 {
+				t.Errorf("requests = %d, want 1 (maintenance is not retried within a call)", requests.Load())
+			}
+		})
+	}
+}
+
+func TestClient_PersistedToken(t *testing.T) {
+	var authCalls atomic.Int32
+	freshToken := testJWT(time.Now().Add(time.Hour))
+	revokedToken := testJWT(time.Now().Add(time.Hour).Add(time.Second))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(body.Query, "obtainKrakenToken"):
+			authCalls.Add(1)
+			fmt.Fprintf(w, `{"data": {"obtainKrakenToken": {"token": %q}}}`, freshToken)
+		case r.Header.Get("Authorization") == revokedToken:
+			w.Write([]byte(`{"errors": [{"message": "Invalid JSON Web Token"}]}`))
+		default:
+			w.Write([]byte(`{"data": {"account": {"electricityAgreements": [{"meterPoint": {"mpan": "1", "meters": [{"serialNumber": "S", "smartDevices": [{"deviceId": "guid"}]}]}}]}}}`))
+		}
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "octopus_token.json")
+	newClient := func() *Client {
+		client := NewClientWithEndpoint("test_key", "A-12345678", server.URL)
+		client.SetRetryBudget(time.Second, 100*time.Millisecond)
+		if err := client.SetTokenFile(tokenFile); err != nil {
+			t.Fatalf("SetTokenFile() error = %v", err)
+		}
+		return client
+	}
+	writeToken := func(token string, expiresAt time.Time) {
+		data, _ := json.Marshal(persistedToken{
+			Token:         token,
+			ExpiresAt:     expiresAt,
+			AccountNumber: "A-12345678",
+			APIKeyHash:    apiKeyHash("test_key"),
+		})
+		if err := os.WriteFile(tokenFile, data, 0600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	ctx := context.Background()
+
+	// A cold start authenticates and persists the token privately
+	if err := newClient().Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if got := authCalls.Load(); got != 1 {
+		t.Fatalf("auth calls after cold start = %d, want 1", got)
+	}
+	info, err := os.Stat(tokenFile)
+	if err != nil {
+		t.Fatalf("token file not written: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("token file mode = %o, want 600", perm)
+	}
+
+	// A restart within the token's validity reuses it
+	client := newClient()
+	if err := client.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if got := authCalls.Load(); got != 1 {
+		t.Errorf("auth calls after restart with a valid token = %d, want 1", got)
+	}
+	if client.token != freshToken {
+		t.Error("restarted client did not reuse the persisted token")
+	}
+
+	// An expired token triggers re-authentication
+	writeToken(testJWT(time.Now().Add(-time.Minute)), time.Now().Add(-time.Minute))
+	if err := newClient().Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if got := authCalls.Load(); got != 2 {
+		t.Errorf("auth calls after restart with an expired token = %d, want 2", got)
+	}
+
+	// A token the API rejects is discarded in favor of a fresh one
+	writeToken(revokedToken, time.Now().Add(time.Hour))
+	client = newClient()
+	if err := client.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if got := authCalls.Load(); got != 3 {
+		t.Errorf("auth calls after restart with a revoked token = %d, want 3", got)
+	}
+	if client.token != freshToken {
+		t.Error("client kept the rejected token")
+	}
+}
+
+func TestClient_ProvidedToken(t *testing.T) {
+	var authCalls atomic.Int32
+	freshToken := testJWT(time.Now().Add(time.Hour))
+	providedToken := testJWT(time.Now().Add(2 * time.Hour))
+	revokedToken := testJWT(time.Now().Add(3 * time.Hour))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(body.Query, "obtainKrakenToken"):
+			authCalls.Add(1)
+			fmt.Fprintf(w, `{"data": {"obtainKrakenToken": {"token": %q}}}`, freshToken)
+		case r.Header.Get("Authorization") == revokedToken:
+			w.Write([]byte(`{"errors": [{"message": "Invalid JSON Web Token"}]}`))
+		default:
+			w.Write([]byte(`{"data": {"account": {"electricityAgreements": [{"meterPoint": {"mpan": "1", "meters": [{"serialNumber": "S", "smartDevices": [{"deviceId": "guid"}]}]}}]}, "smartMeterTelemetry": []}}`))
+		}
+	}))
+	defer server.Close()
+
+	newClient := func(apiKey, token string) *Client {
+		client := NewClientWithEndpoint(apiKey, "A-12345678", server.URL)
+		client.SetRetryBudget(time.Second, 100*time.Millisecond)
+		client.SetToken(token)
+		return client
+	}
+	ctx := context.Background()
+
+	// A valid provided token is used without calling the auth mutation, even with no API key
+	client := newClient("", providedToken)
+	if err := client.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if _, err := client.GetTelemetry(ctx, time.Now().Add(-time.Hour), time.Now()); err != nil {
+		t.Fatalf("GetTelemetry() error = %v", err)
+	}
+	if got := authCalls.Load(); got != 0 {
+		t.Errorf("auth calls with a provided token = %d, want 0", got)
+	}
+	if client.token != providedToken {
+		t.Error("client did not use the provided token")
+	}
+
+	// An expired provided token is refreshed with the API key
+	client = newClient("test_key", testJWT(time.Now().Add(-time.Minute)))
+	if err := client.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if got := authCalls.Load(); got != 1 || client.token != freshToken {
+		t.Errorf("auth calls with an expired provided token = %d, want 1 and the fresh token", got)
+	}
+
+	// ...and is an error without one
+	client = newClient("", testJWT(time.Now().Add(-time.Minute)))
+	if err := client.Initialize(ctx); !errors.Is(err, ErrNoAPIKey) {
+		t.Errorf("Initialize() error = %v, want ErrNoAPIKey", err)
+	}
+
+	// A provided token expiring mid-run is replaced before the next request
+	client = newClient("test_key", providedToken)
+	if err := client.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	expiring := testJWT(time.Now().Add(time.Minute))
+	client.providedToken, client.token = expiring, expiring
+	if _, err := client.GetTelemetry(ctx, time.Now().Add(-time.Hour), time.Now()); err != nil {
+		t.Fatalf("GetTelemetry() error = %v", err)
+	}
+	if got := authCalls.Load(); got != 2 || client.token != freshToken {
+		t.Errorf("auth calls after the provided token expired = %d, want 2 and the fresh token", got)
+	}
+
+	// A provided token the API rejects falls back to the API key
+	client = newClient("test_key", revokedToken)
+	if err := client.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if got := authCalls.Load(); got != 3 || client.token != freshToken {
+		t.Errorf("auth calls after the provided token was rejected = %d, want 3 and the fresh token", got)
+	}
+}
+
+func TestTokenExpiry(t *testing.T) {
+	expiresAt := time.Unix(1767225600, 0)
+	got, err := tokenExpiry(testJWT(expiresAt))
+	if err != nil || !got.Equal(expiresAt) {
+		t.Errorf("tokenExpiry() = %v, %v, want %v", got, err, expiresAt)
+	}
+
+	for _, token := range []string{"fresh_token", "a.!!!.c", "a." + base64.RawURLEncoding.EncodeToString([]byte(`{}`)) + ".c"} {
+		if _, err := tokenExpiry(token); err == nil {
+			t.Errorf("tokenExpiry(%q) expected error, got nil", token)
+		}
+	}
+}
+
+func TestClient_GetSavingSessions(t *testing.T) {
+	var queries []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		queries = append(queries, body.Query)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"data": {
+				"obtainKrakenToken": {"token": "test_token"},
+				"savingSessions": {
+					"events": [
+						{"code": "EVENT_1", "startAt": "2026-01-20T17:30:00Z", "endAt": "2026-01-20T18:30:00Z"},
+						{"code": "EVENT_2", "startAt": "2026-01-27T08:00:00+00:00", "endAt": "2026-01-27T09:00:00+00:00"}
+					]
+				}
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithEndpoint("test_key", "A-12345678", server.URL)
+	sessions, err := client.GetSavingSessions(context.Background())
+	if err != nil {
+		t.Fatalf("GetSavingSessions() error = %v", err)
+	}
+
+	want := []SavingSession{
+		{Code: "EVENT_1", StartAt: time.Date(2026, 1, 20, 17, 30, 0, 0, time.UTC), EndAt: time.Date(2026, 1, 20, 18, 30, 0, 0, time.UTC)},
+		{Code: "EVENT_2", StartAt: time.Date(2026, 1, 27, 8, 0, 0, 0, time.UTC), EndAt: time.Date(2026, 1, 27, 9, 0, 0, 0, time.UTC)},
+	}
+	if len(sessions) != len(want) {
+		t.Fatalf("GetSavingSessions() = %+v, want %+v", sessions, want)
+	}
+	for i, session := range sessions {
+		if session.Code != want[i].Code || !session.StartAt.Equal(want[i].StartAt) || !session.EndAt.Equal(want[i].EndAt) {
+			t.Errorf("session %d = %+v, want %+v", i, session, want[i])
+		}
+	}
+
+	// Authenticates first when there is no token yet
+	mu.Lock()
+	defer mu.Unlock()
+	if len(queries) != 2 || !strings.Contains(queries[0], "obtainKrakenToken") || !strings.Contains(queries[1], "savingSessions") {
+		t.Errorf("queries = %q, want authentication then saving sessions", queries)
+	}
+}
@@ -0,0 +1,54 @@
+package octopus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// ErrMaintenance is returned when the API answers with a 503 or an HTML page instead of
+// GraphQL, as it does during Kraken maintenance. Such a response is not retried within
+// the call: the outage typically lasts far longer than the retry budget.
+var ErrMaintenance = errors.New("the Octopus API is unavailable for maintenance")
+
+// maxMaintenanceDrain caps how much of a maintenance page is read before closing it
+const maxMaintenanceDrain = 64 << 10
+
+// maintenanceTransport turns maintenance responses into ErrMaintenance before the
+// GraphQL client tries to decode them, which would fail with an opaque JSON error
+type maintenanceTransport struct {
+	base http.RoundTripper
+}
+
+func (t maintenanceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !isMaintenanceResponse(resp) {
+		return resp, err
+	}
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxMaintenanceDrain))
+	resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "no content type"
+	}
+	return nil, fmt.Errorf("%w (HTTP %d, %s)", ErrMaintenance, resp.StatusCode, contentType)
+}
+
+// isMaintenanceResponse reports whether resp is a 503 or an HTML page, neither of which
+// the GraphQL endpoint returns while it is serving
+func isMaintenanceResponse(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && mediaType == "text/html"
+}
+
+// IsMaintenance reports whether err was caused by the API being down for maintenance
+func IsMaintenance(err error) bool {
+	return errors.Is(err, ErrMaintenance)
+}
@@ -0,0 +1,82 @@
+package octopus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// SavingSession is an Octopus Saving Session: a window in which customers who join
+// are rewarded for using less electricity than usual
+type SavingSession struct {
+	Code    string
+	StartAt time.Time
+	EndAt   time.Time
+}
+
+// GetSavingSessions returns the Saving Sessions the API lists, past and upcoming, with
+// exponential backoff retry. It authenticates first if needed but does not go through
+// the telemetry circuit breaker, so its failures never hold up polling.
+func (c *Client) GetSavingSessions(ctx context.Context) ([]SavingSession, error) {
+	if c.needsAuth() {
+		if err := c.Authenticate(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var sessions []SavingSession
+	operation := func() error {
+		req := apiRequest{
+			query: `
+			query getSavingSessions {
+				savingSessions {
+					events {
+						code
+						startAt
+						endAt
+					}
+				}
+			}
+		`,
+			authenticated: true,
+			action:        "get saving sessions",
+		}
+
+		var resp struct {
+			SavingSessions struct {
+				Events []struct {
+					Code    string `json:"code"`
+					StartAt string `json:"startAt"`
+					EndAt   string `json:"endAt"`
+				} `json:"events"`
+			} `json:"savingSessions"`
+		}
+
+		if err := c.run(ctx, req, &resp); err != nil {
+			return err
+		}
+
+		sessions = make([]SavingSession, 0, len(resp.SavingSessions.Events))
+		for _, event := range resp.SavingSessions.Events {
+			startAt, err := time.Parse(time.RFC3339, event.StartAt)
+			if err != nil {
+				return backoff.Permanent(fmt.Errorf("invalid start time for saving session %s: %w", event.Code, err))
+			}
+			endAt, err := time.Parse(time.RFC3339, event.EndAt)
+			if err != nil {
+				return backoff.Permanent(fmt.Errorf("invalid end time for saving session %s: %w", event.Code, err))
+			}
+			sessions = append(sessions, SavingSession{Code: event.Code, StartAt: startAt, EndAt: endAt})
+		}
+		return nil
+	}
+
+	b := newBackoff(c.retryMaxElapsed, c.retryMaxInterval)
+	if err := backoff.Retry(operation, backoff.WithContext(b, ctx)); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
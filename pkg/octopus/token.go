@@ -0,0 +1,201 @@
+package octopus
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tokenExpiryMargin is how long a persisted token must still be valid to be reused,
+// so a restart does not pick up a token that expires moments later
+const tokenExpiryMargin = 5 * time.Minute
+
+// persistedToken is the on-disk form of a Kraken token. The account number and a hash
+// of the API key tie it to the credentials it was issued for.
+type persistedToken struct {
+	Token         string    `json:"token"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	AccountNumber string    `json:"account_number"`
+	APIKeyHash    string    `json:"api_key_hash"`
+}
+
+// SetTokenFile enables persisting the API token to path so a restart within the
+// token's validity skips authentication. An empty path disables persistence.
+func (c *Client) SetTokenFile(path string) error {
+	if path == "" {
+		c.tokenFile = ""
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create token directory: %w", err)
+	}
+
+	c.tokenFile = path
+	return nil
+}
+
+// SetToken provides a Kraken token obtained elsewhere, used instead of authenticating
+// with the API key while it is valid. Once it expires, or if the API rejects it at
+// startup, the client authenticates with the API key if it has one. An empty token
+// clears it.
+func (c *Client) SetToken(token string) {
+	c.providedToken = strings.TrimSpace(token)
+}
+
+// useProvidedToken sets the client's token to the SetToken one unless it is about to
+// expire, reporting whether it did. An expiring token is dropped for good, so the API
+// key is used from then on. A token without a readable expiry is left to the API to judge.
+func (c *Client) useProvidedToken() bool {
+	if c.providedToken == "" {
+		return false
+	}
+	if expiresAt, err := tokenExpiry(c.providedToken); err == nil && time.Until(expiresAt) < tokenExpiryMargin {
+		log.Printf("Provided Octopus API token expires %s, no longer using it", expiresAt.Format(time.RFC3339))
+		c.providedToken = ""
+		return false
+	}
+
+	c.token = c.providedToken
+	return true
+}
+
+// needsAuth reports whether a token must be obtained before an authenticated request:
+// there is none yet, or the provided token in use is about to expire
+func (c *Client) needsAuth() bool {
+	if c.token == "" {
+		return true
+	}
+	if c.providedToken == "" || c.token != c.providedToken {
+		return false
+	}
+	expiresAt, err := tokenExpiry(c.token)
+	return err == nil && time.Until(expiresAt) < tokenExpiryMargin
+}
+
+// loadToken sets the client's token from the token file if one was persisted for the
+// same credentials and is not about to expire, reporting whether it did
+func (c *Client) loadToken() bool {
+	if c.tokenFile == "" {
+		return false
+	}
+
+	data, err := os.ReadFile(c.tokenFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Failed to read persisted Octopus API token: %v", err)
+		}
+		return false
+	}
+
+	var persisted persistedToken
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		log.Printf("Ignoring unreadable persisted Octopus API token: %v", err)
+		return false
+	}
+	if persisted.Token == "" || persisted.AccountNumber != c.accountNumber || persisted.APIKeyHash != apiKeyHash(c.apiKey) {
+		return false
+	}
+	if time.Until(persisted.ExpiresAt) < tokenExpiryMargin {
+		return false
+	}
+
+	c.token = persisted.Token
+	log.Printf("Reusing persisted Octopus API token (expires %s)", persisted.ExpiresAt.Format(time.RFC3339))
+	return true
+}
+
+// saveToken writes the client's token to the token file, readable only by the owner.
+// Failures are logged; the token is still used for this run.
+func (c *Client) saveToken() {
+	if c.tokenFile == "" {
+		return
+	}
+
+	expiresAt, err := tokenExpiry(c.token)
+	if err != nil {
+		log.Printf("Not persisting Octopus API token: %v", err)
+		return
+	}
+
+	data, err := json.Marshal(persistedToken{
+		Token:         c.token,
+		ExpiresAt:     expiresAt,
+		AccountNumber: c.accountNumber,
+		APIKeyHash:    apiKeyHash(c.apiKey),
+	})
+	if err != nil {
+		log.Printf("Failed to encode Octopus API token: %v", err)
+		return
+	}
+
+	// Write to a private temp file and rename, so the token is never world-readable
+	// and a crash cannot leave a truncated file behind
+	tmp, err := os.CreateTemp(filepath.Dir(c.tokenFile), ".token-*")
+	if err != nil {
+		log.Printf("Failed to persist Octopus API token: %v", err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		log.Printf("Failed to persist Octopus API token: %v", err)
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		log.Printf("Failed to persist Octopus API token: %v", err)
+		return
+	}
+	if err := os.Rename(tmp.Name(), c.tokenFile); err != nil {
+		log.Printf("Failed to persist Octopus API token: %v", err)
+	}
+}
+
+// discardToken clears the client's token and removes any persisted copy
+func (c *Client) discardToken() {
+	c.token = ""
+	if c.tokenFile == "" {
+		return
+	}
+	if err := os.Remove(c.tokenFile); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove persisted Octopus API token: %v", err)
+	}
+}
+
+// tokenExpiry reads the exp claim from a JWT without verifying its signature; the
+// API remains the authority on whether the token is accepted
+func tokenExpiry(token string) (time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("token is not a JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode token payload: %w", err)
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode token claims: %w", err)
+	}
+	if claims.Exp == 0 {
+		return time.Time{}, fmt.Errorf("token has no expiry")
+	}
+	return time.Unix(claims.Exp, 0), nil
+}
+
+// apiKeyHash identifies the API key a token was issued for without storing the key
+func apiKeyHash(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
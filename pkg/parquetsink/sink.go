@@ -0,0 +1,140 @@
+package parquetsink
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// DataPoint represents a single energy measurement as stored in a Parquet row
+type DataPoint struct {
+	Timestamp        time.Time `parquet:"timestamp,timestamp(millisecond)"`
+	ConsumptionDelta float64   `parquet:"consumption_delta"`
+	Demand           float64   `parquet:"demand"`
+	CostDelta        float64   `parquet:"cost_delta"`
+	Consumption      float64   `parquet:"consumption"`
+}
+
+// Sink buffers data points and writes them to Parquet files partitioned by day.
+// Files are laid out as <dir>/date=YYYY-MM-DD/part-<unix nanos>.parquet, which
+// DuckDB (read_parquet with hive_partitioning) and pandas can read directly.
+type Sink struct {
+	dir           string
+	flushInterval time.Duration
+
+	mu        sync.Mutex
+	buffer    []DataPoint
+	lastFlush time.Time
+}
+
+// NewSink creates a Parquet sink writing to dir. Buffered points are flushed once
+// flushInterval has elapsed since the previous flush, and on Flush or Close.
+func NewSink(dir string, flushInterval time.Duration) (*Sink, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create parquet directory: %w", err)
+	}
+
+	return &Sink{
+		dir:           dir,
+		flushInterval: flushInterval,
+		lastFlush:     time.Now(),
+	}, nil
+}
+
+// Write buffers points and flushes them if the flush interval has elapsed.
+// If the flush fails the points stay buffered for the next attempt.
+func (s *Sink) Write(points []DataPoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buffer = append(s.buffer, points...)
+
+	if time.Since(s.lastFlush) < s.flushInterval {
+		return nil
+	}
+	return s.flushLocked()
+}
+
+// Flush writes all buffered points to disk
+func (s *Sink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flushLocked()
+}
+
+// Close flushes any buffered points
+func (s *Sink) Close() error {
+	return s.Flush()
+}
+
+// Buffered returns the number of points waiting to be flushed
+func (s *Sink) Buffered() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buffer)
+}
+
+// flushLocked writes one file per day present in the buffer. The caller must hold mu.
+func (s *Sink) flushLocked() error {
+	s.lastFlush = time.Now()
+	if len(s.buffer) == 0 {
+		return nil
+	}
+
+	byDay := make(map[string][]DataPoint)
+	for _, point := range s.buffer {
+		day := point.Timestamp.UTC().Format("2006-01-02")
+		byDay[day] = append(byDay[day], point)
+	}
+
+	days := make([]string, 0, len(byDay))
+	for day := range byDay {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	for i, day := range days {
+		if err := s.writeFile(day, byDay[day]); err != nil {
+			// Keep the days that were not written
+			remaining := make([]DataPoint, 0)
+			for _, pending := range days[i:] {
+				remaining = append(remaining, byDay[pending]...)
+			}
+			s.buffer = remaining
+			return err
+		}
+	}
+
+	s.buffer = nil
+	return nil
+}
+
+// writeFile atomically writes points to a new file in the day's partition
+func (s *Sink) writeFile(day string, points []DataPoint) error {
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+
+	partition := filepath.Join(s.dir, "date="+day)
+	if err := os.MkdirAll(partition, 0755); err != nil {
+		return fmt.Errorf("failed to create parquet partition: %w", err)
+	}
+
+	filename := filepath.Join(partition, fmt.Sprintf("part-%d.parquet", time.Now().UnixNano()))
+	tmpFile := filename + ".tmp"
+	if err := parquet.WriteFile(tmpFile, points); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to write parquet file: %w", err)
+	}
+	if err := os.Rename(tmpFile, filename); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to finalize parquet file: %w", err)
+	}
+
+	return nil
+}
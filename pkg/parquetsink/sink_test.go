@@ -0,0 +1,84 @@
+package parquetsink
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestSink_FlushWritesReadableFiles(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "test_parquet_flush")
+	defer os.RemoveAll(dir)
+
+	sink, err := NewSink(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("NewSink() error = %v", err)
+	}
+
+	day1 := time.Date(2024, 1, 1, 23, 59, 50, 0, time.UTC)
+	points := []DataPoint{
+		{Timestamp: day1, ConsumptionDelta: 0.5, Demand: 1200, CostDelta: 0.15, Consumption: 10.5},
+		{Timestamp: day1.Add(10 * time.Second), ConsumptionDelta: 0.6, Demand: 1300, CostDelta: 0.18, Consumption: 11.1},
+		{Timestamp: day1.Add(20 * time.Second), ConsumptionDelta: 0.7, Demand: 1400, CostDelta: 0.21, Consumption: 11.8},
+	}
+
+	if err := sink.Write(points); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if sink.Buffered() != 3 {
+		t.Fatalf("Buffered() = %d, want 3 before flush interval", sink.Buffered())
+	}
+
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if sink.Buffered() != 0 {
+		t.Errorf("Buffered() = %d after flush, want 0", sink.Buffered())
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "date=*", "*.parquet"))
+	if len(files) != 2 {
+		t.Fatalf("parquet files = %v, want one per day", files)
+	}
+
+	var rows []DataPoint
+	for _, file := range files {
+		fileRows, err := parquet.ReadFile[DataPoint](file)
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", file, err)
+		}
+		rows = append(rows, fileRows...)
+	}
+
+	if len(rows) != len(points) {
+		t.Fatalf("rows = %d, want %d", len(rows), len(points))
+	}
+	if !rows[0].Timestamp.Equal(points[0].Timestamp) || rows[0].Demand != 1200 {
+		t.Errorf("first row = %+v, want %+v", rows[0], points[0])
+	}
+}
+
+func TestSink_WriteFlushesAfterInterval(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "test_parquet_interval")
+	defer os.RemoveAll(dir)
+
+	sink, err := NewSink(dir, 0)
+	if err != nil {
+		t.Fatalf("NewSink() error = %v", err)
+	}
+
+	if err := sink.Write([]DataPoint{{Timestamp: time.Now(), Demand: 500}}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if sink.Buffered() != 0 {
+		t.Errorf("Buffered() = %d, want 0 after interval flush", sink.Buffered())
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "date=*", "*.parquet"))
+	if len(files) != 1 {
+		t.Errorf("parquet files = %d, want 1", len(files))
+	}
+}
@@ -0,0 +1,140 @@
+// Package reconcile compares the points stored in InfluxDB over a time range with the
+// points expected at the telemetry resolution, to verify nothing was lost across an outage
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/soothill/octopus-home-mini/pkg/cache"
+)
+
+// TimeSource lists the distinct point timestamps stored between start and end inclusive
+type TimeSource interface {
+	PointTimes(ctx context.Context, start, end time.Time) ([]time.Time, error)
+}
+
+// Gap is a run of consecutive intervals with no stored point
+type Gap struct {
+	Start   time.Time // Start of the first missing interval
+	End     time.Time // End of the last missing interval (exclusive)
+	Missing int       // Intervals without a stored point
+	Cached  int       // Of those, intervals with a point still waiting in the cache
+}
+
+// Report compares the points stored over a range with those expected
+type Report struct {
+	Start    time.Time
+	End      time.Time
+	Interval time.Duration
+	Expected int // Intervals in the range
+	Stored   int // Intervals with at least one stored point
+	Cached   int // Missing intervals covered by the cache
+	Gaps     []Gap
+}
+
+// Run builds a Report for start to end inclusive, expecting one point per interval
+// aligned to the interval boundary. Points left in c count as recoverable, since the
+// next sync writes them; c may be nil.
+func Run(ctx context.Context, source TimeSource, c *cache.Cache, start, end time.Time, interval time.Duration) (Report, error) {
+	if interval <= 0 {
+		return Report{}, fmt.Errorf("interval must be positive, got %v", interval)
+	}
+	if end.Before(start) {
+		return Report{}, fmt.Errorf("end %s is before start %s", end.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+
+	report := Report{Start: start.UTC(), End: end.UTC(), Interval: interval}
+
+	first := start.UTC().Truncate(interval)
+	if first.Before(start) {
+		first = first.Add(interval)
+	}
+	if first.After(end) {
+		return report, nil
+	}
+	report.Expected = int(end.Sub(first)/interval) + 1
+
+	stored, err := source.PointTimes(ctx, first, first.Add(time.Duration(report.Expected)*interval-time.Nanosecond))
+	if err != nil {
+		return Report{}, err
+	}
+	var cachedTimes []time.Time
+	if c != nil {
+		for _, dp := range c.GetAll() {
+			cachedTimes = append(cachedTimes, dp.Timestamp)
+		}
+	}
+
+	hasStored := slots(stored, first, interval, report.Expected)
+	hasCached := slots(cachedTimes, first, interval, report.Expected)
+
+	var gap *Gap
+	for i := 0; i < report.Expected; i++ {
+		slot := first.Add(time.Duration(i) * interval)
+		if hasStored[i] {
+			report.Stored++
+			gap = nil
+			continue
+		}
+		if gap == nil {
+			report.Gaps = append(report.Gaps, Gap{Start: slot})
+			gap = &report.Gaps[len(report.Gaps)-1]
+		}
+		gap.End = slot.Add(interval)
+		gap.Missing++
+		if hasCached[i] {
+			gap.Cached++
+			report.Cached++
+		}
+	}
+
+	return report, nil
+}
+
+// slots marks which of n intervals from first hold at least one of times
+func slots(times []time.Time, first time.Time, interval time.Duration, n int) []bool {
+	marked := make([]bool, n)
+	for _, t := range times {
+		if t.Before(first) {
+			continue
+		}
+		if i := int(t.Sub(first) / interval); i < n {
+			marked[i] = true
+		}
+	}
+	return marked
+}
+
+// Lost returns the number of missing intervals not covered by the cache
+func (r Report) Lost() int {
+	return r.Expected - r.Stored - r.Cached
+}
+
+// Print writes a human-readable summary of the report followed by a table of gaps
+func (r Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "Range: %s to %s (%s interval)\n", r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339), r.Interval)
+	fmt.Fprintf(w, "Expected: %d, stored: %d, missing: %d (%d in cache, %d lost)\n",
+		r.Expected, r.Stored, r.Expected-r.Stored, r.Cached, r.Lost())
+
+	if len(r.Gaps) == 0 {
+		fmt.Fprintln(w, "\nNo gaps found")
+		return
+	}
+
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "START\tEND\tMISSING\tCACHED")
+	for _, gap := range r.Gaps {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n",
+			gap.Start.Format(time.RFC3339),
+			gap.End.Format(time.RFC3339),
+			gap.Missing,
+			gap.Cached,
+		)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,120 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soothill/octopus-home-mini/pkg/cache"
+)
+
+// fakeTimes serves stored timestamps as a mock InfluxDB query would
+type fakeTimes struct {
+	times      []time.Time
+	err        error
+	start, end time.Time
+}
+
+func (f *fakeTimes) PointTimes(ctx context.Context, start, end time.Time) ([]time.Time, error) {
+	f.start, f.end = start, end
+	return f.times, f.err
+}
+
+func TestRun(t *testing.T) {
+	const interval = 10 * time.Second
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(slot int) time.Time { return start.Add(time.Duration(slot) * interval) }
+
+	// Twelve slots: 2-4 missing but cached, 7-8 missing with only 8 cached, 11 missing
+	source := &fakeTimes{}
+	for _, slot := range []int{0, 1, 5, 6, 9, 10} {
+		source.times = append(source.times, at(slot).Add(300*time.Millisecond))
+	}
+	c, err := cache.NewCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	for _, slot := range []int{2, 3, 4, 8} {
+		if err := c.AddSingle(cache.DataPoint{Timestamp: at(slot), Demand: 100}); err != nil {
+			t.Fatalf("AddSingle() error = %v", err)
+		}
+	}
+
+	// An unaligned start rounds up to the next interval boundary
+	report, err := Run(context.Background(), source, c, start.Add(-5*time.Second), at(11).Add(5*time.Second), interval)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if !source.start.Equal(start) || !source.end.Equal(at(12).Add(-time.Nanosecond)) {
+		t.Errorf("queried %v to %v, want %v to just before %v", source.start, source.end, start, at(12))
+	}
+	if report.Expected != 12 || report.Stored != 6 || report.Cached != 4 || report.Lost() != 2 {
+		t.Errorf("Run() expected/stored/cached/lost = %d/%d/%d/%d, want 12/6/4/2",
+			report.Expected, report.Stored, report.Cached, report.Lost())
+	}
+
+	want := []Gap{
+		{Start: at(2), End: at(5), Missing: 3, Cached: 3},
+		{Start: at(7), End: at(9), Missing: 2, Cached: 1},
+		{Start: at(11), End: at(12), Missing: 1, Cached: 0},
+	}
+	if len(report.Gaps) != len(want) {
+		t.Fatalf("Run() gaps = %+v, want %+v", report.Gaps, want)
+	}
+	for i, gap := range report.Gaps {
+		if !gap.Start.Equal(want[i].Start) || !gap.End.Equal(want[i].End) || gap.Missing != want[i].Missing || gap.Cached != want[i].Cached {
+			t.Errorf("gap %d = %+v, want %+v", i, gap, want[i])
+		}
+	}
+
+	var buf bytes.Buffer
+	report.Print(&buf)
+	out := buf.String()
+	for _, line := range []string{
+		"Expected: 12, stored: 6, missing: 6 (4 in cache, 2 lost)",
+		"2026-03-01T12:00:20Z",
+		"2026-03-01T12:01:50Z",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("Print() output missing %q:\n%s", line, out)
+		}
+	}
+}
+
+func TestRun_NoGaps(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	source := &fakeTimes{times: []time.Time{start, start.Add(time.Minute)}}
+
+	report, err := Run(context.Background(), source, nil, start, start.Add(time.Minute), time.Minute)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if report.Expected != 2 || report.Stored != 2 || len(report.Gaps) != 0 {
+		t.Errorf("Run() = %+v, want 2 stored and no gaps", report)
+	}
+
+	var buf bytes.Buffer
+	report.Print(&buf)
+	if !strings.Contains(buf.String(), "No gaps found") {
+		t.Errorf("Print() output = %q, want no gaps", buf.String())
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, err := Run(context.Background(), &fakeTimes{}, nil, start, start.Add(-time.Minute), time.Minute); err == nil {
+		t.Error("Run() with end before start error = nil, want error")
+	}
+	if _, err := Run(context.Background(), &fakeTimes{}, nil, start, start.Add(time.Minute), 0); err == nil {
+		t.Error("Run() with zero interval error = nil, want error")
+	}
+	queryErr := errors.New("failed to query point times")
+	if _, err := Run(context.Background(), &fakeTimes{err: queryErr}, nil, start, start.Add(time.Minute), time.Minute); !errors.Is(err, queryErr) {
+		t.Errorf("Run() error = %v, want query error", err)
+	}
+}
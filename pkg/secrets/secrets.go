@@ -33,6 +33,9 @@ const (
 	ProviderTypeVault ProviderType = "vault"
 	// ProviderTypeK8s uses Kubernetes Secrets
 	ProviderTypeK8s ProviderType = "k8s"
+	// ProviderTypeComposite reads environment variables before a .env file and
+	// writes new secrets back to the file
+	ProviderTypeComposite ProviderType = "composite"
 )
 
 // Config holds configuration for secret providers
@@ -53,6 +56,16 @@ func NewProvider(cfg Config) (Provider, error) {
 			filePath = ".env"
 		}
 		return NewFileProvider(filePath)
+	case ProviderTypeComposite:
+		filePath := cfg.Options["file_path"]
+		if filePath == "" {
+			filePath = ".env"
+		}
+		fileProvider, err := NewFileProvider(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return NewWriteBackManager(fileProvider, NewEnvProvider(), fileProvider), nil
 	case ProviderTypeAWS:
 		return nil, fmt.Errorf("AWS Secrets Manager provider not yet implemented")
 	case ProviderTypeVault:
@@ -67,6 +80,7 @@ func NewProvider(cfg Config) (Provider, error) {
 // Manager manages multiple secret providers with fallback
 type Manager struct {
 	providers []Provider
+	writer    Provider // Receives SetSecret; nil makes the manager read-only
 }
 
 // NewManager creates a new secret manager with multiple providers
@@ -76,6 +90,17 @@ func NewManager(providers ...Provider) *Manager {
 	}
 }
 
+// NewWriteBackManager creates a secret manager that reads from providers in order but
+// sends SetSecret to writer, e.g. so a refreshed token lands in a .env file while
+// environment variables still take precedence for reads. The writer should normally
+// also be one of the providers so stored secrets can be read back.
+func NewWriteBackManager(writer Provider, providers ...Provider) *Manager {
+	return &Manager{
+		providers: providers,
+		writer:    writer,
+	}
+}
+
 // GetSecret retrieves a secret from the first provider that has it
 func (m *Manager) GetSecret(ctx context.Context, key string) (string, error) {
 	var lastErr error
@@ -92,6 +117,14 @@ func (m *Manager) GetSecret(ctx context.Context, key string) (string, error) {
 	return "", fmt.Errorf("secret %q not found in any provider", key)
 }
 
+// SetSecret stores a secret with the write-back provider
+func (m *Manager) SetSecret(ctx context.Context, key, value string) error {
+	if m.writer == nil {
+		return fmt.Errorf("SetSecret not supported: no write-back provider configured")
+	}
+	return m.writer.SetSecret(ctx, key, value)
+}
+
 // Close closes all providers
 func (m *Manager) Close() error {
 	var errs []error
@@ -133,42 +166,77 @@ func (p *EnvProvider) Close() error {
 	return nil
 }
 
-// FileProvider retrieves secrets from .env files
+// FileProvider retrieves secrets from one or more .env files
 type FileProvider struct {
-	filePath string
-	secrets  map[string]string
-	mu       sync.RWMutex
+	filePath    string   // Most specific file, written by SetSecret
+	filePaths   []string // All files in load order
+	secrets     map[string]string
+	fileSecrets map[string]string // Contents of filePath only
+	mu          sync.RWMutex
 }
 
 // NewFileProvider creates a new file-based provider
 func NewFileProvider(filePath string) (*FileProvider, error) {
+	return NewMultiFileProvider(filePath)
+}
+
+// NewMultiFileProvider creates a file-based provider that layers several .env files.
+// Files are loaded in order, with later files overriding keys from earlier ones.
+// SetSecret writes to the last (most specific) file.
+func NewMultiFileProvider(filePaths ...string) (*FileProvider, error) {
+	if len(filePaths) == 0 {
+		return nil, fmt.Errorf("at least one file path is required")
+	}
+
 	p := &FileProvider{
-		filePath: filePath,
-		secrets:  make(map[string]string),
+		filePath:    filePaths[len(filePaths)-1],
+		filePaths:   filePaths,
+		secrets:     make(map[string]string),
+		fileSecrets: make(map[string]string),
 	}
 
-	// Load secrets from file if it exists
 	if err := p.load(); err != nil {
-		// If file doesn't exist, that's okay - we'll create it on first write
-		if !os.IsNotExist(err) {
-			return nil, fmt.Errorf("failed to load secrets from %s: %w", filePath, err)
-		}
+		return nil, err
 	}
 
 	return p, nil
 }
 
-// load reads and parses the .env file
+// load reads and merges all configured .env files
 func (p *FileProvider) load() error {
-	file, err := os.Open(p.filePath)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, path := range p.filePaths {
+		values, err := parseEnvFile(path)
+		if err != nil {
+			// If file doesn't exist, that's okay - we'll create it on first write
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("failed to load secrets from %s: %w", path, err)
+		}
+
+		for key, value := range values {
+			p.secrets[key] = value
+		}
+		if path == p.filePath {
+			p.fileSecrets = values
+		}
+	}
+
+	return nil
+}
+
+// parseEnvFile reads and parses a single .env file
+func parseEnvFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer file.Close()
 
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
+	values := make(map[string]string)
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -190,25 +258,25 @@ func (p *FileProvider) load() error {
 		// Remove quotes if present
 		value = strings.Trim(value, "\"'")
 
-		p.secrets[key] = value
+		values[key] = value
 	}
 
-	return scanner.Err()
+	return values, scanner.Err()
 }
 
-// save writes the secrets back to the file
+// save writes the most specific file's secrets back to disk
 func (p *FileProvider) save() error {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	file, err := os.Create(p.filePath)
+	file, err := os.OpenFile(p.filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
 	defer file.Close()
 
 	writer := bufio.NewWriter(file)
-	for key, value := range p.secrets {
+	for key, value := range p.fileSecrets {
 		if _, err := fmt.Fprintf(writer, "%s=%s\n", key, value); err != nil {
 			return fmt.Errorf("failed to write secret: %w", err)
 		}
@@ -234,6 +302,7 @@ func (p *FileProvider) GetSecret(ctx context.Context, key string) (string, error
 func (p *FileProvider) SetSecret(ctx context.Context, key, value string) error {
 	p.mu.Lock()
 	p.secrets[key] = value
+	p.fileSecrets[key] = value
 	p.mu.Unlock()
 
 	return p.save()
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -371,6 +372,59 @@ func TestManager_MultipleProviders_Fallback(t *testing.T) {
 	}
 }
 
+func TestManager_WriteBack(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), ".env")
+	t.Setenv("WRITE_BACK_TOKEN", "from_env")
+
+	provider, err := NewProvider(Config{
+		Type:    ProviderTypeComposite,
+		Options: map[string]string{"file_path": filePath},
+	})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	defer provider.Close()
+
+	ctx := context.Background()
+	if err := provider.SetSecret(ctx, "WRITE_BACK_TOKEN", "refreshed"); err != nil {
+		t.Fatalf("SetSecret() error = %v", err)
+	}
+
+	// Reads still prefer the environment
+	value, err := provider.GetSecret(ctx, "WRITE_BACK_TOKEN")
+	if err != nil || value != "from_env" {
+		t.Errorf("GetSecret() = %q, %v, want %q", value, err, "from_env")
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(content), "WRITE_BACK_TOKEN=refreshed") {
+		t.Errorf("file content = %q, want the refreshed token", content)
+	}
+	if info, err := os.Stat(filePath); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("file mode = %v (err %v), want 0600", info.Mode().Perm(), err)
+	}
+
+	// Secrets only in the file fall through to it
+	if err := provider.SetSecret(ctx, "FILE_ONLY_SECRET", "stored"); err != nil {
+		t.Fatalf("SetSecret() error = %v", err)
+	}
+	value, err = provider.GetSecret(ctx, "FILE_ONLY_SECRET")
+	if err != nil || value != "stored" {
+		t.Errorf("GetSecret(FILE_ONLY_SECRET) = %q, %v, want %q", value, err, "stored")
+	}
+}
+
+func TestManager_SetSecret_ReadOnly(t *testing.T) {
+	manager := NewManager(NewEnvProvider())
+
+	if err := manager.SetSecret(context.Background(), "KEY", "value"); err == nil {
+		t.Error("SetSecret() expected error without a write-back provider, got nil")
+	}
+}
+
 func TestManager_NotFound(t *testing.T) {
 	envProvider := NewEnvProvider()
 	manager := NewManager(envProvider)
@@ -659,6 +713,63 @@ func TestFileProvider_Persistence(t *testing.T) {
 	}
 }
 
+func TestMultiFileProvider_Precedence(t *testing.T) {
+	tempDir := t.TempDir()
+	basePath := filepath.Join(tempDir, ".env")
+	overridePath := filepath.Join(tempDir, ".env.local")
+
+	if err := os.WriteFile(basePath, []byte("API_KEY=base_key\nBASE_ONLY=base_value\n"), 0600); err != nil {
+		t.Fatalf("Failed to create base file: %v", err)
+	}
+	if err := os.WriteFile(overridePath, []byte("API_KEY=override_key\n"), 0600); err != nil {
+		t.Fatalf("Failed to create override file: %v", err)
+	}
+
+	provider, err := NewMultiFileProvider(basePath, overridePath)
+	if err != nil {
+		t.Fatalf("NewMultiFileProvider() error = %v", err)
+	}
+
+	ctx := context.Background()
+
+	if value, err := provider.GetSecret(ctx, "API_KEY"); err != nil || value != "override_key" {
+		t.Errorf("GetSecret(API_KEY) = %v, %v, want override_key", value, err)
+	}
+	if value, err := provider.GetSecret(ctx, "BASE_ONLY"); err != nil || value != "base_value" {
+		t.Errorf("GetSecret(BASE_ONLY) = %v, %v, want base_value", value, err)
+	}
+
+	// SetSecret should only write to the most specific file
+	if err := provider.SetSecret(ctx, "NEW_KEY", "new_value"); err != nil {
+		t.Fatalf("SetSecret() error = %v", err)
+	}
+
+	overrideContent, err := os.ReadFile(overridePath)
+	if err != nil {
+		t.Fatalf("Failed to read override file: %v", err)
+	}
+	if !strings.Contains(string(overrideContent), "NEW_KEY=new_value") {
+		t.Errorf("override file should contain NEW_KEY, got %q", overrideContent)
+	}
+	if strings.Contains(string(overrideContent), "BASE_ONLY") {
+		t.Errorf("override file should not contain base-only keys, got %q", overrideContent)
+	}
+
+	baseContent, err := os.ReadFile(basePath)
+	if err != nil {
+		t.Fatalf("Failed to read base file: %v", err)
+	}
+	if strings.Contains(string(baseContent), "NEW_KEY") {
+		t.Errorf("base file should not be modified, got %q", baseContent)
+	}
+}
+
+func TestMultiFileProvider_NoPaths(t *testing.T) {
+	if _, err := NewMultiFileProvider(); err == nil {
+		t.Error("NewMultiFileProvider() with no paths should return error")
+	}
+}
+
 func TestFileProvider_ContextCancellation(t *testing.T) {
 	tempDir := t.TempDir()
 	filePath := filepath.Join(tempDir, ".env")
@@ -715,6 +826,7 @@ func TestProviderType_String(t *testing.T) {
 		{"aws", ProviderTypeAWS, "aws"},
 		{"vault", ProviderTypeVault, "vault"},
 		{"k8s", ProviderTypeK8s, "k8s"},
+		{"composite", ProviderTypeComposite, "composite"},
 	}
 
 	for _, tt := range tests {
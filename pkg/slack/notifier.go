@@ -2,20 +2,46 @@ package slack
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/sony/gobreaker"
+	"github.com/soothill/octopus-home-mini/pkg/notify"
+)
+
+// DefaultMaxTextLength is the default cap, in characters, on message and field text.
+// Slack rejects or cuts off attachments with much longer text.
+const DefaultMaxTextLength = 3000
+
+// Notifier implements notify.Notifier and notify.CircuitTripper
+var (
+	_ notify.Notifier       = (*Notifier)(nil)
+	_ notify.CircuitTripper = (*Notifier)(nil)
 )
 
 // Notifier handles sending alerts to Slack
 type Notifier struct {
-	webhookURL     string
-	httpClient     *http.Client
-	circuitBreaker *gobreaker.CircuitBreaker
+	webhookURL      string
+	errorWebhookURL string // Overrides webhookURL for errors when set
+	infoWebhookURL  string // Overrides webhookURL for info messages when set
+	httpClient      *http.Client
+	circuitBreaker  *gobreaker.CircuitBreaker
+	circuitTrips    atomic.Int64 // Times the circuit breaker has opened
+	maxTextLength   int          // Characters of text kept per message or field; 0 keeps all
+
+	// Result of the most recent webhook check - protected by checkMu
+	checkMu      sync.Mutex
+	lastCheck    time.Time
+	lastCheckErr error
 }
 
 // Message represents a Slack message payload
@@ -43,6 +69,14 @@ type Field struct {
 
 // NewNotifier creates a new Slack notifier
 func NewNotifier(webhookURL string) *Notifier {
+	n := &Notifier{
+		webhookURL:    webhookURL,
+		maxTextLength: DefaultMaxTextLength,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+
 	// Configure circuit breaker
 	cbSettings := gobreaker.Settings{
 		Name:        "Slack",
@@ -53,15 +87,80 @@ func NewNotifier(webhookURL string) *Notifier {
 			failureRatio := float64(counts.TotalFailures) / float64(counts.Requests)
 			return counts.Requests >= 3 && failureRatio >= 0.6
 		},
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			if to == gobreaker.StateOpen {
+				n.circuitTrips.Add(1)
+			}
+		},
 	}
+	n.circuitBreaker = gobreaker.NewCircuitBreaker(cbSettings)
 
-	return &Notifier{
-		webhookURL: webhookURL,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		circuitBreaker: gobreaker.NewCircuitBreaker(cbSettings),
+	return n
+}
+
+// CircuitTrips returns how many times the circuit breaker has opened
+func (n *Notifier) CircuitTrips() int64 {
+	return n.circuitTrips.Load()
+}
+
+// SetProxy routes webhook requests through the given HTTP(S) or SOCKS5 proxy
+func (n *Notifier) SetProxy(proxyURL *url.URL) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxyURL)
+	n.httpClient.Transport = transport
+}
+
+// SetSeverityWebhooks routes errors and info messages to their own webhooks.
+// An empty URL sends that severity to the default webhook.
+func (n *Notifier) SetSeverityWebhooks(errorURL, infoURL string) {
+	n.errorWebhookURL = errorURL
+	n.infoWebhookURL = infoURL
+}
+
+// SetMaxTextLength caps the characters of text sent per message, attachment text or
+// field value; longer text keeps its beginning and ends in an ellipsis. 0 removes the cap.
+func (n *Notifier) SetMaxTextLength(length int) {
+	n.maxTextLength = length
+}
+
+// truncate caps the text in msg at maxTextLength characters. Callers redact messages
+// before sending, so this only trims already-safe text.
+func (n *Notifier) truncate(msg Message) Message {
+	if n.maxTextLength <= 0 {
+		return msg
+	}
+	msg.Text = truncateText(msg.Text, n.maxTextLength)
+	attachments := make([]Attachment, len(msg.Attachments))
+	for i, attachment := range msg.Attachments {
+		attachment.Title = truncateText(attachment.Title, n.maxTextLength)
+		attachment.Text = truncateText(attachment.Text, n.maxTextLength)
+		fields := make([]Field, len(attachment.Fields))
+		for j, field := range attachment.Fields {
+			field.Value = truncateText(field.Value, n.maxTextLength)
+			fields[j] = field
+		}
+		attachment.Fields = fields
+		attachments[i] = attachment
+	}
+	msg.Attachments = attachments
+	return msg
+}
+
+// truncateText shortens text to at most limit characters, ending in an ellipsis when cut
+func truncateText(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
 	}
+	runes := []rune(text)
+	return string(runes[:limit-1]) + "…"
+}
+
+// webhookOr returns override if set, otherwise the default webhook URL
+func (n *Notifier) webhookOr(override string) string {
+	if override != "" {
+		return override
+	}
+	return n.webhookURL
 }
 
 // SendError sends an error notification to Slack
@@ -90,7 +189,7 @@ func (n *Notifier) SendError(component, errorMsg string) error {
 		},
 	}
 
-	return n.send(msg)
+	return n.send(n.webhookOr(n.errorWebhookURL), msg)
 }
 
 // SendWarning sends a warning notification to Slack
@@ -119,7 +218,7 @@ func (n *Notifier) SendWarning(component, warningMsg string) error {
 		},
 	}
 
-	return n.send(msg)
+	return n.send(n.webhookURL, msg)
 }
 
 // SendInfo sends an informational notification to Slack
@@ -143,7 +242,7 @@ func (n *Notifier) SendInfo(title, message string) error {
 		},
 	}
 
-	return n.send(msg)
+	return n.send(n.webhookOr(n.infoWebhookURL), msg)
 }
 
 // SendCacheAlert sends an alert about cached data
@@ -177,28 +276,68 @@ func (n *Notifier) SendCacheAlert(count int, action string) error {
 		},
 	}
 
-	return n.send(msg)
+	return n.send(n.webhookURL, msg)
+}
+
+// CheckWebhook verifies the webhook accepts a minimal message. Because each check posts
+// to the channel, the result is reused until it is older than maxAge.
+func (n *Notifier) CheckWebhook(ctx context.Context, maxAge time.Duration) error {
+	n.checkMu.Lock()
+	defer n.checkMu.Unlock()
+
+	if !n.lastCheck.IsZero() && time.Since(n.lastCheck) < maxAge {
+		return n.lastCheckErr
+	}
+
+	n.lastCheckErr = n.postCheck(ctx)
+	n.lastCheck = time.Now()
+	return n.lastCheckErr
 }
 
-// send sends a message to Slack via webhook with exponential backoff retry and circuit breaker
-func (n *Notifier) send(msg Message) error {
+// postCheck sends a single connectivity check message, bypassing retries and the circuit breaker
+func (n *Notifier) postCheck(ctx context.Context) error {
+	payload, err := json.Marshal(Message{Text: "Octopus Home Mini Monitor: webhook connectivity check"})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook check request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Slack webhook: %w", withoutURL(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack webhook returned status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// send sends a message to a Slack webhook with exponential backoff retry and circuit breaker
+func (n *Notifier) send(webhookURL string, msg Message) error {
 	_, err := n.circuitBreaker.Execute(func() (interface{}, error) {
-		return nil, n.sendWithRetry(msg)
+		return nil, n.sendWithRetry(webhookURL, msg)
 	})
 	return err
 }
 
 // sendWithRetry performs the actual send operation with retry logic
-func (n *Notifier) sendWithRetry(msg Message) error {
-	payload, err := json.Marshal(msg)
+func (n *Notifier) sendWithRetry(webhookURL string, msg Message) error {
+	payload, err := json.Marshal(n.truncate(msg))
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
 	operation := func() error {
-		resp, err := n.httpClient.Post(n.webhookURL, "application/json", bytes.NewBuffer(payload))
+		resp, err := n.httpClient.Post(webhookURL, "application/json", bytes.NewBuffer(payload))
 		if err != nil {
-			return fmt.Errorf("failed to send message to Slack: %w", err)
+			return fmt.Errorf("failed to send message to Slack: %w", withoutURL(err))
 		}
 		defer resp.Body.Close()
 
@@ -221,6 +360,16 @@ func (n *Notifier) sendWithRetry(msg Message) error {
 	return backoff.Retry(operation, b)
 }
 
+// withoutURL strips the request URL from a transport error: the webhook URL is itself
+// the credential, so it must not end up in logs or alerts
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
 // Close closes idle connections in the HTTP client
 func (n *Notifier) Close() {
 	if n.httpClient != nil {
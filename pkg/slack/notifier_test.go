@@ -1,10 +1,19 @@
 package slack
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/soothill/octopus-home-mini/pkg/health"
 )
 
 func TestNewNotifier(t *testing.T) {
@@ -113,6 +122,124 @@ func TestNotifier_SendWarning(t *testing.T) {
 	}
 }
 
+func TestNotifier_SetProxy(t *testing.T) {
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.Host
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatalf("failed to parse proxy URL: %v", err)
+	}
+
+	notifier := NewNotifier("http://hooks.slack.invalid/services/test")
+	notifier.SetProxy(proxyURL)
+
+	if err := notifier.SendWarning("TestComponent", "Test warning"); err != nil {
+		t.Fatalf("SendWarning() via proxy unexpected error = %v", err)
+	}
+
+	if proxiedHost != "hooks.slack.invalid" {
+		t.Errorf("proxy received request for host %q, want %q", proxiedHost, "hooks.slack.invalid")
+	}
+}
+
+func TestNotifier_SeverityWebhooks(t *testing.T) {
+	var mu sync.Mutex
+	received := make(map[string][]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg Message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("failed to decode message: %v", err)
+		}
+		mu.Lock()
+		received[r.URL.Path] = append(received[r.URL.Path], msg.Attachments[0].Color)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier := NewNotifier(server.URL + "/default")
+	notifier.SetSeverityWebhooks(server.URL+"/error", server.URL+"/info")
+
+	if err := notifier.SendError("Octopus", "fetch failed"); err != nil {
+		t.Fatalf("SendError() error = %v", err)
+	}
+	if err := notifier.SendInfo("Monitor Started", "started"); err != nil {
+		t.Fatalf("SendInfo() error = %v", err)
+	}
+	if err := notifier.SendWarning("Cache", "growing"); err != nil {
+		t.Fatalf("SendWarning() error = %v", err)
+	}
+
+	mu.Lock()
+	want := map[string][]string{
+		"/error":   {"danger"},
+		"/info":    {"good"},
+		"/default": {"warning"},
+	}
+	for path, colors := range want {
+		if len(received[path]) != 1 || received[path][0] != colors[0] {
+			t.Errorf("%s received %v, want %v", path, received[path], colors)
+		}
+	}
+	mu.Unlock()
+
+	// Unset severities fall back to the default webhook
+	notifier.SetSeverityWebhooks("", "")
+	if err := notifier.SendError("Octopus", "fetch failed"); err != nil {
+		t.Fatalf("SendError() error = %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received["/default"]) != 2 {
+		t.Errorf("/default received %v after clearing overrides, want the error too", received["/default"])
+	}
+}
+
+func TestNotifier_CheckWebhook_Degraded(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	notifier := NewNotifier(server.URL)
+	checker := health.DegradedChecker("Slack", func(ctx context.Context) error {
+		return notifier.CheckWebhook(ctx, time.Hour)
+	})
+
+	result := checker(context.Background())
+	if result.Status != health.StatusDegraded {
+		t.Errorf("status = %v, want %v", result.Status, health.StatusDegraded)
+	}
+	if !strings.Contains(result.Message, "404") {
+		t.Errorf("message = %q, want it to mention the 404 status", result.Message)
+	}
+
+	// The cached result is reused rather than posting again
+	checker(context.Background())
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("webhook requests = %d, want 1 within maxAge", got)
+	}
+}
+
+func TestNotifier_CheckWebhook_Healthy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier := NewNotifier(server.URL)
+	if err := notifier.CheckWebhook(context.Background(), time.Hour); err != nil {
+		t.Errorf("CheckWebhook() unexpected error = %v", err)
+	}
+}
+
 func TestNotifier_SendInfo(t *testing.T) {
 	var receivedBody string
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -140,6 +267,45 @@ func TestNotifier_SendInfo(t *testing.T) {
 	}
 }
 
+func TestNotifier_TruncatesLongMessages(t *testing.T) {
+	var received Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier := NewNotifier(server.URL)
+	notifier.SetMaxTextLength(100)
+
+	long := "InfluxDB write failed: " + strings.Repeat("stack frame ", 1000)
+	if err := notifier.SendError("InfluxDB", long); err != nil {
+		t.Fatalf("SendError() unexpected error = %v", err)
+	}
+
+	if len(received.Attachments) != 1 {
+		t.Fatalf("received %d attachments, want 1", len(received.Attachments))
+	}
+	text := received.Attachments[0].Text
+	if got := utf8.RuneCountInString(text); got != 100 {
+		t.Errorf("text length = %d characters, want 100", got)
+	}
+	if !strings.HasPrefix(text, "InfluxDB write failed: stack frame") {
+		t.Errorf("text = %q, want the beginning of the message kept", text)
+	}
+	if !strings.HasSuffix(text, "…") {
+		t.Errorf("text = %q, want an ellipsis", text)
+	}
+
+	// Short text is sent as it is
+	if err := notifier.SendError("InfluxDB", "short"); err != nil {
+		t.Fatalf("SendError() unexpected error = %v", err)
+	}
+	if received.Attachments[0].Text != "short" {
+		t.Errorf("short text = %q, want %q", received.Attachments[0].Text, "short")
+	}
+}
+
 func TestNotifier_SendCacheAlert(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -199,6 +365,24 @@ func TestNotifier_NetworkError(t *testing.T) {
 	}
 }
 
+func TestNotifier_CircuitTrips(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	notifier := NewNotifier(server.URL)
+	for i := 0; i < 3; i++ {
+		if err := notifier.SendInfo("Test", "message"); err == nil {
+			t.Fatalf("SendInfo() %d error = nil, want client error", i+1)
+		}
+	}
+
+	if got := notifier.CircuitTrips(); got != 1 {
+		t.Errorf("CircuitTrips() = %d after 3 failures, want 1", got)
+	}
+}
+
 func TestNotifier_InvalidJSON(t *testing.T) {
 	// This test ensures the JSON marshaling works correctly
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
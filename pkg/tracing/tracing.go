@@ -0,0 +1,45 @@
+// Package tracing configures optional OpenTelemetry tracing of poll cycles
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// ServiceName identifies this application in exported traces
+const ServiceName = "octopus-home-mini"
+
+// ShutdownFunc flushes buffered spans and stops the exporter
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup installs a global tracer provider that exports spans over OTLP/HTTP. The
+// collector is configured with the standard OTEL_EXPORTER_OTLP_* environment variables
+// (e.g. OTEL_EXPORTER_OTLP_ENDPOINT). When disabled, the global no-op provider is left
+// in place and the returned ShutdownFunc does nothing.
+func Setup(ctx context.Context, enabled bool, version string) (ShutdownFunc, error) {
+	if !enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", ServiceName),
+			attribute.String("service.version", version),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,26 @@
+package version
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// Build metadata, injected at build time with:
+//
+//	-ldflags "-X github.com/soothill/octopus-home-mini/pkg/version.Version=v1.2.3
+//	          -X github.com/soothill/octopus-home-mini/pkg/version.Commit=abc1234
+//	          -X github.com/soothill/octopus-home-mini/pkg/version.BuildDate=2024-01-01T00:00:00Z"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
+
+// Print writes the build metadata in a human-readable form
+func Print(w io.Writer) {
+	fmt.Fprintf(w, "octopus-monitor %s\n", Version)
+	fmt.Fprintf(w, "  commit:     %s\n", Commit)
+	fmt.Fprintf(w, "  built:      %s\n", BuildDate)
+	fmt.Fprintf(w, "  go version: %s\n", runtime.Version())
+}
@@ -0,0 +1,34 @@
+package version
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrint_IncludesInjectedValues(t *testing.T) {
+	origVersion, origCommit, origBuildDate := Version, Commit, BuildDate
+	defer func() {
+		Version, Commit, BuildDate = origVersion, origCommit, origBuildDate
+	}()
+
+	Version = "v1.2.3"
+	Commit = "abc1234"
+	BuildDate = "2024-01-01T00:00:00Z"
+
+	var buf bytes.Buffer
+	Print(&buf)
+
+	output := buf.String()
+	for _, want := range []string{"v1.2.3", "abc1234", "2024-01-01T00:00:00Z"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Print() output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	if Version == "" || Commit == "" || BuildDate == "" {
+		t.Errorf("build metadata defaults must not be empty: %q %q %q", Version, Commit, BuildDate)
+	}
+}
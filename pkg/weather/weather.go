@@ -0,0 +1,116 @@
+// Package weather fetches outdoor temperature so consumption can be correlated with it
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Supported providers
+const (
+	ProviderOpenWeatherMap = "openweathermap"
+)
+
+const openWeatherMapEndpoint = "https://api.openweathermap.org/data/2.5/weather"
+
+// Reading is the outdoor temperature observed at a location
+type Reading struct {
+	Time         time.Time
+	TemperatureC float64
+}
+
+// Provider fetches the current outdoor temperature from a weather API
+type Provider interface {
+	CurrentTemperature(ctx context.Context) (Reading, error)
+}
+
+// NewProvider creates the named provider for the location at latitude, longitude
+func NewProvider(name, apiKey string, latitude, longitude float64) (Provider, error) {
+	switch name {
+	case ProviderOpenWeatherMap:
+		return NewOpenWeatherMap(apiKey, latitude, longitude), nil
+	default:
+		return nil, fmt.Errorf("unsupported weather provider: %s", name)
+	}
+}
+
+// OpenWeatherMap reads the current temperature from the OpenWeatherMap current weather API
+type OpenWeatherMap struct {
+	apiKey     string
+	latitude   float64
+	longitude  float64
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewOpenWeatherMap creates an OpenWeatherMap provider
+func NewOpenWeatherMap(apiKey string, latitude, longitude float64) *OpenWeatherMap {
+	return NewOpenWeatherMapWithEndpoint(apiKey, latitude, longitude, openWeatherMapEndpoint)
+}
+
+// NewOpenWeatherMapWithEndpoint creates an OpenWeatherMap provider with a specific endpoint
+func NewOpenWeatherMapWithEndpoint(apiKey string, latitude, longitude float64, endpoint string) *OpenWeatherMap {
+	return &OpenWeatherMap{
+		apiKey:    apiKey,
+		latitude:  latitude,
+		longitude: longitude,
+		endpoint:  endpoint,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// CurrentTemperature returns the latest observation for the configured location
+func (p *OpenWeatherMap) CurrentTemperature(ctx context.Context) (Reading, error) {
+	query := url.Values{}
+	query.Set("lat", strconv.FormatFloat(p.latitude, 'f', -1, 64))
+	query.Set("lon", strconv.FormatFloat(p.longitude, 'f', -1, 64))
+	query.Set("units", "metric")
+	query.Set("appid", p.apiKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return Reading{}, fmt.Errorf("failed to create weather request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		// The request URL carries the API key, so leave it out of the error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return Reading{}, fmt.Errorf("failed to fetch weather: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Reading{}, fmt.Errorf("weather API returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Dt   int64 `json:"dt"`
+		Main *struct {
+			Temp float64 `json:"temp"`
+		} `json:"main"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Reading{}, fmt.Errorf("failed to decode weather response: %w", err)
+	}
+	if body.Main == nil {
+		return Reading{}, fmt.Errorf("weather response has no temperature")
+	}
+
+	observed := time.Now()
+	if body.Dt > 0 {
+		observed = time.Unix(body.Dt, 0)
+	}
+	return Reading{Time: observed.UTC(), TemperatureC: body.Main.Temp}, nil
+}
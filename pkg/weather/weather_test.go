@@ -0,0 +1,82 @@
+package weather
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenWeatherMap_CurrentTemperature(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("lat") != "51.5072" || query.Get("lon") != "-0.1276" || query.Get("units") != "metric" || query.Get("appid") != "test_key" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"dt": 1700000000, "main": {"temp": 8.25, "humidity": 81}}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenWeatherMapWithEndpoint("test_key", 51.5072, -0.1276, server.URL)
+
+	reading, err := provider.CurrentTemperature(context.Background())
+	if err != nil {
+		t.Fatalf("CurrentTemperature() error = %v", err)
+	}
+	if reading.TemperatureC != 8.25 {
+		t.Errorf("TemperatureC = %v, want 8.25", reading.TemperatureC)
+	}
+	if !reading.Time.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Time = %v, want the observation time", reading.Time)
+	}
+}
+
+func TestOpenWeatherMap_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"unauthorized", http.StatusUnauthorized, `{"cod": 401}`, "status 401"},
+		{"no temperature", http.StatusOK, `{"dt": 1700000000}`, "no temperature"},
+		{"malformed", http.StatusOK, `not json`, "decode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			provider := NewOpenWeatherMapWithEndpoint("test_key", 0, 0, server.URL)
+			_, err := provider.CurrentTemperature(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CurrentTemperature() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Connection errors must not leak the API key from the request URL
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	provider := NewOpenWeatherMapWithEndpoint("secret_weather_key", 0, 0, server.URL)
+	_, err := provider.CurrentTemperature(context.Background())
+	if err == nil || strings.Contains(err.Error(), "secret_weather_key") {
+		t.Errorf("CurrentTemperature() error = %v, want an error without the API key", err)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	if _, err := NewProvider(ProviderOpenWeatherMap, "key", 0, 0); err != nil {
+		t.Errorf("NewProvider(openweathermap) error = %v", err)
+	}
+	if _, err := NewProvider("unknown", "key", 0, 0); err == nil {
+		t.Error("NewProvider(unknown) error = nil, want unsupported provider error")
+	}
+}
@@ -2,6 +2,8 @@ package integration
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"testing"
 	"time"
 
@@ -127,22 +129,105 @@ func TestInfluxDBBatchWrites(t *testing.T) {
 	// Create large batch
 	testData := CreateInfluxDataPoints(100)
 
-	// Write batch
-	err = influxClient.WriteDataPoints(testData)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Write batch synchronously so delivery failures are reported
+	err = influxClient.WriteDataPointsBlocking(ctx, testData)
 	if err != nil {
-		t.Errorf("WriteDataPoints failed: %v", err)
+		t.Errorf("WriteDataPointsBlocking failed: %v", err)
 	}
 
-	influxClient.Flush()
-	time.Sleep(2 * time.Second)
-
 	// Verify connection is still healthy after batch write
+	err = influxClient.CheckConnection(ctx)
+	if err != nil {
+		t.Errorf("InfluxDB connection check failed after batch write: %v", err)
+	}
+}
+
+// TestInfluxDBBatchWritesReportsBadPoint tests that the blocking bulk write returns an error
+// for a point that cannot be written, while still delivering the valid points
+func TestInfluxDBBatchWritesReportsBadPoint(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	cfg := NewTestConfig(t)
+	SkipIfNoInfluxDB(t, cfg)
+	defer CleanupInfluxDB(t, cfg)
+
+	influxClient, err := influx.NewClient(cfg.InfluxDBURL, cfg.InfluxDBToken, cfg.InfluxDBOrg, cfg.InfluxDBBucket, cfg.InfluxDBMeasurement)
+	if err != nil {
+		t.Fatalf("Failed to create InfluxDB client: %v", err)
+	}
+	defer influxClient.Close()
+
+	testData := CreateInfluxDataPoints(5)
+	testData[2] = influx.DataPoint{
+		Timestamp:        testData[2].Timestamp,
+		ConsumptionDelta: math.NaN(),
+		Demand:           math.NaN(),
+		CostDelta:        math.NaN(),
+		Consumption:      math.NaN(),
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	err = influxClient.CheckConnection(ctx)
+	err = influxClient.WriteDataPointsBlocking(ctx, testData)
+	if err == nil {
+		t.Fatal("WriteDataPointsBlocking should return an error for a point with no valid fields")
+	}
+
+	count, err := influxClient.CountPoints(ctx, testData[0].Timestamp, testData[len(testData)-1].Timestamp)
 	if err != nil {
-		t.Errorf("InfluxDB connection check failed after batch write: %v", err)
+		t.Fatalf("CountPoints failed: %v", err)
+	}
+	if count != 4 {
+		t.Errorf("Points stored = %d, want the 4 valid points", count)
+	}
+}
+
+// TestInfluxDBQueryRecent tests reading back aggregates of written points
+func TestInfluxDBQueryRecent(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	cfg := NewTestConfig(t)
+	SkipIfNoInfluxDB(t, cfg)
+	defer CleanupInfluxDB(t, cfg)
+
+	// A measurement of its own keeps points from other tests out of the aggregates
+	measurement := fmt.Sprintf("query_recent_%d", time.Now().UnixNano())
+	influxClient, err := influx.NewClient(cfg.InfluxDBURL, cfg.InfluxDBToken, cfg.InfluxDBOrg, cfg.InfluxDBBucket, measurement)
+	if err != nil {
+		t.Fatalf("Failed to create InfluxDB client: %v", err)
+	}
+	defer influxClient.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	testData := CreateInfluxDataPoints(5)
+	if err := influxClient.WriteDataPointsBlocking(ctx, testData); err != nil {
+		t.Fatalf("WriteDataPointsBlocking failed: %v", err)
+	}
+
+	summary, err := influxClient.QueryRecent(ctx, "", 2*time.Hour)
+	if err != nil {
+		t.Fatalf("QueryRecent failed: %v", err)
+	}
+
+	// CreateInfluxDataPoints uses deltas of i*0.1 kWh and i*0.05 cost and demand of i*0.2
+	if summary.Points != 5 {
+		t.Errorf("Points = %d, want 5", summary.Points)
+	}
+	if math.Abs(summary.ConsumptionDelta-1.0) > 1e-9 || math.Abs(summary.CostDelta-0.5) > 1e-9 {
+		t.Errorf("ConsumptionDelta = %v, CostDelta = %v, want 1.0 and 0.5", summary.ConsumptionDelta, summary.CostDelta)
+	}
+	if math.Abs(summary.DemandMax-0.8) > 1e-9 {
+		t.Errorf("DemandMax = %v, want 0.8", summary.DemandMax)
 	}
 }
 